@@ -3,6 +3,7 @@ package main
 import (
 	"discord-event-bot/config"
 	"discord-event-bot/internal/discord"
+	backupsvc "discord-event-bot/internal/services/backups"
 	"discord-event-bot/internal/storage"
 	"discord-event-bot/internal/web"
 	"log"
@@ -19,6 +20,12 @@ func main() {
 		log.Fatalf("Error cargando configuración: %v", err)
 	}
 
+	// Seleccionar backend de almacenamiento (archivos JSON o SQLite)
+	if err := storage.InitBackend(config.AppConfig.StorageBackend, config.AppConfig.SQLitePath); err != nil {
+		log.Fatalf("Error inicializando backend de almacenamiento: %v", err)
+	}
+	defer storage.CloseBackend()
+
 	// Inicializar almacenamiento
 	if err := storage.InitEventStore(); err != nil {
 		log.Fatalf("Error inicializando almacenamiento: %v", err)
@@ -29,6 +36,38 @@ func main() {
 		log.Fatalf("Error inicializando templates: %v", err)
 	}
 
+	// Inicializar tokens de la API
+	if err := storage.InitTokenStore(); err != nil {
+		log.Fatalf("Error inicializando tokens: %v", err)
+	}
+
+	if err := storage.InitProfileStore(); err != nil {
+		log.Fatalf("Error inicializando perfiles: %v", err)
+	}
+
+	if err := storage.InitNotificationStore(); err != nil {
+		log.Fatalf("Error inicializando notificaciones: %v", err)
+	}
+
+	// Configuración por servidor de Discord
+	if err := storage.InitGuildStore(); err != nil {
+		log.Fatalf("Error inicializando servidores: %v", err)
+	}
+
+	// Accesos a los calendarios iCal
+	if err := storage.InitCalendarStore(); err != nil {
+		log.Fatalf("Error inicializando calendarios: %v", err)
+	}
+
+	// Iniciar backups programados de eventos y templates
+	if err := backupsvc.Start(backupsvc.Config{
+		Dir:       config.AppConfig.BackupDir,
+		Schedule:  config.AppConfig.BackupSchedule,
+		Retention: config.AppConfig.BackupRetention,
+	}); err != nil {
+		log.Fatalf("Error inicializando backups: %v", err)
+	}
+
 	// Inicializar bot de Discord
 	if err := discord.InitBot(); err != nil {
 		log.Fatalf("Error inicializando bot de Discord: %v", err)
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"discord-event-bot/internal/storage"
+	"flag"
+	"log"
+	"os"
+)
+
+// Comando de un solo uso para importar los datos del backend JSON
+// (data/events, data/templates, tokens, perfiles, preferencias, servidores
+// y calendarios) a una base SQLite. Los tokens, perfiles, preferencias,
+// servidores y calendarios siempre se leen de data/ dentro de la carpeta del
+// bot, igual que los lee el bot; -dir indica cuál es esa carpeta.
+func main() {
+	botDir := flag.String("dir", ".", "Carpeta del bot; el resto de las rutas relativas parten de ella")
+	eventsDir := flag.String("events", "data/events", "Directorio con los eventos en JSON")
+	templatesDir := flag.String("templates", "data/templates", "Directorio con los templates en JSON/YAML")
+	dbPath := flag.String("db", "data/bot.db", "Ruta de la base SQLite de destino")
+	flag.Parse()
+
+	if err := os.Chdir(*botDir); err != nil {
+		log.Fatalf("Error entrando a la carpeta del bot: %v", err)
+	}
+
+	source, err := storage.NewJSONBackend(*eventsDir, *templatesDir)
+	if err != nil {
+		log.Fatalf("Error abriendo datos JSON: %v", err)
+	}
+
+	target, err := storage.NewSQLiteBackend(*dbPath)
+	if err != nil {
+		log.Fatalf("Error abriendo base SQLite: %v", err)
+	}
+	defer target.Close()
+
+	events, err := source.LoadEvents()
+	if err != nil {
+		log.Fatalf("Error leyendo eventos: %v", err)
+	}
+	for _, event := range events {
+		if err := target.SaveEvent(event); err != nil {
+			log.Fatalf("Error importando evento %s: %v", event.ID, err)
+		}
+	}
+	log.Printf("📦 Importados %d eventos", len(events))
+
+	templates, err := source.LoadTemplates()
+	if err != nil {
+		log.Fatalf("Error leyendo templates: %v", err)
+	}
+	for _, template := range templates {
+		if err := target.SaveTemplate(template, storage.TemplateFormatJSON); err != nil {
+			log.Fatalf("Error importando template %s: %v", template.Name, err)
+		}
+	}
+	log.Printf("📦 Importados %d templates", len(templates))
+
+	tokens, err := source.LoadTokens()
+	if err != nil {
+		log.Fatalf("Error leyendo tokens: %v", err)
+	}
+	for _, token := range tokens {
+		if err := target.SaveToken(token); err != nil {
+			log.Fatalf("Error importando token %s: %v", token.Name, err)
+		}
+	}
+	log.Printf("📦 Importados %d tokens", len(tokens))
+
+	profiles, err := source.LoadProfiles()
+	if err != nil {
+		log.Fatalf("Error leyendo perfiles: %v", err)
+	}
+	for _, profile := range profiles {
+		if err := target.SaveProfile(profile); err != nil {
+			log.Fatalf("Error importando perfil %s: %v", profile.UserID, err)
+		}
+	}
+	log.Printf("📦 Importados %d perfiles", len(profiles))
+
+	prefs, err := source.LoadNotificationPrefs()
+	if err != nil {
+		log.Fatalf("Error leyendo preferencias: %v", err)
+	}
+	for _, p := range prefs {
+		if err := target.SaveNotificationPrefs(p); err != nil {
+			log.Fatalf("Error importando preferencias de %s: %v", p.UserID, err)
+		}
+	}
+	log.Printf("📦 Importadas %d preferencias de notificación", len(prefs))
+
+	guilds, err := source.LoadGuildSettings()
+	if err != nil {
+		log.Fatalf("Error leyendo servidores: %v", err)
+	}
+	for _, settings := range guilds {
+		if err := target.SaveGuildSettings(settings); err != nil {
+			log.Fatalf("Error importando servidor %s: %v", settings.GuildID, err)
+		}
+	}
+	log.Printf("📦 Importados %d servidores", len(guilds))
+
+	feeds, err := source.LoadCalendarFeeds()
+	if err != nil {
+		log.Fatalf("Error leyendo calendarios: %v", err)
+	}
+	for _, feed := range feeds {
+		if err := target.SaveCalendarFeed(feed); err != nil {
+			log.Fatalf("Error importando calendario de %s: %v", feed.UserID, err)
+		}
+	}
+	log.Printf("📦 Importados %d calendarios", len(feeds))
+
+	log.Printf("✅ Migración completa. Configura STORAGE_BACKEND=sqlite y SQLITE_PATH=%s para usarla", *dbPath)
+}
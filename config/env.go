@@ -5,21 +5,56 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 // Config contiene toda la configuración del bot
 type Config struct {
-	DiscordToken          string
-	GuildID               string
-	AdminUser             string
-	AdminPass             string
-	Port                  string
-	Timezone              string
-	DefaultRoles          []Role
-	EnableDiscordEvents   bool
-	ReminderOffsetMinutes int
+	DiscordToken             string
+	GuildID                  string
+	GuildIDs                 []string // servidores permitidos además de GuildID; vacío = todos
+	AdminUser                string
+	AdminPass                string
+	Port                     string
+	PublicURL                string // URL con la que se llega al panel desde afuera (feeds de calendario)
+	Timezone                 string
+	DefaultRoles             []Role
+	EnableDiscordEvents      bool
+	ReminderOffsetMinutes    int
+	StorageBackend           string
+	SQLitePath               string
+	BackupDir                string
+	BackupSchedule           string
+	BackupRetention          int
+	AttendanceVoiceChannelID string
+	FillInLeadMinutes        int               // 0 = sin llamado a completar roles
+	FillInRolePings          map[string]string // rol del juego -> ID de rol de Discord
+	ImportDiscordEvents      bool              // copiar los eventos creados desde Discord
+	ImportEventsChannelID    string            // canal donde se publican los eventos importados
+	ImportInterestedSignups  bool              // "me interesa" = inscripción pendiente
+	OAuth                    OAuthConfig
+}
+
+// OAuthConfig configura el login con Discord del panel web. Las URLs son
+// configurables para poder usar un proveedor local de prueba sin conexión.
+type OAuthConfig struct {
+	ClientID      string
+	ClientSecret  string
+	RedirectURL   string
+	AuthorizeURL  string
+	TokenURL      string
+	APIBaseURL    string
+	SessionSecret string
+	AdminRoles    []string
+	OfficerRoles  []string
+	ViewerRoles   []string
+}
+
+// Enabled indica si el login con Discord está configurado
+func (o OAuthConfig) Enabled() bool {
+	return o.ClientID != "" && o.ClientSecret != "" && o.RedirectURL != ""
 }
 
 // Role representa un rol/clase del MMO
@@ -39,14 +74,39 @@ func LoadConfig() error {
 	}
 
 	config := &Config{
-		DiscordToken:          getEnv("DISCORD_TOKEN", ""),
-		GuildID:               getEnv("GUILD_ID", ""),
-		AdminUser:             getEnv("ADMIN_USER", "admin"),
-		AdminPass:             getEnv("ADMIN_PASS", "admin123"),
-		Port:                  getEnv("PORT", "8080"),
-		Timezone:              getEnv("TIMEZONE", "America/Argentina/Buenos_Aires"),
-		EnableDiscordEvents:   getEnvAsBool("ENABLE_DISCORD_EVENTS", true),
-		ReminderOffsetMinutes: getEnvAsInt("REMINDER_OFFSET_MINUTES", 15),
+		DiscordToken:             getEnv("DISCORD_TOKEN", ""),
+		GuildID:                  getEnv("GUILD_ID", ""),
+		GuildIDs:                 getEnvAsList("GUILD_IDS"),
+		AdminUser:                getEnv("ADMIN_USER", "admin"),
+		AdminPass:                getEnv("ADMIN_PASS", "admin123"),
+		Port:                     getEnv("PORT", "8080"),
+		PublicURL:                strings.TrimSuffix(getEnv("PUBLIC_URL", ""), "/"),
+		Timezone:                 getEnv("TIMEZONE", "America/Argentina/Buenos_Aires"),
+		EnableDiscordEvents:      getEnvAsBool("ENABLE_DISCORD_EVENTS", true),
+		ReminderOffsetMinutes:    getEnvAsInt("REMINDER_OFFSET_MINUTES", 15),
+		StorageBackend:           getEnv("STORAGE_BACKEND", "json"),
+		SQLitePath:               getEnv("SQLITE_PATH", "data/bot.db"),
+		BackupDir:                getEnv("BACKUP_DIR", "data/backups"),
+		BackupSchedule:           getEnv("BACKUP_SCHEDULE", "@daily"),
+		BackupRetention:          getEnvAsInt("BACKUP_RETENTION", 7),
+		AttendanceVoiceChannelID: getEnv("ATTENDANCE_VOICE_CHANNEL", ""),
+		FillInLeadMinutes:        getEnvAsInt("FILL_IN_LEAD_MINUTES", 0),
+		FillInRolePings:          getEnvAsMap("FILL_IN_ROLE_PINGS"),
+		ImportDiscordEvents:      getEnvAsBool("IMPORT_DISCORD_EVENTS", false),
+		ImportEventsChannelID:    getEnv("IMPORT_EVENTS_CHANNEL", ""),
+		ImportInterestedSignups:  getEnvAsBool("IMPORT_INTERESTED_SIGNUPS", false),
+		OAuth: OAuthConfig{
+			ClientID:      getEnv("OAUTH_CLIENT_ID", ""),
+			ClientSecret:  getEnv("OAUTH_CLIENT_SECRET", ""),
+			RedirectURL:   getEnv("OAUTH_REDIRECT_URL", ""),
+			AuthorizeURL:  getEnv("OAUTH_AUTHORIZE_URL", "https://discord.com/oauth2/authorize"),
+			TokenURL:      getEnv("OAUTH_TOKEN_URL", "https://discord.com/api/oauth2/token"),
+			APIBaseURL:    getEnv("OAUTH_API_BASE_URL", "https://discord.com/api/v10"),
+			SessionSecret: getEnv("SESSION_SECRET", ""),
+			AdminRoles:    getEnvAsList("OAUTH_ADMIN_ROLES"),
+			OfficerRoles:  getEnvAsList("OAUTH_OFFICER_ROLES"),
+			ViewerRoles:   getEnvAsList("OAUTH_VIEWER_ROLES"),
+		},
 	}
 
 	// Parsear roles por defecto
@@ -72,6 +132,9 @@ func LoadConfig() error {
 	if config.GuildID == "" {
 		log.Fatal("GUILD_ID es requerido")
 	}
+	if config.AdminPass == "admin123" {
+		log.Println("⚠️ ADMIN_PASS tiene el valor por defecto; cámbialo o usa tokens de API desde /tokens")
+	}
 
 	AppConfig = config
 	log.Println("✅ Configuración cargada exitosamente")
@@ -87,6 +150,33 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvAsList convierte una variable de entorno separada por comas en una lista
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvAsMap convierte una variable de entorno "clave=valor" separada por
+// comas en un mapa, por ejemplo "Tank=123,Healer=456"
+func getEnvAsMap(key string) map[string]string {
+	values := make(map[string]string)
+	for _, pair := range getEnvAsList(key) {
+		name, value, ok := strings.Cut(pair, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" || value == "" {
+			log.Printf("config: %s tiene una entrada inválida %q, se ignora", key, pair)
+			continue
+		}
+		values[name] = value
+	}
+	return values
+}
+
 // getEnvAsInt convierte una variable de entorno a int
 func getEnvAsInt(key string, defaultValue int) int {
 	valueStr := getEnv(key, "")
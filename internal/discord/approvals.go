@@ -0,0 +1,114 @@
+package discord
+
+import (
+	signupsvc "discord-event-bot/internal/services/signups"
+	"discord-event-bot/internal/storage"
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// officerPermissions son los permisos que habilitan a revisar inscripciones
+const officerPermissions = discordgo.PermissionAdministrator |
+	discordgo.PermissionManageServer |
+	discordgo.PermissionManageEvents
+
+// RequestSignupApproval publica en el hilo del evento (o en el canal si no hay
+// hilo) una solicitud con botones para que un oficial apruebe o rechace
+func RequestSignupApproval(s *discordgo.Session, event *storage.Event, signup storage.Signup) {
+	label := signup.Role
+	if signup.Class != "" {
+		label = fmt.Sprintf("%s - %s", signup.Role, signup.Class)
+	}
+
+	message := &discordgo.MessageSend{
+		Content: fmt.Sprintf("🕒 <@%s> solicita inscribirse como **%s**. Un oficial debe aprobar la inscripción.", signup.UserID, label),
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "✅ Aprobar",
+						Style:    discordgo.SuccessButton,
+						CustomID: fmt.Sprintf("approve_%s_%s_%s", event.ID, signup.UserID, signup.Role),
+					},
+					discordgo.Button{
+						Label:    "❌ Rechazar",
+						Style:    discordgo.DangerButton,
+						CustomID: fmt.Sprintf("decline_%s_%s_%s", event.ID, signup.UserID, signup.Role),
+					},
+				},
+			},
+		},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+
+	targetChannelID := event.Channel
+	if event.ThreadID != "" {
+		targetChannelID = event.ThreadID
+	}
+
+	if _, err := s.ChannelMessageSendComplex(targetChannelID, message); err != nil {
+		log.Printf("Error publicando solicitud de aprobación para evento %s: %v", event.ID, err)
+	}
+}
+
+// handleReviewSignup procesa los botones de aprobar/rechazar inscripción
+func handleReviewSignup(s *discordgo.Session, i *discordgo.InteractionCreate, eventID, userID, role string, approve bool) {
+	if !isOfficer(i) {
+		respondError(s, i, "Solo los oficiales pueden revisar inscripciones")
+		return
+	}
+
+	input := signupsvc.ReviewInput{
+		EventID:    eventID,
+		UserID:     userID,
+		Role:       role,
+		ReviewerID: i.Member.User.ID,
+	}
+
+	var (
+		event *storage.Event
+		err   error
+	)
+	if approve {
+		event, err = signupsvc.ApproveSignup(input)
+	} else {
+		event, err = signupsvc.DeclineSignup(input)
+	}
+	if err != nil {
+		respondError(s, i, err.Error())
+		return
+	}
+
+	UpdateEventMessage(s, event)
+
+	result := fmt.Sprintf("✅ Inscripción de <@%s> como **%s** aprobada por <@%s>.", userID, role, i.Member.User.ID)
+	dm := fmt.Sprintf("✅ Tu inscripción a **%s** como **%s** fue aprobada.", event.Name, role)
+	if !approve {
+		result = fmt.Sprintf("❌ Inscripción de <@%s> como **%s** rechazada por <@%s>.", userID, role, i.Member.User.ID)
+		dm = fmt.Sprintf("❌ Tu inscripción a **%s** como **%s** fue rechazada.", event.Name, role)
+	}
+
+	if err := sendDirectMessage(s, userID, dm); err != nil {
+		log.Printf("Error avisando revisión de inscripción a %s en evento %s: %v", userID, event.ID, err)
+	}
+
+	// Reemplazar la solicitud por el resultado y quitar los botones
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:         result,
+			Components:      []discordgo.MessageComponent{},
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+// isOfficer indica si quien interactúa tiene permisos de oficial en el servidor
+func isOfficer(i *discordgo.InteractionCreate) bool {
+	if i.Member == nil {
+		return false
+	}
+	return i.Member.Permissions&officerPermissions != 0
+}
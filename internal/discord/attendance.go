@@ -0,0 +1,150 @@
+package discord
+
+import (
+	"discord-event-bot/internal/services/attendance"
+	"discord-event-bot/internal/services/guilds"
+	"discord-event-bot/internal/storage"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord permite hasta 25 opciones por menú desplegable
+const maxAttendanceOptions = 25
+
+// Autor registrado cuando la asistencia se completa desde el canal de voz
+const voiceAttendanceActor = "voice"
+
+// sendAttendancePanel publica en el hilo del evento (o en el canal si no hay
+// hilo) el panel para que los oficiales marquen la asistencia. Si hay un canal
+// de voz configurado, antes marca como presentes a los confirmados conectados.
+func sendAttendancePanel(s *discordgo.Session, event *storage.Event) {
+	guildID := guilds.EventGuild(event)
+	if channelID := guilds.AttendanceVoiceChannel(guildID); channelID != "" {
+		if marked := attendance.MarkPresent(event, voiceChannelMembers(s, guildID, channelID), voiceAttendanceActor); marked > 0 {
+			log.Printf("🎙️ %d presentes marcados desde el canal de voz para evento %s", marked, event.ID)
+		}
+	}
+
+	content, components := buildAttendancePanel(event)
+	message := &discordgo.MessageSend{
+		Content:         content,
+		Components:      components,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+
+	targetChannelID := event.Channel
+	if event.ThreadID != "" {
+		targetChannelID = event.ThreadID
+	}
+
+	if _, err := s.ChannelMessageSendComplex(targetChannelID, message); err != nil {
+		log.Printf("Error publicando panel de asistencia para evento %s: %v", event.ID, err)
+	}
+}
+
+// handleAttendanceSelect registra la asistencia elegida en el panel y lo actualiza
+func handleAttendanceSelect(s *discordgo.Session, i *discordgo.InteractionCreate, eventID, status string, userIDs []string) {
+	if !isOfficer(i) {
+		respondError(s, i, "Solo los oficiales pueden marcar la asistencia")
+		return
+	}
+
+	var event *storage.Event
+	for _, userID := range userIDs {
+		marked, err := attendance.Mark(attendance.MarkInput{
+			EventID:    eventID,
+			UserID:     userID,
+			Attendance: status,
+			MarkedBy:   i.Member.User.ID,
+		})
+		if err != nil {
+			respondError(s, i, err.Error())
+			return
+		}
+		event = marked
+	}
+	if event == nil {
+		return
+	}
+
+	content, components := buildAttendancePanel(event)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:         content,
+			Components:      components,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+// buildAttendancePanel arma el texto con la asistencia actual y un menú por estado
+func buildAttendancePanel(event *storage.Event) (string, []discordgo.MessageComponent) {
+	entries := attendance.Entries(event)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📋 **Asistencia de %s**\nLos oficiales pueden marcar a cada inscrito con los menús de abajo.\n\n", event.Name)
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "%s — <@%s> (%s)\n", attendance.Label(entry.Attendance), entry.UserID, strings.Join(entry.Roles, ", "))
+	}
+	if len(entries) > maxAttendanceOptions {
+		fmt.Fprintf(&b, "\nSolo los primeros %d inscritos aparecen en los menús; el resto se marca desde el panel web.", maxAttendanceOptions)
+	}
+
+	if len(entries) > maxAttendanceOptions {
+		entries = entries[:maxAttendanceOptions]
+	}
+
+	options := make([]discordgo.SelectMenuOption, 0, len(entries))
+	for _, entry := range entries {
+		options = append(options, discordgo.SelectMenuOption{
+			Label: truncateText(entry.Username, 100),
+			Value: entry.UserID,
+		})
+	}
+
+	var components []discordgo.MessageComponent
+	if len(options) > 0 {
+		placeholders := map[string]string{
+			attendance.Present: "Marcar presentes",
+			attendance.Late:    "Marcar llegadas tarde",
+			attendance.NoShow:  "Marcar ausentes",
+		}
+		for _, status := range attendance.Statuses {
+			components = append(components, discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.SelectMenu{
+						MenuType:    discordgo.StringSelectMenu,
+						CustomID:    fmt.Sprintf("attendance_%s_%s", event.ID, status),
+						Placeholder: placeholders[status],
+						MaxValues:   len(options),
+						Options:     options,
+					},
+				},
+			})
+		}
+	}
+
+	return truncateText(b.String(), 2000), components
+}
+
+// voiceChannelMembers devuelve los usuarios conectados a un canal de voz
+// según el estado de voz que mantiene la sesión
+func voiceChannelMembers(s *discordgo.Session, guildID, channelID string) []string {
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		log.Printf("Error leyendo estado de voz del servidor: %v", err)
+		return nil
+	}
+
+	var userIDs []string
+	for _, vs := range guild.VoiceStates {
+		if vs.ChannelID == channelID {
+			userIDs = append(userIDs, vs.UserID)
+		}
+	}
+	return userIDs
+}
@@ -2,8 +2,11 @@ package discord
 
 import (
 	"discord-event-bot/config"
+	"discord-event-bot/internal/services/guilds"
+	"discord-event-bot/internal/storage"
 	"fmt"
 	"log"
+	"sync"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -17,36 +20,73 @@ func InitBot() error {
 	}
 
 	Session.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
-		log.Printf("✅ Bot conectado como: %v#%v", s.State.User.Username, s.State.User.Discriminator)
+		log.Printf("✅ Bot conectado como: %v#%v en %d servidores", s.State.User.Username, s.State.User.Discriminator, len(r.Guilds))
+	})
+
+	// Los comandos se registran en cada servidor al que está unido el bot,
+	// tanto al conectarse como al entrar a uno nuevo
+	Session.AddHandler(handleGuildCreate)
+	Session.AddHandler(func(s *discordgo.Session, g *discordgo.GuildDelete) {
+		if !g.Unavailable {
+			log.Printf("👋 El bot salió del servidor %s", g.ID)
+			registeredGuilds.Delete(g.ID)
+		}
 	})
 
 	// Registrar handlers de interacciones
 	Session.AddHandler(handleInteractionCreate)
 
+	// Eventos creados desde Discord e interesados (IMPORT_DISCORD_EVENTS)
+	Session.AddHandler(handleScheduledEventCreate)
+	Session.AddHandler(handleScheduledEventUpdate)
+	Session.AddHandler(handleScheduledEventDelete)
+	Session.AddHandler(handleScheduledEventUserAdd)
+	Session.AddHandler(handleScheduledEventUserRemove)
+
 	// Necesitamos permisos para intents
 	Session.Identify.Intents = discordgo.IntentsGuildMessages |
 		discordgo.IntentsGuildMessageReactions |
 		discordgo.IntentsGuilds |
-		discordgo.IntentsGuildScheduledEvents
+		discordgo.IntentsGuildScheduledEvents |
+		discordgo.IntentsGuildVoiceStates
 
 	// Abrir conexión
 	if err := Session.Open(); err != nil {
 		return fmt.Errorf("error abriendo conexión: %w", err)
 	}
 
-	// Registrar comandos slash
-	log.Println("📝 Registrando comandos slash...")
-	for _, cmd := range commands {
-		_, err := Session.ApplicationCommandCreate(Session.State.User.ID, config.AppConfig.GuildID, cmd)
-		if err != nil {
-			log.Printf("Error registrando comando %s: %v", cmd.Name, err)
-		}
-	}
-
 	log.Println("✅ Bot de Discord inicializado correctamente")
 	return nil
 }
 
+// registeredGuilds guarda los servidores que ya tienen los comandos registrados
+// en esta ejecución, para no repetirlo en cada reconexión
+var registeredGuilds sync.Map
+
+// handleGuildCreate registra el servidor y sus comandos slash
+func handleGuildCreate(s *discordgo.Session, g *discordgo.GuildCreate) {
+	if !guilds.Allowed(g.ID) {
+		log.Printf("⚠️ Servidor %s (%s) fuera de GUILD_IDS, se ignora", g.Name, g.ID)
+		return
+	}
+
+	if err := storage.Guilds.RememberGuild(g.ID, g.Name); err != nil {
+		log.Printf("Error guardando servidor %s: %v", g.ID, err)
+	}
+
+	if _, loaded := registeredGuilds.LoadOrStore(g.ID, true); loaded {
+		return
+	}
+
+	go importGuildScheduledEvents(s, g.ID)
+
+	log.Printf("📝 Registrando comandos slash en %s (%s)...", g.Name, g.ID)
+	if _, err := s.ApplicationCommandBulkOverwrite(s.State.User.ID, g.ID, commands); err != nil {
+		log.Printf("Error registrando comandos en %s: %v", g.ID, err)
+		registeredGuilds.Delete(g.ID)
+	}
+}
+
 // Close cierra la sesión de Discord
 func Close() {
 	if Session != nil {
@@ -0,0 +1,52 @@
+package discord
+
+import (
+	"discord-event-bot/internal/services/calendar"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleCalendar responde con las direcciones de los calendarios iCal del
+// jugador. Son privadas: quien tenga la URL puede leer los eventos.
+func handleCalendar(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	params := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+	for _, opt := range i.ApplicationCommandData().Options {
+		params[opt.Name] = opt
+	}
+
+	reset := false
+	if opt, ok := params["regenerar"]; ok {
+		reset = opt.BoolValue()
+	}
+
+	user := i.Member.User
+	feed, err := calendar.Feed(i.GuildID, user.ID, user.Username, reset)
+	if err != nil {
+		respondError(s, i, err.Error())
+		return
+	}
+	urls := calendar.URLs(feed)
+
+	var b strings.Builder
+	b.WriteString("📅 **Tus calendarios**\nSuscríbete desde tu aplicación de calendario (Google Calendar: *Otros calendarios → Desde URL*).\n\n")
+	b.WriteString(fmt.Sprintf("• Mis eventos: <%s>\n", urls.Personal))
+	b.WriteString(fmt.Sprintf("• Todos los eventos: <%s>\n", urls.Events))
+	if opt, ok := params["tipo"]; ok && strings.TrimSpace(opt.StringValue()) != "" {
+		eventType := strings.TrimSpace(opt.StringValue())
+		b.WriteString(fmt.Sprintf("• Solo %s: <%s>\n", eventType, calendar.TypeURL(urls, eventType)))
+	}
+	b.WriteString("\n🔒 No compartas estas direcciones. Si se filtraron, usa `/calendar regenerar:true` y vuelve a suscribirte.")
+	if reset {
+		b.WriteString("\n♻️ Se generaron direcciones nuevas; las anteriores ya no funcionan.")
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: b.String(),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
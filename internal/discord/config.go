@@ -2,15 +2,16 @@ package discord
 
 import (
 	"discord-event-bot/config"
+	"discord-event-bot/internal/services/guilds"
 	"fmt"
 
 	"github.com/bwmarrin/discordgo"
 )
 
-// handleConfig muestra la configuración actual
+// handleConfig muestra la configuración del servidor
 func handleConfig(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	rolesText := ""
-	for _, role := range config.AppConfig.DefaultRoles {
+	for _, role := range guilds.DefaultRoles(i.GuildID) {
 		rolesText += fmt.Sprintf("%s %s (Límite: %d)\n", role.Emoji, role.Name, role.Limit)
 	}
 
@@ -18,10 +19,11 @@ func handleConfig(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		Title: "⚙️ Configuración del Bot",
 		Color: 0x5865F2,
 		Fields: []*discordgo.MessageEmbedField{
-			{Name: "Guild ID", Value: config.AppConfig.GuildID, Inline: true},
+			{Name: "Guild ID", Value: i.GuildID, Inline: true},
 			{Name: "Puerto Web", Value: config.AppConfig.Port, Inline: true},
 			{Name: "Zona Horaria", Value: config.AppConfig.Timezone, Inline: true},
-			{Name: "Eventos de Discord", Value: fmt.Sprintf("%v", config.AppConfig.EnableDiscordEvents), Inline: true},
+			{Name: "Eventos de Discord", Value: fmt.Sprintf("%v", guilds.DiscordEventsEnabled(i.GuildID)), Inline: true},
+			{Name: "Recordatorio", Value: fmt.Sprintf("%d minutos antes", guilds.ReminderOffsetMinutes(i.GuildID)), Inline: true},
 			{Name: "Roles Disponibles", Value: rolesText, Inline: false},
 		},
 	}
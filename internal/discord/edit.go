@@ -0,0 +1,219 @@
+package discord
+
+import (
+	"discord-event-bot/config"
+	eventsvc "discord-event-bot/internal/services/events"
+	"discord-event-bot/internal/services/guilds"
+	"discord-event-bot/internal/storage"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleEditEvent edita un evento existente. Solo cambian las opciones
+// indicadas. Solo los oficiales pueden editar eventos.
+func handleEditEvent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isOfficer(i) {
+		respondError(s, i, "Solo los oficiales pueden editar eventos")
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	eventID := optionMap["id"].StringValue()
+	event, err := storage.Store.GetEvent(eventID)
+	if err != nil || !guilds.InGuild(event, i.GuildID) {
+		respondError(s, i, "Evento no encontrado")
+		return
+	}
+
+	input, err := buildEditEventInputFromInteraction(optionMap)
+	if err != nil {
+		respondError(s, i, err.Error())
+		return
+	}
+
+	result, err := eventsvc.EditEvent(eventID, input)
+	if err != nil {
+		respondError(s, i, "Error editando el evento: "+err.Error())
+		return
+	}
+
+	SyncEditedEvent(s, result)
+
+	content := fmt.Sprintf("✅ Evento **%s** actualizado", result.Event.Name)
+	if len(result.Changes) > 0 {
+		content += ": cambió " + strings.Join(result.Changes, ", ") + ". Los inscritos reciben un aviso por privado."
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// buildEditEventInputFromInteraction arma los cambios con las opciones del
+// comando; las que no se indicaron quedan en nil
+func buildEditEventInputFromInteraction(optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) (eventsvc.EditEventInput, error) {
+	var input eventsvc.EditEventInput
+	changed := false
+
+	if opt, ok := optionMap["nombre"]; ok {
+		name := opt.StringValue()
+		input.Name = &name
+		changed = true
+	}
+	if opt, ok := optionMap["tipo"]; ok {
+		eventType := opt.StringValue()
+		input.Type = &eventType
+		changed = true
+	}
+	if opt, ok := optionMap["descripcion"]; ok {
+		description := opt.StringValue()
+		input.Description = &description
+		changed = true
+	}
+	if opt, ok := optionMap["fecha"]; ok {
+		loc, _ := time.LoadLocation(config.AppConfig.Timezone)
+		fecha, err := time.ParseInLocation("2006-01-02 15:04", opt.StringValue(), loc)
+		if err != nil {
+			return input, fmt.Errorf("Formato de fecha inválido. Usa: YYYY-MM-DD HH:MM (ej: 2024-12-25 20:00)")
+		}
+		input.DateTime = &fecha
+		changed = true
+	}
+	if opt, ok := optionMap["canal"]; ok {
+		channelID := opt.ChannelValue(nil).ID
+		input.ChannelID = &channelID
+		changed = true
+	}
+	if opt, ok := optionMap["roles"]; ok {
+		roles, err := eventsvc.ParseRoles(opt.StringValue())
+		if err != nil {
+			return input, fmt.Errorf("Roles inválidos: %v", err)
+		}
+		input.Roles = roles
+		changed = true
+	}
+
+	if !changed {
+		return input, fmt.Errorf("Indica al menos un dato a cambiar")
+	}
+	return input, nil
+}
+
+// SyncEditedEvent refleja en Discord la edición de un evento: vuelve a
+// dibujar el mensaje (o lo mueve de canal), actualiza el evento programado
+// de Discord y avisa a los inscritos de lo que cambió
+func SyncEditedEvent(s *discordgo.Session, result *eventsvc.EditResult) {
+	event := result.Event
+
+	if result.ChannelMoved() {
+		if result.PreviousMessageID != "" {
+			if err := s.ChannelMessageDelete(result.PreviousChannelID, result.PreviousMessageID); err != nil {
+				log.Printf("Error borrando el mensaje anterior del evento %s: %v", event.ID, err)
+			}
+		}
+		if result.PreviousThreadID != "" {
+			archiveEventThread(s, event.ID, result.PreviousThreadID)
+		}
+		// Si el anuncio está programado para más adelante, se publica a su hora
+		if event.AnnouncementTime.IsZero() || !event.AnnouncementTime.After(time.Now()) {
+			if err := PublishEventMessage(s, event); err != nil {
+				log.Printf("Error publicando el evento %s en el canal nuevo: %v", event.ID, err)
+			}
+		}
+	} else if event.MessageID != "" {
+		UpdateEventMessage(s, event)
+	}
+
+	if event.DiscordEventID != "" && result.Changed("nombre", "descripción", "fecha") {
+		UpdateDiscordScheduledEvent(s, event)
+	}
+
+	if len(result.Promoted) > 0 {
+		NotifyWaitlistPromotions(s, event, result.Promoted)
+	}
+
+	queueEditNotice(result)
+}
+
+// UpdateDiscordScheduledEvent actualiza el evento oficial de Discord con el
+// nombre, la descripción y la fecha del evento
+func UpdateDiscordScheduledEvent(s *discordgo.Session, event *storage.Event) {
+	endTime := event.DateTime.Add(2 * time.Hour)
+
+	params := &discordgo.GuildScheduledEventParams{
+		Name:               event.Name,
+		Description:        event.Description,
+		ScheduledStartTime: &event.DateTime,
+		ScheduledEndTime:   &endTime,
+	}
+	if _, err := s.GuildScheduledEventEdit(guilds.EventGuild(event), event.DiscordEventID, params); err != nil {
+		log.Printf("Error actualizando evento de Discord %s: %v", event.DiscordEventID, err)
+	}
+}
+
+// queueEditNotice avisa por privado a los inscritos y a la banca de los
+// datos del evento que cambiaron
+func queueEditNotice(result *eventsvc.EditResult) {
+	if len(result.Changes) == 0 {
+		return
+	}
+	event := result.Event
+
+	content := fmt.Sprintf("✏️ El evento **%s** cambió: %s.", event.Name, strings.Join(result.Changes, ", "))
+	if result.DateChanged {
+		content += fmt.Sprintf("\nNueva fecha: <t:%d:F> (antes <t:%d:F>).", event.DateTime.Unix(), result.PreviousDate.Unix())
+	}
+	if link := eventMessageLink(event); link != "" {
+		content += "\n" + link
+	}
+
+	for _, userID := range eventParticipants(event) {
+		queueDirectMessage(directMessage{UserID: userID, GuildID: guilds.EventGuild(event), Content: content})
+	}
+}
+
+// eventParticipants devuelve los usuarios inscritos (sin los rechazados) y
+// los de la banca, sin repetir
+func eventParticipants(event *storage.Event) []string {
+	seen := make(map[string]bool)
+	var users []string
+	add := func(userID string) {
+		if !seen[userID] {
+			seen[userID] = true
+			users = append(users, userID)
+		}
+	}
+
+	for _, role := range event.Roles {
+		for _, signup := range event.Signups[role.Name] {
+			if signup.Status != "declined" {
+				add(signup.UserID)
+			}
+		}
+		for _, waiting := range event.Waitlist[role.Name] {
+			add(waiting.UserID)
+		}
+	}
+	return users
+}
+
+// archiveEventThread archiva y bloquea el hilo de un evento
+func archiveEventThread(s *discordgo.Session, eventID, threadID string) {
+	archived := true
+	locked := true
+	if _, err := s.ChannelEdit(threadID, &discordgo.ChannelEdit{Archived: &archived, Locked: &locked}); err != nil {
+		log.Printf("Error archivando hilo %s para evento %s: %v", threadID, eventID, err)
+	}
+}
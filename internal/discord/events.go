@@ -3,7 +3,11 @@ package discord
 import (
 	"discord-event-bot/config"
 	eventsvc "discord-event-bot/internal/services/events"
+	"discord-event-bot/internal/services/guilds"
+	"discord-event-bot/internal/services/recurrence"
+	remindersvc "discord-event-bot/internal/services/reminders"
 	"discord-event-bot/internal/storage"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -11,6 +15,9 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
+// errInvalidDate indica que la fecha del comando no respeta el formato esperado
+var errInvalidDate = errors.New("formato de fecha inválido")
+
 // CreateDiscordScheduledEvent crea un evento oficial de Discord
 func CreateDiscordScheduledEvent(s *discordgo.Session, event *storage.Event) {
 	// Calcular hora de fin (2 horas después del inicio)
@@ -27,24 +34,69 @@ func CreateDiscordScheduledEvent(s *discordgo.Session, event *storage.Event) {
 			Location: "In-Game",
 		},
 	}
-	discordEvent, err := s.GuildScheduledEventCreate(config.AppConfig.GuildID, params)
+	discordEvent, err := s.GuildScheduledEventCreate(guilds.EventGuild(event), params)
 	if err != nil {
 		log.Printf("Error creando evento de Discord: %v", err)
 		return
 	}
 
 	event.DiscordEventID = discordEvent.ID
+	event.DiscordEventStatus = ""
 	storage.Store.SaveEvent(event)
 }
 
+// setDiscordScheduledEventStatus cambia el estado del evento oficial de Discord
+func setDiscordScheduledEventStatus(s *discordgo.Session, event *storage.Event, status discordgo.GuildScheduledEventStatus) error {
+	_, err := s.GuildScheduledEventEdit(guilds.EventGuild(event), event.DiscordEventID, &discordgo.GuildScheduledEventParams{Status: status})
+	return err
+}
+
+// CancelDiscordScheduledEvent cancela el evento oficial de Discord de un
+// evento cancelado. Si ya había empezado, Discord solo permite terminarlo.
+func CancelDiscordScheduledEvent(s *discordgo.Session, event *storage.Event) {
+	if event.DiscordEventID == "" || event.DiscordEventStatus == "completed" || event.DiscordEventStatus == "cancelled" {
+		return
+	}
+
+	status, label := discordgo.GuildScheduledEventStatusCanceled, "cancelled"
+	if event.DiscordEventStatus == "active" {
+		status, label = discordgo.GuildScheduledEventStatusCompleted, "completed"
+	}
+	if err := setDiscordScheduledEventStatus(s, event, status); err != nil {
+		log.Printf("Error cancelando evento de Discord %s: %v", event.DiscordEventID, err)
+		return
+	}
+
+	event.DiscordEventStatus = label
+	if err := storage.Store.SaveEvent(event); err != nil {
+		log.Printf("Error guardando evento %s tras cancelar su evento de Discord: %v", event.ID, err)
+	}
+}
+
+// DeleteDiscordScheduledEvent borra el evento oficial de Discord de un evento eliminado
+func DeleteDiscordScheduledEvent(s *discordgo.Session, event *storage.Event) {
+	if event.DiscordEventID == "" {
+		return
+	}
+	if err := s.GuildScheduledEventDelete(guilds.EventGuild(event), event.DiscordEventID); err != nil {
+		log.Printf("Error borrando evento de Discord %s: %v", event.DiscordEventID, err)
+	}
+	event.DiscordEventID = ""
+	event.DiscordEventStatus = ""
+}
+
 // handleCreateEvent crea un nuevo evento
 func handleCreateEvent(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	input, err := buildCreateEventInputFromInteraction(i)
 	if err != nil {
+		content := "❌ " + err.Error()
+		if errors.Is(err, errInvalidDate) {
+			content = "❌ Formato de fecha inválido. Usa: YYYY-MM-DD HH:MM (ej: 2024-12-25 20:00)"
+		}
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "❌ Formato de fecha inválido. Usa: YYYY-MM-DD HH:MM (ej: 2024-12-25 20:00)",
+				Content: content,
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
@@ -71,8 +123,8 @@ func handleCreateEvent(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		}
 	}
 
-	// Crear evento oficial de Discord solo si está habilitado globalmente y el evento lo requiere
-	if config.AppConfig.EnableDiscordEvents && event.CreateDiscordEvent {
+	// Crear evento oficial de Discord solo si está habilitado en el servidor y el evento lo requiere
+	if guilds.DiscordEventsEnabled(event.GuildID) && event.CreateDiscordEvent {
 		CreateDiscordScheduledEvent(s, event)
 	}
 
@@ -126,6 +178,15 @@ func buildCreateEventInputFromInteraction(i *discordgo.InteractionCreate) (event
 		}
 	}
 
+	var reminderOffsets []int
+	if roOpt, ok := optionMap["recordatorios"]; ok {
+		offsets, err := remindersvc.ParseOffsets(roOpt.StringValue())
+		if err != nil {
+			return eventsvc.CreateEventInput{}, fmt.Errorf("Recordatorios inválidos: %v", err)
+		}
+		reminderOffsets = offsets
+	}
+
 	deleteAfterHours := 0
 	if dahOpt, ok := optionMap["delete_after_hours"]; ok {
 		v := int(dahOpt.IntValue())
@@ -134,6 +195,16 @@ func buildCreateEventInputFromInteraction(i *discordgo.InteractionCreate) (event
 		}
 	}
 
+	requireApproval := false
+	if apOpt, ok := optionMap["approval"]; ok {
+		requireApproval = apOpt.BoolValue()
+	}
+
+	carryOverSignups := false
+	if coOpt, ok := optionMap["mantener_inscritos"]; ok {
+		carryOverSignups = coOpt.BoolValue()
+	}
+
 	// Template opcional
 	templateName := ""
 	if tmpl, ok := optionMap["template"]; ok {
@@ -143,17 +214,31 @@ func buildCreateEventInputFromInteraction(i *discordgo.InteractionCreate) (event
 	// Canal por defecto es el canal actual
 	channelID := i.ChannelID
 	if canal, ok := optionMap["canal"]; ok {
-		channelID = canal.StringValue()
+		channelID = canal.ChannelValue(nil).ID
 	}
 
 	// Parsear fecha
 	loc, _ := time.LoadLocation(config.AppConfig.Timezone)
 	fecha, err := time.ParseInLocation("2006-01-02 15:04", fechaStr, loc)
 	if err != nil {
-		return eventsvc.CreateEventInput{}, err
+		return eventsvc.CreateEventInput{}, errInvalidDate
+	}
+
+	// Regla de repetición estilo RRULE (opcional)
+	var rule *storage.Recurrence
+	if ruleOpt, ok := optionMap["recurrencia"]; ok {
+		exceptions := ""
+		if exOpt, ok := optionMap["excepciones"]; ok {
+			exceptions = exOpt.StringValue()
+		}
+		rule, err = recurrence.Parse(ruleOpt.StringValue(), exceptions, fecha)
+		if err != nil {
+			return eventsvc.CreateEventInput{}, fmt.Errorf("Regla de repetición inválida: %v", err)
+		}
 	}
 
 	return eventsvc.CreateEventInput{
+		GuildID:               i.GuildID,
 		Name:                  nombre,
 		Type:                  tipo,
 		Description:           descripcion,
@@ -166,6 +251,10 @@ func buildCreateEventInputFromInteraction(i *discordgo.InteractionCreate) (event
 		AnnounceHours:         announceHours,
 		ReminderOffsetMinutes: reminderOffsetMinutes,
 		DeleteAfterHours:      deleteAfterHours,
+		RequireApproval:       requireApproval,
+		Recurrence:            rule,
+		CarryOverSignups:      carryOverSignups,
+		ReminderOffsets:       reminderOffsets,
 	}, nil
 }
 
@@ -175,7 +264,7 @@ func handleDeleteEvent(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	eventID := options[0].StringValue()
 
 	event, err := storage.Store.GetEvent(eventID)
-	if err != nil {
+	if err != nil || !guilds.InGuild(event, i.GuildID) {
 		respondError(s, i, "Evento no encontrado")
 		return
 	}
@@ -187,13 +276,12 @@ func handleDeleteEvent(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 	// Cerrar hilo asociado si existe
 	if event.ThreadID != "" {
-		archived := true
-		locked := true
-		if _, err := s.ChannelEdit(event.ThreadID, &discordgo.ChannelEdit{Archived: &archived, Locked: &locked}); err != nil {
-			log.Printf("Error archivando hilo %s para evento %s: %v", event.ThreadID, event.ID, err)
-		}
+		archiveEventThread(s, event.ID, event.ThreadID)
 	}
 
+	// Borrar el evento oficial de Discord
+	DeleteDiscordScheduledEvent(s, event)
+
 	// Eliminar evento
 	storage.Store.DeleteEvent(eventID)
 
@@ -208,7 +296,7 @@ func handleDeleteEvent(s *discordgo.Session, i *discordgo.InteractionCreate) {
 
 // handleListEvents lista todos los eventos activos
 func handleListEvents(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	events := storage.Store.GetActiveEvents()
+	events := guilds.FilterEvents(storage.Store.GetActiveEvents(), i.GuildID)
 
 	if len(events) == 0 {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
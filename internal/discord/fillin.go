@@ -0,0 +1,77 @@
+package discord
+
+import (
+	remindersvc "discord-event-bot/internal/services/reminders"
+	signupsvc "discord-event-bot/internal/services/signups"
+	"discord-event-bot/internal/storage"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func deliverFillIns(result remindersvc.ProcessResult) {
+	// Pedir jugadores en el canal para los roles que siguen incompletos
+	for _, fillIn := range result.FillIns {
+		if Session == nil {
+			continue
+		}
+		log.Printf("📣 Llamado a completar roles para evento %s", fillIn.Event.ID)
+		if err := sendFillIn(Session, fillIn.Event, fillIn.Gaps); err != nil {
+			log.Printf("Error enviando llamado a completar para evento %s: %v", fillIn.Event.ID, err)
+		}
+	}
+}
+
+// sendFillIn publica en el canal del evento qué roles faltan, mencionando el
+// rol de Discord configurado para cada uno y con botones para inscribirse
+func sendFillIn(s *discordgo.Session, event *storage.Event, gaps []signupsvc.RoleGap) error {
+	var (
+		lines   []string
+		pings   []string
+		buttons []discordgo.MessageComponent
+	)
+	for _, gap := range gaps {
+		line := strings.TrimSpace(fmt.Sprintf("%s Falta%s **%d %s**", gap.Role.Emoji, plural(gap.Missing, "n"), gap.Missing, gap.Role.Name))
+		if roleID := remindersvc.FillInRolePing(event, gap.Role.Name); roleID != "" {
+			line = fmt.Sprintf("<@&%s> %s", roleID, line)
+			pings = append(pings, roleID)
+		}
+		lines = append(lines, line)
+
+		if len(buttons) < maxActionRows*5 {
+			buttons = append(buttons, roleSignupButton(event, gap.Role))
+		}
+	}
+
+	content := fmt.Sprintf("📣 **Se buscan jugadores** para **%s**, que comienza <t:%d:R>\n\n%s",
+		event.Name, event.DateTime.Unix(), strings.Join(lines, "\n"))
+	if link := eventMessageLink(event); link != "" {
+		content += "\n\n" + link
+	}
+
+	var components []discordgo.MessageComponent
+	for start := 0; start < len(buttons); start += 5 {
+		end := start + 5
+		if end > len(buttons) {
+			end = len(buttons)
+		}
+		components = append(components, discordgo.ActionsRow{Components: buttons[start:end]})
+	}
+
+	_, err := s.ChannelMessageSendComplex(event.Channel, &discordgo.MessageSend{
+		Content:         content,
+		Components:      components,
+		AllowedMentions: &discordgo.MessageAllowedMentions{Roles: pings},
+	})
+	return err
+}
+
+// plural devuelve el sufijo si la cantidad es distinta de uno
+func plural(n int, suffix string) string {
+	if n == 1 {
+		return ""
+	}
+	return suffix
+}
@@ -0,0 +1,288 @@
+package discord
+
+import (
+	"discord-event-bot/config"
+	eventsvc "discord-event-bot/internal/services/events"
+	"discord-event-bot/internal/services/guilds"
+	signupsvc "discord-event-bot/internal/services/signups"
+	"discord-event-bot/internal/storage"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Los eventos creados desde la interfaz de Discord (fuera del bot) se copian
+// como eventos del bot para que tengan mensaje, inscripciones y
+// recordatorios. Los cambios que se hagan en Discord se reflejan en la copia.
+
+// Máximo de interesados que devuelve Discord por consulta
+const scheduledEventUsersLimit = 100
+
+// handleScheduledEventCreate importa un evento creado desde Discord
+func handleScheduledEventCreate(s *discordgo.Session, e *discordgo.GuildScheduledEventCreate) {
+	if !importEnabled(e.GuildID) {
+		return
+	}
+	importScheduledEvent(s, e.GuildScheduledEvent)
+}
+
+// handleScheduledEventUpdate refleja en el evento del bot los cambios hechos
+// en Discord: nombre, descripción, fecha y estado
+func handleScheduledEventUpdate(s *discordgo.Session, e *discordgo.GuildScheduledEventUpdate) {
+	if !guilds.Allowed(e.GuildID) {
+		return
+	}
+
+	event, ok := eventsvc.FindByDiscordEventID(e.ID)
+	if !ok || event.Status != "active" {
+		return
+	}
+
+	switch e.Status {
+	case discordgo.GuildScheduledEventStatusActive:
+		event.DiscordEventStatus = "active"
+	case discordgo.GuildScheduledEventStatusCompleted:
+		event.DiscordEventStatus = "completed"
+	case discordgo.GuildScheduledEventStatusCanceled:
+		event.DiscordEventStatus = "cancelled"
+		if event.Imported {
+			cancelImportedEvent(s, event)
+			return
+		}
+	}
+	if err := storage.Store.SaveEvent(event); err != nil {
+		log.Printf("Error guardando estado del evento de Discord %s: %v", e.ID, err)
+	}
+
+	// Solo los eventos importados siguen a Discord; los del bot mandan al revés
+	if !event.Imported {
+		return
+	}
+
+	var input eventsvc.EditEventInput
+	if e.Name != event.Name {
+		input.Name = &e.Name
+	}
+	if e.Description != event.Description {
+		input.Description = &e.Description
+	}
+	if !e.ScheduledStartTime.Equal(event.DateTime) {
+		dateTime := localTime(e.ScheduledStartTime)
+		input.DateTime = &dateTime
+	}
+	if input.Name == nil && input.Description == nil && input.DateTime == nil {
+		return
+	}
+
+	result, err := eventsvc.EditEvent(event.ID, input)
+	if err != nil {
+		log.Printf("Error actualizando evento importado %s: %v", event.ID, err)
+		return
+	}
+
+	// No se usa SyncEditedEvent: el evento de Discord ya tiene estos datos
+	if result.Event.MessageID != "" {
+		UpdateEventMessage(s, result.Event)
+	}
+	queueEditNotice(result)
+}
+
+// handleScheduledEventDelete cancela el evento importado cuando se borra el
+// de Discord. Si el evento era del bot, solo se olvida el vínculo.
+func handleScheduledEventDelete(s *discordgo.Session, e *discordgo.GuildScheduledEventDelete) {
+	if !guilds.Allowed(e.GuildID) {
+		return
+	}
+
+	event, ok := eventsvc.FindByDiscordEventID(e.ID)
+	if !ok {
+		return
+	}
+
+	if event.Imported && event.Status == "active" {
+		cancelImportedEvent(s, event)
+		return
+	}
+
+	event.DiscordEventID = ""
+	event.DiscordEventStatus = ""
+	if err := storage.Store.SaveEvent(event); err != nil {
+		log.Printf("Error desvinculando evento de Discord %s: %v", e.ID, err)
+	}
+}
+
+// handleScheduledEventUserAdd inscribe como pendiente a quien marca "me
+// interesa" en un evento importado
+func handleScheduledEventUserAdd(s *discordgo.Session, e *discordgo.GuildScheduledEventUserAdd) {
+	event, ok := importedEventForInterest(e.GuildID, e.GuildScheduledEventID)
+	if !ok || e.UserID == s.State.User.ID {
+		return
+	}
+	addInterestedUser(s, event, e.UserID, memberUsername(s, e.GuildID, e.UserID))
+}
+
+// handleScheduledEventUserRemove quita la inscripción pendiente de quien
+// deja de estar interesado
+func handleScheduledEventUserRemove(s *discordgo.Session, e *discordgo.GuildScheduledEventUserRemove) {
+	event, ok := importedEventForInterest(e.GuildID, e.GuildScheduledEventID)
+	if !ok {
+		return
+	}
+
+	updated, promoted, err := signupsvc.RemoveInterested(event.ID, e.UserID)
+	if err != nil {
+		log.Printf("Error quitando interesado %s del evento %s: %v", e.UserID, event.ID, err)
+		return
+	}
+	if updated == nil {
+		return
+	}
+
+	UpdateEventMessage(s, updated)
+	if len(promoted) > 0 {
+		NotifyWaitlistPromotions(s, updated, promoted)
+	}
+}
+
+// importGuildScheduledEvents importa los eventos de Discord creados mientras
+// el bot estaba desconectado
+func importGuildScheduledEvents(s *discordgo.Session, guildID string) {
+	if !importEnabled(guildID) {
+		return
+	}
+
+	scheduled, err := s.GuildScheduledEvents(guildID, false)
+	if err != nil {
+		log.Printf("Error obteniendo eventos de Discord de %s: %v", guildID, err)
+		return
+	}
+	for _, discordEvent := range scheduled {
+		if discordEvent.Status == discordgo.GuildScheduledEventStatusScheduled {
+			importScheduledEvent(s, discordEvent)
+		}
+	}
+}
+
+// importScheduledEvent crea y publica el evento del bot de un evento de
+// Discord que todavía no tiene uno
+func importScheduledEvent(s *discordgo.Session, discordEvent *discordgo.GuildScheduledEvent) {
+	// Los eventos que crea el propio bot ya están vinculados o se vinculan al crearlos
+	if discordEvent.CreatorID == s.State.User.ID {
+		return
+	}
+	if _, ok := eventsvc.FindByDiscordEventID(discordEvent.ID); ok {
+		return
+	}
+
+	event, err := eventsvc.ImportEvent(eventsvc.ImportEventInput{
+		GuildID:        discordEvent.GuildID,
+		DiscordEventID: discordEvent.ID,
+		Name:           discordEvent.Name,
+		Description:    discordEvent.Description,
+		DateTime:       localTime(discordEvent.ScheduledStartTime),
+		CreatedBy:      discordEvent.CreatorID,
+	})
+	if err != nil {
+		log.Printf("Error importando evento de Discord %s: %v", discordEvent.ID, err)
+		return
+	}
+	log.Printf("📥 Evento de Discord %s importado como %s (%s)", discordEvent.ID, event.ID, event.Name)
+
+	if event.AnnouncementTime.IsZero() || !event.AnnouncementTime.After(time.Now()) {
+		if err := PublishEventMessage(s, event); err != nil {
+			log.Printf("Error publicando evento importado %s: %v", event.ID, err)
+		}
+	}
+
+	if !guilds.ImportInterestedSignups(event.GuildID) {
+		return
+	}
+	users, err := s.GuildScheduledEventUsers(discordEvent.GuildID, discordEvent.ID, scheduledEventUsersLimit, true, "", "")
+	if err != nil {
+		log.Printf("Error obteniendo interesados del evento de Discord %s: %v", discordEvent.ID, err)
+		return
+	}
+	for _, user := range users {
+		if user.User == nil || user.User.Bot {
+			continue
+		}
+		username := user.User.Username
+		if user.Member != nil && user.Member.Nick != "" {
+			username = user.Member.Nick
+		}
+		addInterestedUser(s, event, user.User.ID, username)
+	}
+}
+
+// addInterestedUser inscribe a un interesado y pide la aprobación de un oficial
+func addInterestedUser(s *discordgo.Session, event *storage.Event, userID, username string) {
+	signup, err := signupsvc.AddInterested(event.ID, userID, username)
+	if err != nil {
+		log.Printf("Error inscribiendo interesado %s en evento %s: %v", userID, event.ID, err)
+		return
+	}
+	if signup == nil {
+		return
+	}
+
+	UpdateEventMessage(s, event)
+	RequestSignupApproval(s, event, *signup)
+}
+
+// cancelImportedEvent cancela el evento del bot y quita su mensaje
+func cancelImportedEvent(s *discordgo.Session, event *storage.Event) {
+	if _, err := eventsvc.CancelEvent(event.ID); err != nil {
+		log.Printf("Error cancelando evento importado %s: %v", event.ID, err)
+		return
+	}
+	if event.MessageID != "" {
+		if err := s.ChannelMessageDelete(event.Channel, event.MessageID); err != nil {
+			log.Printf("Error borrando mensaje del evento importado %s: %v", event.ID, err)
+		}
+	}
+	if event.ThreadID != "" {
+		archiveEventThread(s, event.ID, event.ThreadID)
+	}
+	log.Printf("🗑️ Evento importado %s cancelado desde Discord", event.ID)
+}
+
+// importedEventForInterest devuelve el evento importado activo cuando los
+// interesados cuentan como inscripción
+func importedEventForInterest(guildID, discordEventID string) (*storage.Event, bool) {
+	if !importEnabled(guildID) || !guilds.ImportInterestedSignups(guildID) {
+		return nil, false
+	}
+	event, ok := eventsvc.FindByDiscordEventID(discordEventID)
+	if !ok || !event.Imported || event.Status != "active" {
+		return nil, false
+	}
+	return event, true
+}
+
+func importEnabled(guildID string) bool {
+	return guilds.Allowed(guildID) && guilds.ImportDiscordEvents(guildID)
+}
+
+// memberUsername devuelve el apodo o el nombre de usuario de un miembro
+func memberUsername(s *discordgo.Session, guildID, userID string) string {
+	member, err := s.State.Member(guildID, userID)
+	if err != nil {
+		member, err = s.GuildMember(guildID, userID)
+	}
+	if err != nil || member.User == nil {
+		log.Printf("Error obteniendo miembro %s: %v", userID, err)
+		return userID
+	}
+	if member.Nick != "" {
+		return member.Nick
+	}
+	return member.User.Username
+}
+
+func localTime(t time.Time) time.Time {
+	if loc, err := time.LoadLocation(config.AppConfig.Timezone); err == nil {
+		return t.In(loc)
+	}
+	return t
+}
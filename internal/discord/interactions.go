@@ -73,12 +73,90 @@ var (
 					Description: "Minutos de antelación para el recordatorio (0 = usar valor global)",
 					Required:    false,
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "recordatorios",
+					Description: "Varias etapas de recordatorio, ej: 24h, 1h, 10m (reemplaza reminder_minutes)",
+					Required:    false,
+				},
 				{
 					Type:        discordgo.ApplicationCommandOptionInteger,
 					Name:        "delete_after_hours",
 					Description: "Horas después del evento para borrar el mensaje (0 = no borrar)",
 					Required:    false,
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "approval",
+					Description: "Las inscripciones quedan pendientes hasta que un oficial las apruebe",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "recurrencia",
+					Description: "Regla RRULE (ej: FREQ=WEEKLY;BYDAY=TU,TH o FREQ=MONTHLY;BYDAY=-1FR;COUNT=6)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "excepciones",
+					Description: "Fechas a saltear separadas por coma (YYYY-MM-DD)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "mantener_inscritos",
+					Description: "Copiar las inscripciones a la siguiente repetición del evento",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:        "edit_event",
+			Description: "Editar un evento existente (solo cambian las opciones indicadas)",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "id",
+					Description: "ID del evento a editar",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "nombre",
+					Description: "Nuevo nombre del evento",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "tipo",
+					Description: "Nuevo tipo de evento",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "fecha",
+					Description: "Nueva fecha y hora (formato: YYYY-MM-DD HH:MM)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "descripcion",
+					Description: "Nueva descripción del evento",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionChannel,
+					Name:        "canal",
+					Description: "Mover el evento a otro canal",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "roles",
+					Description: "Roles como Nombre:emoji:límite separados por coma (ej: Tank:🛡️:2, DPS:⚔️:6)",
+					Required:    false,
+				},
 			},
 		},
 		{
@@ -105,6 +183,29 @@ var (
 				},
 			},
 		},
+		{
+			Name:        "roster",
+			Description: "Descargar el roster de un evento (CSV, JSON o Markdown)",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "id",
+					Description: "ID del evento",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "formato",
+					Description: "Formato del archivo (por defecto CSV)",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "CSV (planillas)", Value: "csv"},
+						{Name: "JSON", Value: "json"},
+						{Name: "Markdown", Value: "md"},
+					},
+				},
+			},
+		},
 		{
 			Name:        "config",
 			Description: "Mostrar la configuración actual del bot",
@@ -113,6 +214,173 @@ var (
 			Name:        "list_events",
 			Description: "Listar todos los eventos activos",
 		},
+		{
+			Name:        "stats",
+			Description: "Ver la confiabilidad de un jugador o la tabla de posiciones",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "Jugador a consultar (vacío = tabla de posiciones)",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:        "profile",
+			Description: "Gestionar tus personajes (main y alts)",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Agregar o actualizar un personaje",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "nombre",
+							Description: "Nombre del personaje",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "clase",
+							Description: "Clase del personaje",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "rol",
+							Description: "Rol que juega (ej: Tank, Healer, DPS)",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "item_level",
+							Description: "Item level del personaje",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "notas",
+							Description: "Notas (spec, profesiones, etc.)",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "main",
+							Description: "Marcar como personaje principal",
+							Required:    false,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Eliminar un personaje",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "nombre",
+							Description: "Nombre del personaje",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "main",
+					Description: "Elegir tu personaje principal",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "nombre",
+							Description: "Nombre del personaje",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "show",
+					Description: "Ver los personajes de un jugador",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Name:        "user",
+							Description: "Jugador a consultar (vacío = tú)",
+							Required:    false,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "notifications",
+			Description: "Configurar tus avisos por mensaje privado",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "reminders",
+					Description: "Recordatorios por privado de los eventos en los que estás inscrito",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "activar",
+							Description: "Recibir recordatorios por mensaje privado",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "minutos",
+							Description: "Minutos de anticipación (0 = los del evento)",
+							Required:    false,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "announce",
+					Description: "Avisos por privado de nuevos eventos de un tipo",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "tipo",
+							Description: "Tipo de evento (ej: Raid) o \"todos\"",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "activar",
+							Description: "Recibir o dejar de recibir los avisos",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "show",
+					Description: "Ver tus preferencias de notificación",
+				},
+			},
+		},
+		{
+			Name:        "calendar",
+			Description: "Obtener tus calendarios de eventos para el celular (iCal)",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "tipo",
+					Description: "Agregar un calendario solo con un tipo de evento (ej: Raid)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "regenerar",
+					Description: "Generar direcciones nuevas e invalidar las anteriores",
+					Required:    false,
+				},
+			},
+		},
 	}
 )
 
@@ -122,6 +390,10 @@ func handleInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreat
 	case discordgo.InteractionApplicationCommand:
 		handleSlashCommand(s, i)
 	case discordgo.InteractionMessageComponent:
+		if i.MessageComponentData().ComponentType == discordgo.SelectMenuComponent {
+			handleSelectMenu(s, i)
+			return
+		}
 		handleButtonClick(s, i)
 	}
 }
@@ -133,14 +405,26 @@ func handleSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	switch commandName {
 	case "create_event":
 		handleCreateEvent(s, i)
+	case "edit_event":
+		handleEditEvent(s, i)
 	case "delete_event":
 		handleDeleteEvent(s, i)
 	case "remind_event":
 		handleRemindEvent(s, i)
+	case "roster":
+		handleRoster(s, i)
 	case "config":
 		handleConfig(s, i)
 	case "list_events":
 		handleListEvents(s, i)
+	case "stats":
+		handleStats(s, i)
+	case "profile":
+		handleProfile(s, i)
+	case "notifications":
+		handleNotifications(s, i)
+	case "calendar":
+		handleCalendar(s, i)
 	}
 }
 
@@ -149,7 +433,25 @@ func handleButtonClick(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	customID := i.MessageComponentData().CustomID
 
 	if eventID, role, class, ok := parseSignupCustomID(customID); ok {
-		handleSignup(s, i, eventID, role, class)
+		if class == "" && offerCharacterChoice(s, i, eventID, role) {
+			return
+		}
+		handleSignup(s, i, eventID, role, class, "")
+		return
+	}
+
+	if eventID, role, ok := parseRoleCustomID(customID); ok {
+		handleRoleClassChoice(s, i, eventID, role)
+		return
+	}
+
+	if eventID, userID, role, approve, ok := parseReviewCustomID(customID); ok {
+		handleReviewSignup(s, i, eventID, userID, role, approve)
+		return
+	}
+
+	if eventID, role, class, ok := parseWaitlistCustomID(customID); ok {
+		handleJoinWaitlist(s, i, eventID, role, class)
 		return
 	}
 
@@ -158,6 +460,28 @@ func handleButtonClick(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	}
 }
 
+// handleSelectMenu maneja las selecciones en menús desplegables
+func handleSelectMenu(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.MessageComponentData()
+	if len(data.Values) == 0 {
+		return
+	}
+
+	if eventID, role, ok := parseClassSelectCustomID(data.CustomID); ok {
+		handleSignup(s, i, eventID, role, data.Values[0], "")
+		return
+	}
+
+	if eventID, role, ok := parseCharacterSelectCustomID(data.CustomID); ok {
+		handleCharacterChoice(s, i, eventID, role, data.Values[0])
+		return
+	}
+
+	if eventID, attendance, ok := parseAttendanceCustomID(data.CustomID); ok {
+		handleAttendanceSelect(s, i, eventID, attendance, data.Values)
+	}
+}
+
 func parseSignupCustomID(customID string) (eventID, role, class string, ok bool) {
 	if !strings.HasPrefix(customID, "signup_") {
 		return "", "", "", false
@@ -182,6 +506,83 @@ func parseSignupCustomID(customID string) (eventID, role, class string, ok bool)
 	return eventID, role, class, true
 }
 
+func parseRoleCustomID(customID string) (eventID, role string, ok bool) {
+	if !strings.HasPrefix(customID, "role_") {
+		return "", "", false
+	}
+
+	payload := strings.TrimPrefix(customID, "role_")
+	underscoreIdx := strings.Index(payload, "_")
+	if underscoreIdx == -1 {
+		return "", "", false
+	}
+
+	return payload[:underscoreIdx], payload[underscoreIdx+1:], true
+}
+
+// parseClassSelectCustomID interpreta IDs con formato classsel_<evento>_<bloque>_<rol>
+func parseClassSelectCustomID(customID string) (eventID, role string, ok bool) {
+	if !strings.HasPrefix(customID, "classsel_") {
+		return "", "", false
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(customID, "classsel_"), "_", 3)
+	if len(parts) != 3 {
+		return "", "", false
+	}
+
+	return parts[0], parts[2], true
+}
+
+// parseCharacterSelectCustomID interpreta IDs con formato charsel_<evento>_<rol>
+func parseCharacterSelectCustomID(customID string) (eventID, role string, ok bool) {
+	if !strings.HasPrefix(customID, "charsel_") {
+		return "", "", false
+	}
+
+	eventID, role, found := strings.Cut(strings.TrimPrefix(customID, "charsel_"), "_")
+	if !found {
+		return "", "", false
+	}
+	return eventID, role, true
+}
+
+// parseReviewCustomID interpreta IDs con formato approve_<evento>_<usuario>_<rol>
+// o decline_<evento>_<usuario>_<rol>
+func parseReviewCustomID(customID string) (eventID, userID, role string, approve, ok bool) {
+	var payload string
+	switch {
+	case strings.HasPrefix(customID, "approve_"):
+		payload = strings.TrimPrefix(customID, "approve_")
+		approve = true
+	case strings.HasPrefix(customID, "decline_"):
+		payload = strings.TrimPrefix(customID, "decline_")
+	default:
+		return "", "", "", false, false
+	}
+
+	parts := strings.SplitN(payload, "_", 3)
+	if len(parts) != 3 {
+		return "", "", "", false, false
+	}
+
+	return parts[0], parts[1], parts[2], approve, true
+}
+
+// parseAttendanceCustomID interpreta IDs con formato attendance_<evento>_<estado>
+func parseAttendanceCustomID(customID string) (eventID, attendance string, ok bool) {
+	if !strings.HasPrefix(customID, "attendance_") {
+		return "", "", false
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(customID, "attendance_"), "_", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
 func parseCancelCustomID(customID string) (eventID string, ok bool) {
 	if !strings.HasPrefix(customID, "cancel_") {
 		return "", false
@@ -189,3 +590,23 @@ func parseCancelCustomID(customID string) (eventID string, ok bool) {
 
 	return strings.TrimPrefix(customID, "cancel_"), true
 }
+
+// parseWaitlistCustomID interpreta IDs con formato bench_<evento>[_<rol>[__<clase>]]
+func parseWaitlistCustomID(customID string) (eventID, role, class string, ok bool) {
+	if !strings.HasPrefix(customID, "bench_") {
+		return "", "", "", false
+	}
+
+	payload := strings.TrimPrefix(customID, "bench_")
+	eventID, rest, found := strings.Cut(payload, "_")
+	if !found {
+		return payload, "", "", true
+	}
+
+	role = rest
+	if sep := strings.Index(rest, "__"); sep != -1 {
+		role = rest[:sep]
+		class = rest[sep+2:]
+	}
+	return eventID, role, class, true
+}
@@ -1,6 +1,8 @@
 package discord
 
 import (
+	"discord-event-bot/internal/services/recurrence"
+	signupsvc "discord-event-bot/internal/services/signups"
 	"discord-event-bot/internal/storage"
 	"fmt"
 	"log"
@@ -32,10 +34,18 @@ func PublishEventMessage(s *discordgo.Session, event *storage.Event) error {
 		event.ThreadID = thread.ID
 	}
 
+	// Solo la primera publicación avisa a los suscriptos por privado
+	announce := !event.AnnouncementDMsSent
+	event.AnnouncementDMsSent = true
+
 	if err := storage.Store.SaveEvent(event); err != nil {
 		return fmt.Errorf("error guardando evento: %w", err)
 	}
 
+	if announce {
+		queueEventAnnouncement(event)
+	}
+
 	return nil
 }
 
@@ -102,14 +112,22 @@ func buildBaseEventEmbed(event *storage.Event) *discordgo.MessageEmbed {
 		},
 	}
 
-	if event.RepeatEveryDays > 0 {
+	if rule := recurrence.RuleFor(event); rule != nil {
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
 			Name:   "Recurrencia",
-			Value:  fmt.Sprintf("Cada %d días", event.RepeatEveryDays),
+			Value:  recurrence.Describe(rule),
 			Inline: true,
 		})
 	}
 
+	if requirements := signupsvc.DescribeRequirements(event.Requirements); requirements != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Requisitos",
+			Value:  requirements,
+			Inline: false,
+		})
+	}
+
 	return embed
 }
 
@@ -119,49 +137,10 @@ func buildSignupComponents(event *storage.Event, cancelLabel string) []discordgo
 	var currentRow discordgo.ActionsRow
 
 	for _, role := range event.Roles {
-		if len(role.Classes) > 0 {
-			// Botones por clase dentro del rol
-			for _, class := range role.Classes {
-				emojiComponent, isCustomEmoji := parseComponentEmoji(class.Emoji)
-
-				label := class.Name
-				if !isCustomEmoji && class.Emoji != "" {
-					label = fmt.Sprintf("%s %s", class.Emoji, class.Name)
-				}
-
-				button := discordgo.Button{
-					Label:    label,
-					Style:    discordgo.PrimaryButton,
-					CustomID: fmt.Sprintf("signup_%s_%s__%s", event.ID, role.Name, class.Name),
-				}
-				if emojiComponent != nil {
-					button.Emoji = emojiComponent
-				}
-
-				currentRow.Components = append(currentRow.Components, button)
-				if len(currentRow.Components) == 5 {
-					components = append(components, currentRow)
-					currentRow = discordgo.ActionsRow{}
-				}
-			}
-		} else {
-			// Botón único por rol
-			label := role.Name
-			if role.Emoji != "" {
-				label = fmt.Sprintf("%s %s", role.Emoji, role.Name)
-			}
-
-			button := discordgo.Button{
-				Label:    label,
-				Style:    discordgo.PrimaryButton,
-				CustomID: fmt.Sprintf("signup_%s_%s", event.ID, role.Name),
-			}
-
-			currentRow.Components = append(currentRow.Components, button)
-			if len(currentRow.Components) == 5 {
-				components = append(components, currentRow)
-				currentRow = discordgo.ActionsRow{}
-			}
+		currentRow.Components = append(currentRow.Components, roleSignupButton(event, role))
+		if len(currentRow.Components) == 5 {
+			components = append(components, currentRow)
+			currentRow = discordgo.ActionsRow{}
 		}
 	}
 
@@ -179,11 +158,117 @@ func buildSignupComponents(event *storage.Event, cancelLabel string) []discordgo
 			},
 		},
 	}
+
+	// Botón de banca solo cuando algún rol ya está lleno
+	if len(signupsvc.FullRoles(event)) > 0 {
+		cancelRow.Components = append(cancelRow.Components, discordgo.Button{
+			Label:    "🪑 Unirse a la banca",
+			Style:    discordgo.SecondaryButton,
+			CustomID: fmt.Sprintf("bench_%s", event.ID),
+		})
+	}
 	components = append(components, cancelRow)
 
 	return components
 }
 
+// roleSignupButton arma el botón de inscripción de un rol
+func roleSignupButton(event *storage.Event, role storage.RoleSignup) discordgo.Button {
+	emojiComponent, isCustomEmoji := parseComponentEmoji(role.Emoji)
+
+	label := role.Name
+	if !isCustomEmoji && role.Emoji != "" {
+		label = fmt.Sprintf("%s %s", role.Emoji, role.Name)
+	}
+
+	// Los roles con clases abren un menú de selección; el resto inscribe directo
+	customID := fmt.Sprintf("signup_%s_%s", event.ID, role.Name)
+	if len(role.Classes) > 0 {
+		customID = fmt.Sprintf("role_%s_%s", event.ID, role.Name)
+	}
+
+	button := discordgo.Button{
+		Label:    label,
+		Style:    discordgo.PrimaryButton,
+		CustomID: customID,
+	}
+	if emojiComponent != nil {
+		button.Emoji = emojiComponent
+	}
+	return button
+}
+
+const (
+	maxSelectOptions = 25
+	maxActionRows    = 5
+)
+
+// buildClassSelectMenus arma uno o más menús de selección con las clases de un rol
+func buildClassSelectMenus(event *storage.Event, role storage.RoleSignup) []discordgo.MessageComponent {
+	var components []discordgo.MessageComponent
+
+	for start := 0; start < len(role.Classes); start += maxSelectOptions {
+		end := start + maxSelectOptions
+		if end > len(role.Classes) {
+			end = len(role.Classes)
+		}
+
+		options := make([]discordgo.SelectMenuOption, 0, end-start)
+		for _, class := range role.Classes[start:end] {
+			option := discordgo.SelectMenuOption{
+				Label:       class.Name,
+				Value:       class.Name,
+				Description: truncateText(class.Description, 100),
+				Emoji:       selectOptionEmoji(class.Emoji),
+			}
+			if signupsvc.IsClassFull(event, role.Name, class.Name) {
+				option.Description = "Llena"
+			}
+			options = append(options, option)
+		}
+
+		placeholder := "Seleccioná tu clase"
+		if len(role.Classes) > maxSelectOptions {
+			placeholder = fmt.Sprintf("Seleccioná tu clase (%d-%d)", start+1, end)
+		}
+
+		components = append(components, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    fmt.Sprintf("classsel_%s_%d_%s", event.ID, start/maxSelectOptions, role.Name),
+					Placeholder: placeholder,
+					Options:     options,
+				},
+			},
+		})
+	}
+
+	return components
+}
+
+// selectOptionEmoji convierte un emoji (unicode o personalizado) al formato de las opciones de menú
+func selectOptionEmoji(raw string) *discordgo.ComponentEmoji {
+	if emoji, isCustom := parseComponentEmoji(raw); isCustom {
+		return emoji
+	}
+
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+
+	return &discordgo.ComponentEmoji{Name: raw}
+}
+
+// truncateText recorta un texto a max runas (límite de Discord en varios campos)
+func truncateText(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-1]) + "…"
+}
+
 // UpdateEventMessage actualiza el mensaje del evento
 func UpdateEventMessage(s *discordgo.Session, event *storage.Event) {
 	// Recargar evento para obtener datos actualizados
@@ -205,21 +290,43 @@ func buildSignupsText(event *storage.Event) string {
 	var builder strings.Builder
 
 	for _, role := range event.Roles {
-		signups := event.Signups[role.Name]
+		// Separar confirmados de pendientes; los rechazados no se muestran
+		var signups, pending []storage.Signup
+		for _, signup := range event.Signups[role.Name] {
+			switch signup.Status {
+			case "confirmed":
+				signups = append(signups, signup)
+			case "pending":
+				pending = append(pending, signup)
+			}
+		}
 
-		// Cabecera del rol con contador simple de inscriptos
+		// Cabecera del rol con los lugares ocupados (confirmados y pendientes),
+		// igual que los límites
 		limitText := "∞"
 		if role.Limit > 0 {
 			limitText = fmt.Sprintf("%d", role.Limit)
 		}
 		builder.WriteString(fmt.Sprintf("%s **%s**: %d/%s\n",
-			role.Emoji, role.Name, len(signups), limitText))
+			role.Emoji, role.Name, len(signups)+len(pending), limitText))
 
 		// Listado de nombres debajo del rol
 		for _, signup := range signups {
+			if signup.Character != "" {
+				builder.WriteString(fmt.Sprintf("- %s (%s)\n", signup.Username, signup.Character))
+				continue
+			}
 			builder.WriteString(fmt.Sprintf("- %s\n", signup.Username))
 		}
 
+		if len(pending) > 0 {
+			names := make([]string, 0, len(pending))
+			for _, signup := range pending {
+				names = append(names, signup.Username)
+			}
+			builder.WriteString(fmt.Sprintf("  ⏳ Pendientes: %s\n", strings.Join(names, ", ")))
+		}
+
 		// Si hay clases definidas, mostrar desglose por clase
 		if len(role.Classes) > 0 {
 			classCounts := make(map[string]int)
@@ -231,13 +338,25 @@ func buildSignupsText(event *storage.Event) string {
 
 			for _, class := range role.Classes {
 				count := classCounts[class.Name]
-				if count > 0 {
+				if class.Limit > 0 {
+					builder.WriteString(fmt.Sprintf("  %s %s %d/%d\n", class.Emoji, class.Name, count, class.Limit))
+				} else if count > 0 {
 					builder.WriteString(fmt.Sprintf("  %s %s: %d\n", class.Emoji, class.Name, count))
 				}
 			}
 		}
 	}
 
+	// Banca (lista de espera) por rol, en orden de llegada
+	if len(event.Waitlist) > 0 {
+		builder.WriteString("\n🪑 **Banca**\n")
+		for _, role := range event.Roles {
+			for _, signup := range event.Waitlist[role.Name] {
+				builder.WriteString(fmt.Sprintf("- %s (%s)\n", signup.Username, role.Name))
+			}
+		}
+	}
+
 	if builder.Len() == 0 {
 		return "Sin inscripciones aún"
 	}
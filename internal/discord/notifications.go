@@ -0,0 +1,216 @@
+package discord
+
+import (
+	"discord-event-bot/internal/services/guilds"
+	"discord-event-bot/internal/services/notifications"
+	remindersvc "discord-event-bot/internal/services/reminders"
+	"discord-event-bot/internal/storage"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Los mensajes privados salen de a uno desde una cola para no chocar con los
+// límites de Discord: una pausa corta entre mensajes y una más larga cada lote
+const (
+	dmQueueSize  = 1000
+	dmBatchSize  = 10
+	dmInterval   = 250 * time.Millisecond
+	dmBatchPause = 5 * time.Second
+)
+
+// directMessage es un mensaje privado pendiente de envío. Con GuildID solo
+// se entrega si el usuario es miembro de ese servidor.
+type directMessage struct {
+	UserID  string
+	GuildID string
+	Content string
+}
+
+var dmQueue = make(chan directMessage, dmQueueSize)
+
+// startDirectMessageWorker procesa la cola de mensajes privados en segundo plano
+func startDirectMessageWorker() {
+	go func() {
+		sent := 0
+		for msg := range dmQueue {
+			if Session == nil {
+				continue
+			}
+			deliverDirectMessage(Session, msg)
+
+			sent++
+			if sent%dmBatchSize == 0 && len(dmQueue) > 0 {
+				time.Sleep(dmBatchPause)
+			} else {
+				time.Sleep(dmInterval)
+			}
+		}
+	}()
+}
+
+// queueDirectMessage encola un mensaje privado. Si la cola está llena se
+// descarta y se registra como fallo para que el jugador lo vea.
+func queueDirectMessage(msg directMessage) {
+	select {
+	case dmQueue <- msg:
+	default:
+		log.Printf("Cola de mensajes privados llena, se descarta el mensaje para %s", msg.UserID)
+		recordDirectMessageFailure(msg.UserID, "cola de envío llena")
+	}
+}
+
+func deliverDirectMessage(s *discordgo.Session, msg directMessage) {
+	if msg.GuildID != "" && !isGuildMember(s, msg.GuildID, msg.UserID) {
+		return
+	}
+
+	if err := sendDirectMessage(s, msg.UserID, msg.Content); err != nil {
+		log.Printf("Error enviando mensaje privado a %s: %v", msg.UserID, err)
+		recordDirectMessageFailure(msg.UserID, directMessageFailureReason(err))
+		return
+	}
+
+	if err := storage.Notifications.ClearDMFailures(msg.UserID); err != nil {
+		log.Printf("Error actualizando preferencias de %s: %v", msg.UserID, err)
+	}
+}
+
+// isGuildMember indica si el usuario está en el servidor
+func isGuildMember(s *discordgo.Session, guildID, userID string) bool {
+	if _, err := s.State.Member(guildID, userID); err == nil {
+		return true
+	}
+	_, err := s.GuildMember(guildID, userID)
+	return err == nil
+}
+
+func recordDirectMessageFailure(userID, reason string) {
+	if err := storage.Notifications.RecordDMFailure(userID, reason); err != nil {
+		log.Printf("Error registrando fallo de mensaje privado para %s: %v", userID, err)
+	}
+}
+
+// directMessageFailureReason traduce los errores más comunes de Discord
+func directMessageFailureReason(err error) string {
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) {
+		if restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeCannotSendMessagesToThisUser {
+			return "mensajes directos cerrados"
+		}
+		if restErr.Response != nil && restErr.Response.StatusCode == http.StatusTooManyRequests {
+			return "límite de envíos de Discord"
+		}
+	}
+	return err.Error()
+}
+
+func deliverDirectReminders(result remindersvc.ProcessResult) {
+	// Encolar los recordatorios por mensaje privado
+	for _, reminder := range result.DirectReminders {
+		for _, userID := range reminder.UserIDs {
+			queueDirectMessage(directMessage{
+				UserID:  userID,
+				Content: buildDirectReminderContent(reminder.Event, userID),
+			})
+		}
+	}
+}
+
+func buildDirectReminderContent(event *storage.Event, userID string) string {
+	content := fmt.Sprintf("🔔 **Recordatorio**: **%s** comienza <t:%d:R> (<t:%d:F>).",
+		event.Name, event.DateTime.Unix(), event.DateTime.Unix())
+
+	for role, signups := range event.Signups {
+		for _, signup := range signups {
+			if signup.UserID == userID && signup.Status == "confirmed" {
+				content += fmt.Sprintf("\nEstás inscrito como **%s**.", role)
+			}
+		}
+	}
+
+	if link := eventMessageLink(event); link != "" {
+		content += "\n" + link
+	}
+	return content + "\n\nPuedes cambiar estos avisos con `/notifications`."
+}
+
+// queueEventAnnouncement avisa por privado a quienes siguen el tipo del evento
+func queueEventAnnouncement(event *storage.Event) {
+	content := fmt.Sprintf("📢 Nuevo evento de **%s**: **%s**, <t:%d:F>.", event.Type, event.Name, event.DateTime.Unix())
+	if link := eventMessageLink(event); link != "" {
+		content += "\nInscríbete aquí: " + link
+	}
+	content += "\n\nPuedes cambiar estos avisos con `/notifications`."
+
+	for _, userID := range notifications.AnnouncementRecipients(event) {
+		// Los avisos son por tipo de evento: solo llegan a los miembros del servidor
+		queueDirectMessage(directMessage{UserID: userID, GuildID: guilds.EventGuild(event), Content: content})
+	}
+}
+
+func eventMessageLink(event *storage.Event) string {
+	if event.MessageID == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guilds.EventGuild(event), event.Channel, event.MessageID)
+}
+
+// handleNotifications procesa los subcomandos de /notifications
+func handleNotifications(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 {
+		respondError(s, i, "Subcomando inválido")
+		return
+	}
+
+	sub := options[0]
+	params := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(sub.Options))
+	for _, opt := range sub.Options {
+		params[opt.Name] = opt
+	}
+
+	user := i.Member.User
+	var (
+		prefs storage.NotificationPrefs
+		err   error
+	)
+
+	switch sub.Name {
+	case "reminders":
+		input := notifications.ReminderInput{
+			UserID:   user.ID,
+			Username: user.Username,
+			Enabled:  params["activar"].BoolValue(),
+		}
+		if opt, ok := params["minutos"]; ok {
+			minutes := int(opt.IntValue())
+			input.LeadMinutes = &minutes
+		}
+		prefs, err = notifications.SetReminders(input)
+	case "announce":
+		prefs, err = notifications.SetAnnouncement(user.ID, user.Username,
+			params["tipo"].StringValue(), params["activar"].BoolValue())
+	case "show":
+		prefs, _ = storage.Notifications.GetPrefs(user.ID)
+	default:
+		err = fmt.Errorf("Subcomando inválido")
+	}
+
+	if err != nil {
+		respondError(s, i, err.Error())
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "⚙️ **Tus notificaciones**\n" + notifications.Describe(prefs),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
@@ -0,0 +1,172 @@
+package discord
+
+import (
+	"discord-event-bot/internal/services/profiles"
+	"discord-event-bot/internal/storage"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Valor de la opción para inscribirse sin elegir un personaje del perfil
+const noCharacterOption = "none"
+
+// handleProfile procesa los subcomandos de /profile
+func handleProfile(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 {
+		respondError(s, i, "Subcomando inválido")
+		return
+	}
+
+	sub := options[0]
+	params := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(sub.Options))
+	for _, opt := range sub.Options {
+		params[opt.Name] = opt
+	}
+
+	userID := i.Member.User.ID
+	var (
+		profile *storage.Profile
+		message string
+		err     error
+	)
+
+	switch sub.Name {
+	case "add":
+		input := profiles.CharacterInput{
+			UserID:   userID,
+			Username: i.Member.User.Username,
+			Name:     params["nombre"].StringValue(),
+			Class:    params["clase"].StringValue(),
+		}
+		if opt, ok := params["rol"]; ok {
+			input.Role = opt.StringValue()
+		}
+		if opt, ok := params["item_level"]; ok {
+			input.ItemLevel = int(opt.IntValue())
+		}
+		if opt, ok := params["notas"]; ok {
+			input.Notes = opt.StringValue()
+		}
+		if opt, ok := params["main"]; ok {
+			input.Main = opt.BoolValue()
+		}
+		profile, err = profiles.SaveCharacter(input)
+		message = fmt.Sprintf("✅ Personaje **%s** guardado", strings.TrimSpace(input.Name))
+	case "remove":
+		name := params["nombre"].StringValue()
+		profile, err = profiles.RemoveCharacter(userID, name)
+		message = fmt.Sprintf("🗑️ Personaje **%s** eliminado", name)
+	case "main":
+		name := params["nombre"].StringValue()
+		profile, err = profiles.SetMain(userID, name)
+		message = fmt.Sprintf("⭐ **%s** es ahora tu main", name)
+	case "show":
+		if opt, ok := params["user"]; ok {
+			userID = opt.UserValue(nil).ID
+		}
+		profile, err = storage.Profiles.GetProfile(userID)
+		if err != nil {
+			err = fmt.Errorf("<@%s> todavía no tiene personajes. Se agregan con `/profile add`", userID)
+		}
+	default:
+		err = fmt.Errorf("Subcomando inválido")
+	}
+
+	if err != nil {
+		respondError(s, i, err.Error())
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: message,
+			Embeds:  []*discordgo.MessageEmbed{buildProfileEmbed(profile)},
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+func buildProfileEmbed(profile *storage.Profile) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("🧙 Personajes de %s", profile.Username),
+		Description: fmt.Sprintf("<@%s>", profile.UserID),
+		Color:       0x5865F2,
+	}
+
+	if len(profile.Characters) == 0 {
+		embed.Description += "\nSin personajes"
+		return embed
+	}
+
+	for _, character := range profile.Characters {
+		name := character.Name
+		if character.Main {
+			name = "⭐ " + name
+		}
+		value := profiles.Describe(character)
+		if character.Notes != "" {
+			value += "\n" + character.Notes
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  name,
+			Value: truncateText(value, 1024),
+		})
+	}
+	return embed
+}
+
+// offerCharacterChoice responde con un menú para elegir con qué personaje
+// inscribirse. Devuelve false si el jugador no tiene personajes en su perfil.
+func offerCharacterChoice(s *discordgo.Session, i *discordgo.InteractionCreate, eventID, role string) bool {
+	characters := profiles.CharactersForSignup(i.Member.User.ID, role)
+	if len(characters) == 0 {
+		return false
+	}
+
+	options := make([]discordgo.SelectMenuOption, 0, len(characters)+1)
+	for _, character := range characters {
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       truncateText(character.Name, 100),
+			Value:       character.ID,
+			Description: truncateText(profiles.Describe(character), 100),
+		})
+	}
+	options = append(options, discordgo.SelectMenuOption{
+		Label: "Sin personaje",
+		Value: noCharacterOption,
+	})
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("¿Con qué personaje te inscribes como **%s**?", role),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.SelectMenu{
+							CustomID:    fmt.Sprintf("charsel_%s_%s", eventID, role),
+							Placeholder: "Selecciona tu personaje",
+							Options:     options,
+						},
+					},
+				},
+			},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	return true
+}
+
+// handleCharacterChoice inscribe con el personaje elegido; sin personaje
+// sigue el flujo normal de elección de clase
+func handleCharacterChoice(s *discordgo.Session, i *discordgo.InteractionCreate, eventID, role, characterID string) {
+	if characterID == noCharacterOption {
+		respondClassChoice(s, i, eventID, role)
+		return
+	}
+	handleSignup(s, i, eventID, role, "", characterID)
+}
@@ -1,10 +1,14 @@
 package discord
 
 import (
+	"discord-event-bot/internal/services/guilds"
 	remindersvc "discord-event-bot/internal/services/reminders"
+	signupsvc "discord-event-bot/internal/services/signups"
 	"discord-event-bot/internal/storage"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"strings"
 	"time"
 
@@ -13,6 +17,8 @@ import (
 
 // StartReminderService inicia el servicio de recordatorios automáticos
 func StartReminderService() {
+	startDirectMessageWorker()
+
 	ticker := time.NewTicker(1 * time.Minute)
 	go func() {
 		for range ticker.C {
@@ -28,14 +34,29 @@ func checkAndSendReminders() {
 	result := remindersvc.ProcessReminders(time.Now())
 
 	deliverReminders(result)
+	deliverDirectReminders(result)
+	deliverFillIns(result)
+	deliverAttendancePanels(result)
 	updateEventMessages(result)
 	cleanupEventMessages(result)
+	syncScheduledEvents(result)
 }
 
 func deliverReminders(result remindersvc.ProcessResult) {
-	// Enviar recordatorios a través de Discord
-	for _, event := range result.EventsToRemind {
-		sendReminder(Session, event)
+	// Enviar la etapa de recordatorio que corresponde a cada evento
+	for _, reminder := range result.Reminders {
+		log.Printf("🔔 Recordatorio %d/%d (%s antes) para evento %s", reminder.Stage, reminder.Stages,
+			remindersvc.FormatOffset(reminder.OffsetMinutes), reminder.Event.ID)
+		sendReminder(Session, reminder.Event, reminder.Final)
+	}
+}
+
+func deliverAttendancePanels(result remindersvc.ProcessResult) {
+	// Publicar el panel de asistencia de los eventos que empezaron
+	for _, event := range result.EventsToTakeAttendance {
+		if Session != nil {
+			sendAttendancePanel(Session, event)
+		}
 	}
 }
 
@@ -78,6 +99,68 @@ func cleanupEventMessages(result remindersvc.ProcessResult) {
 	}
 }
 
+func syncScheduledEvents(result remindersvc.ProcessResult) {
+	// Llevar los eventos oficiales de Discord al estado de cada evento
+	if Session == nil {
+		return
+	}
+	for _, change := range result.ScheduledEvents {
+		event := change.Event
+		switch change.Action {
+		case remindersvc.ScheduledEventStart:
+			applyScheduledEventStatus(event, discordgo.GuildScheduledEventStatusActive, "active")
+		case remindersvc.ScheduledEventEnd:
+			// Discord solo termina eventos que ya empezaron
+			if event.DiscordEventStatus == "" && !applyScheduledEventStatus(event, discordgo.GuildScheduledEventStatusActive, "active") {
+				continue
+			}
+			applyScheduledEventStatus(event, discordgo.GuildScheduledEventStatusCompleted, "completed")
+		case remindersvc.ScheduledEventRoll:
+			if guilds.DiscordEventsEnabled(event.GuildID) {
+				CreateDiscordScheduledEvent(Session, event)
+			}
+		}
+	}
+}
+
+// applyScheduledEventStatus cambia el estado del evento de Discord y lo guarda
+// solo si Discord lo aceptó; si Discord lo rechaza de forma definitiva (el
+// evento ya no existe, falta permiso, etc.) se olvida el vínculo para no
+// reintentarlo
+func applyScheduledEventStatus(event *storage.Event, status discordgo.GuildScheduledEventStatus, label string) bool {
+	if event.DiscordEventID == "" {
+		return false
+	}
+
+	err := setDiscordScheduledEventStatus(Session, event, status)
+	if err != nil {
+		log.Printf("Error pasando a %s el evento de Discord %s: %v", label, event.DiscordEventID, err)
+		if !permanentScheduledEventError(err) {
+			return false
+		}
+		event.DiscordEventID = ""
+		event.DiscordEventStatus = ""
+	} else {
+		event.DiscordEventStatus = label
+	}
+
+	if saveErr := storage.Store.SaveEvent(event); saveErr != nil {
+		log.Printf("Error guardando estado del evento de Discord del evento %s: %v", event.ID, saveErr)
+	}
+	return err == nil
+}
+
+// permanentScheduledEventError indica si Discord rechazó el cambio con un
+// error 4xx que no se arregla reintentando; un 429 es solo rate limit
+func permanentScheduledEventError(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Response == nil {
+		return false
+	}
+	status := restErr.Response.StatusCode
+	return status >= 400 && status < 500 && status != http.StatusTooManyRequests
+}
+
 func checkAndPublishScheduledEvents() {
 	if Session == nil {
 		return
@@ -123,12 +206,12 @@ func handleRemindEvent(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	eventID := options[0].StringValue()
 
 	event, err := storage.Store.GetEvent(eventID)
-	if err != nil {
+	if err != nil || !guilds.InGuild(event, i.GuildID) {
 		respondError(s, i, "Evento no encontrado")
 		return
 	}
 
-	sendReminder(s, event)
+	sendReminder(s, event, false)
 
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -139,8 +222,9 @@ func handleRemindEvent(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	})
 }
 
-// sendReminder envía un recordatorio del evento
-func sendReminder(s *discordgo.Session, event *storage.Event) {
+// sendReminder envía un recordatorio del evento. En la última etapa se
+// agrega qué roles siguen sin cubrir.
+func sendReminder(s *discordgo.Session, event *storage.Event, final bool) {
 	var mentions []string
 	for _, signups := range event.Signups {
 		for _, signup := range signups {
@@ -177,6 +261,12 @@ func sendReminder(s *discordgo.Session, event *storage.Event) {
 		event.DateTime.Unix(),
 		strings.Join(mentions, " "))
 
+	if final {
+		if missing := missingSlotsText(event); missing != "" {
+			content += "\n\n⚠️ Todavía faltan: " + missing
+		}
+	}
+
 	// Enviar al hilo del evento si existe, con fallback al canal principal
 	targetChannelID := event.Channel
 	if event.ThreadID != "" {
@@ -189,3 +279,13 @@ func sendReminder(s *discordgo.Session, event *storage.Event) {
 
 	s.ChannelMessageSend(targetChannelID, content)
 }
+
+// missingSlotsText resume los lugares libres por rol, como "1 Healer, 2 DPS"
+func missingSlotsText(event *storage.Event) string {
+	gaps := signupsvc.MissingSlots(event)
+	parts := make([]string, 0, len(gaps))
+	for _, gap := range gaps {
+		parts = append(parts, strings.TrimSpace(fmt.Sprintf("%s %d %s", gap.Role.Emoji, gap.Missing, gap.Role.Name)))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,49 @@
+package discord
+
+import (
+	"bytes"
+	"discord-event-bot/internal/services/guilds"
+	"discord-event-bot/internal/services/roster"
+	"discord-event-bot/internal/storage"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleRoster responde con el roster del evento como archivo adjunto
+func handleRoster(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	params := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+	for _, opt := range i.ApplicationCommandData().Options {
+		params[opt.Name] = opt
+	}
+
+	event, err := storage.Store.GetEvent(params["id"].StringValue())
+	if err != nil || !guilds.InGuild(event, i.GuildID) {
+		respondError(s, i, "Evento no encontrado")
+		return
+	}
+
+	format := ""
+	if opt, ok := params["formato"]; ok {
+		format = opt.StringValue()
+	}
+
+	export, err := roster.Build(event, format)
+	if err != nil {
+		respondError(s, i, err.Error())
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("📋 Roster de **%s** (<t:%d:F>)", event.Name, event.DateTime.Unix()),
+			Files: []*discordgo.File{{
+				Name:        export.Filename,
+				ContentType: export.ContentType,
+				Reader:      bytes.NewReader(export.Data),
+			}},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
@@ -2,24 +2,34 @@ package discord
 
 import (
 	signupsvc "discord-event-bot/internal/services/signups"
+	"discord-event-bot/internal/storage"
 	"fmt"
+	"log"
 
 	"github.com/bwmarrin/discordgo"
 )
 
-// handleSignup maneja las inscripciones
-func handleSignup(s *discordgo.Session, i *discordgo.InteractionCreate, eventID, role, class string) {
+// handleSignup maneja las inscripciones. El personaje es opcional y, si se
+// indica, define la clase cuando no se eligió una.
+func handleSignup(s *discordgo.Session, i *discordgo.InteractionCreate, eventID, role, class, character string) {
 	userID := i.Member.User.ID
 	username := i.Member.User.Username
 
 	event, err := signupsvc.SignupToEvent(signupsvc.SignupInput{
-		EventID:  eventID,
-		UserID:   userID,
-		Username: username,
-		Role:     role,
-		Class:    class,
+		EventID:     eventID,
+		UserID:      userID,
+		Username:    username,
+		Role:        role,
+		Class:       class,
+		Character:   character,
+		MemberRoles: i.Member.Roles,
 	})
 	if err != nil {
+		// Con el rol o la clase llenos se ofrece la banca de esa misma clase
+		if event, getErr := storage.Store.GetEvent(eventID); getErr == nil && character == "" && signupsvc.CanJoinWaitlist(event, role, class) {
+			respondWaitlistOffer(s, i, err.Error(), eventID, role, class)
+			return
+		}
 		respondError(s, i, err.Error())
 		return
 	}
@@ -28,22 +38,85 @@ func handleSignup(s *discordgo.Session, i *discordgo.InteractionCreate, eventID,
 	UpdateEventMessage(s, event)
 
 	label := role
-	if class != "" {
-		label = fmt.Sprintf("%s - %s", role, class)
+	signup, found := signupsvc.FindSignup(event, role, userID)
+	if found && signup.Class != "" {
+		label = fmt.Sprintf("%s - %s", role, signup.Class)
+	}
+	if found && signup.Character != "" {
+		label = fmt.Sprintf("%s (%s)", label, signup.Character)
+	}
+
+	content := fmt.Sprintf("✅ Te has inscrito como **%s**. Tu inscripción está confirmada.", label)
+	if found && signup.Status == "pending" {
+		content = fmt.Sprintf("⏳ Te has inscrito como **%s**. Tu inscripción está pendiente de aprobación por un oficial.", label)
+		RequestSignupApproval(s, event, signup)
 	}
 
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("✅ Te has inscrito como **%s**. Tu inscripción está confirmada.", label),
+			Content: content,
 			Flags:   discordgo.MessageFlagsEphemeral,
 		},
 	})
 }
 
+// handleRoleClassChoice ofrece primero los personajes del perfil del jugador
+// y, si no tiene, el menú de clases del rol
+func handleRoleClassChoice(s *discordgo.Session, i *discordgo.InteractionCreate, eventID, roleName string) {
+	if offerCharacterChoice(s, i, eventID, roleName) {
+		return
+	}
+	respondClassChoice(s, i, eventID, roleName)
+}
+
+// respondClassChoice responde con un menú efímero para elegir la clase
+// dentro de un rol. Discord permite 25 opciones por menú y 5 menús por
+// mensaje, así que las clases se reparten en bloques.
+func respondClassChoice(s *discordgo.Session, i *discordgo.InteractionCreate, eventID, roleName string) {
+	event, err := storage.Store.GetEvent(eventID)
+	if err != nil {
+		respondError(s, i, "Evento no encontrado")
+		return
+	}
+
+	var role *storage.RoleSignup
+	for idx := range event.Roles {
+		if event.Roles[idx].Name == roleName {
+			role = &event.Roles[idx]
+			break
+		}
+	}
+	if role == nil {
+		respondError(s, i, "El rol ya no existe en este evento")
+		return
+	}
+
+	if len(role.Classes) == 0 {
+		handleSignup(s, i, eventID, roleName, "", "")
+		return
+	}
+
+	if len(role.Classes) > maxSelectOptions*maxActionRows {
+		respondError(s, i, fmt.Sprintf("El rol %s tiene demasiadas clases para mostrarlas en Discord", roleName))
+		return
+	}
+
+	components := buildClassSelectMenus(event, *role)
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:    fmt.Sprintf("Elegí tu clase para **%s** en **%s**:", roleName, event.Name),
+			Components: components,
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
 // handleCancelSignup maneja la cancelación de inscripción
 func handleCancelSignup(s *discordgo.Session, i *discordgo.InteractionCreate, eventID string) {
-	event, err := signupsvc.CancelSignup(signupsvc.CancelInput{
+	event, promoted, err := signupsvc.CancelSignup(signupsvc.CancelInput{
 		EventID: eventID,
 		UserID:  i.Member.User.ID,
 	})
@@ -53,6 +126,7 @@ func handleCancelSignup(s *discordgo.Session, i *discordgo.InteractionCreate, ev
 	}
 
 	UpdateEventMessage(s, event)
+	NotifyWaitlistPromotions(s, event, promoted)
 
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -62,3 +136,168 @@ func handleCancelSignup(s *discordgo.Session, i *discordgo.InteractionCreate, ev
 		},
 	})
 }
+
+// handleJoinWaitlist maneja el botón de banca. Si no se indicó rol, ofrece
+// los roles que ya están llenos para que el usuario elija. La clase, si se
+// eligió, se guarda con el lugar en la banca.
+func handleJoinWaitlist(s *discordgo.Session, i *discordgo.InteractionCreate, eventID, role, class string) {
+	if role == "" {
+		event, err := storage.Store.GetEvent(eventID)
+		if err != nil {
+			respondError(s, i, "Evento no encontrado")
+			return
+		}
+
+		fullRoles := signupsvc.FullRoles(event)
+		switch len(fullRoles) {
+		case 0:
+			respondError(s, i, "Todavía hay lugares libres en todos los roles, inscribite directamente")
+			return
+		case 1:
+			role = fullRoles[0].Name
+		default:
+			respondWaitlistRoleChoice(s, i, event, fullRoles)
+			return
+		}
+	}
+
+	_, err := signupsvc.JoinWaitlist(signupsvc.SignupInput{
+		EventID:     eventID,
+		UserID:      i.Member.User.ID,
+		Username:    i.Member.User.Username,
+		Role:        role,
+		Class:       class,
+		MemberRoles: i.Member.Roles,
+	})
+	if err != nil {
+		respondError(s, i, err.Error())
+		return
+	}
+
+	event, _ := storage.Store.GetEvent(eventID)
+	if event != nil {
+		UpdateEventMessage(s, event)
+	}
+
+	label := role
+	if class != "" {
+		label = fmt.Sprintf("%s - %s", role, class)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("🪑 Estás en la banca de **%s**. Te avisaremos por mensaje privado si se libera un lugar.", label),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// respondWaitlistOffer explica por qué no se pudo inscribir y ofrece la banca
+// del rol y la clase elegidos
+func respondWaitlistOffer(s *discordgo.Session, i *discordgo.InteractionCreate, reason, eventID, role, class string) {
+	customID := fmt.Sprintf("bench_%s_%s", eventID, role)
+	if class != "" {
+		customID += "__" + class
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "❌ " + reason,
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.Button{
+							Label:    "🪑 Unirme a la banca",
+							Style:    discordgo.SecondaryButton,
+							CustomID: customID,
+						},
+					},
+				},
+			},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+func respondWaitlistRoleChoice(s *discordgo.Session, i *discordgo.InteractionCreate, event *storage.Event, fullRoles []storage.RoleSignup) {
+	var rows []discordgo.MessageComponent
+	var currentRow discordgo.ActionsRow
+
+	for _, role := range fullRoles {
+		label := role.Name
+		if role.Emoji != "" {
+			label = fmt.Sprintf("%s %s", role.Emoji, role.Name)
+		}
+
+		currentRow.Components = append(currentRow.Components, discordgo.Button{
+			Label:    label,
+			Style:    discordgo.SecondaryButton,
+			CustomID: fmt.Sprintf("bench_%s_%s", event.ID, role.Name),
+		})
+		if len(currentRow.Components) == 5 {
+			rows = append(rows, currentRow)
+			currentRow = discordgo.ActionsRow{}
+		}
+	}
+
+	if len(currentRow.Components) > 0 {
+		rows = append(rows, currentRow)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:    "¿En la banca de qué rol querés esperar?",
+			Components: rows,
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// NotifyWaitlistPromotions avisa por mensaje privado a los usuarios que
+// pasaron de la banca a la lista de inscriptos
+func NotifyWaitlistPromotions(s *discordgo.Session, event *storage.Event, promoted []storage.Signup) {
+	for _, signup := range promoted {
+		content := fmt.Sprintf("🎉 Se liberó un lugar en **%s** (<t:%d:F>). Pasaste de la banca a inscripto como **%s**.",
+			event.Name, event.DateTime.Unix(), signup.Role)
+		if signup.Status == "pending" {
+			content = fmt.Sprintf("🎉 Se liberó un lugar en **%s** (<t:%d:F>). Pasaste de la banca a inscripto como **%s**, pendiente de aprobación.",
+				event.Name, event.DateTime.Unix(), signup.Role)
+			RequestSignupApproval(s, event, signup)
+		}
+		if err := sendDirectMessage(s, signup.UserID, content); err != nil {
+			log.Printf("Error avisando promoción de banca a %s en evento %s: %v", signup.UserID, event.ID, err)
+		}
+	}
+}
+
+// MemberRoles devuelve los IDs de roles de un miembro del servidor, primero
+// desde el estado de la sesión y si no está, consultando a Discord
+func MemberRoles(s *discordgo.Session, guildID, userID string) []string {
+	if member, err := s.State.Member(guildID, userID); err == nil {
+		return member.Roles
+	}
+
+	member, err := s.GuildMember(guildID, userID)
+	if err != nil {
+		log.Printf("Error obteniendo roles de %s: %v", userID, err)
+		return nil
+	}
+	return member.Roles
+}
+
+// sendDirectMessage envía un mensaje privado a un usuario
+func sendDirectMessage(s *discordgo.Session, userID, content string) error {
+	channel, err := s.UserChannelCreate(userID)
+	if err != nil {
+		return fmt.Errorf("error abriendo canal privado: %w", err)
+	}
+
+	if _, err := s.ChannelMessageSend(channel.ID, content); err != nil {
+		return fmt.Errorf("error enviando mensaje privado: %w", err)
+	}
+
+	return nil
+}
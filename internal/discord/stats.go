@@ -0,0 +1,89 @@
+package discord
+
+import (
+	"discord-event-bot/internal/services/stats"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Cantidad de jugadores que muestra /stats sin usuario
+const statsLeaderboardSize = 10
+
+// handleStats muestra las estadísticas de un jugador o, sin usuario, la tabla
+// de los más confiables
+func handleStats(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var embed *discordgo.MessageEmbed
+
+	options := i.ApplicationCommandData().Options
+	if len(options) > 0 && options[0].Name == "user" {
+		user := options[0].UserValue(nil)
+		st, ok := stats.ForUser(i.GuildID, user.ID)
+		if !ok {
+			respondError(s, i, fmt.Sprintf("<@%s> no tiene inscripciones registradas", user.ID))
+			return
+		}
+		embed = buildUserStatsEmbed(st)
+	} else {
+		embed = buildLeaderboardEmbed(stats.Compute(i.GuildID))
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+func buildUserStatsEmbed(st stats.UserStats) *discordgo.MessageEmbed {
+	favourite := st.FavouriteRole
+	if favourite == "" {
+		favourite = "-"
+	}
+	if st.FavouriteClass != "" {
+		favourite += fmt.Sprintf(" (%s)", st.FavouriteClass)
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("📊 Estadísticas de %s", st.Username),
+		Description: fmt.Sprintf("<@%s>", st.UserID),
+		Color:       0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Confiabilidad", Value: reliabilityText(st), Inline: true},
+			{Name: "Inscripciones", Value: fmt.Sprintf("%d", st.Signups), Inline: true},
+			{Name: "Cancelaciones", Value: fmt.Sprintf("%d (%d tardías)", st.Cancellations, st.LateCancellations), Inline: true},
+			{Name: "Asistencia", Value: fmt.Sprintf("✅ %d · ⏰ %d · ❌ %d", st.Present, st.Late, st.NoShow), Inline: true},
+			{Name: "Rol favorito", Value: favourite, Inline: true},
+			{Name: "Llamados respondidos", Value: fmt.Sprintf("%d", st.LateFills), Inline: true},
+		},
+	}
+}
+
+func buildLeaderboardEmbed(all []stats.UserStats) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title: "🏆 Jugadores más confiables",
+		Color: 0x5865F2,
+	}
+	if len(all) == 0 {
+		embed.Description = "Todavía no hay inscripciones registradas"
+		return embed
+	}
+
+	var lines []string
+	for idx, st := range all[:min(len(all), statsLeaderboardSize)] {
+		lines = append(lines, fmt.Sprintf("**%d.** <@%s> — %s · %d inscripciones · %d cancelaciones tardías",
+			idx+1, st.UserID, reliabilityText(st), st.Signups, st.LateCancellations))
+	}
+	embed.Description = strings.Join(lines, "\n")
+	return embed
+}
+
+func reliabilityText(st stats.UserStats) string {
+	if st.Commitments == 0 {
+		return "Sin datos"
+	}
+	return fmt.Sprintf("%d%% de %d", st.Reliability, st.Commitments)
+}
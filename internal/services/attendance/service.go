@@ -0,0 +1,145 @@
+package attendance
+
+import (
+	"discord-event-bot/internal/storage"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Estados de asistencia de una inscripción confirmada
+const (
+	Present = "present"
+	Late    = "late"
+	NoShow  = "no_show"
+)
+
+// Statuses lista los estados en el orden en que se muestran
+var Statuses = []string{Present, Late, NoShow}
+
+// MarkInput representa la asistencia que un oficial registra para un usuario
+type MarkInput struct {
+	EventID    string
+	UserID     string
+	Attendance string
+	MarkedBy   string
+}
+
+// Entry es la asistencia de un usuario confirmado en un evento
+type Entry struct {
+	UserID     string   `json:"user_id"`
+	Username   string   `json:"username"`
+	Roles      []string `json:"roles"`
+	Attendance string   `json:"attendance,omitempty"`
+	MarkedBy   string   `json:"marked_by,omitempty"`
+}
+
+// Valid indica si un estado de asistencia es válido
+func Valid(attendance string) bool {
+	for _, s := range Statuses {
+		if s == attendance {
+			return true
+		}
+	}
+	return false
+}
+
+// Label devuelve el texto para mostrar de un estado de asistencia
+func Label(attendance string) string {
+	switch attendance {
+	case Present:
+		return "✅ Presente"
+	case Late:
+		return "⏰ Tarde"
+	case NoShow:
+		return "❌ No se presentó"
+	}
+	return "❔ Sin marcar"
+}
+
+// Mark registra la asistencia de un usuario. Solo se puede marcar una vez
+// que el evento empezó.
+func Mark(input MarkInput) (*storage.Event, error) {
+	if !Valid(input.Attendance) {
+		return nil, fmt.Errorf("Estado de asistencia inválido: %s", input.Attendance)
+	}
+
+	event, err := storage.Store.GetEvent(input.EventID)
+	if err != nil {
+		return nil, fmt.Errorf("Evento no encontrado")
+	}
+	if event.Status == "cancelled" {
+		return nil, fmt.Errorf("El evento fue cancelado")
+	}
+	if time.Now().Before(event.DateTime) {
+		return nil, fmt.Errorf("La asistencia se marca cuando empieza el evento")
+	}
+
+	if err := storage.Store.SetAttendance(input.EventID, input.UserID, input.Attendance, input.MarkedBy); err != nil {
+		return nil, fmt.Errorf("El usuario no tiene una inscripción confirmada")
+	}
+
+	return event, nil
+}
+
+// MarkPresent marca como presentes a los usuarios indicados que todavía no
+// tienen asistencia registrada. Se usa para completar desde el canal de voz.
+func MarkPresent(event *storage.Event, userIDs []string, markedBy string) int {
+	present := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		present[id] = true
+	}
+
+	marked := 0
+	for _, entry := range Entries(event) {
+		if entry.Attendance != "" || !present[entry.UserID] {
+			continue
+		}
+		if err := storage.Store.SetAttendance(event.ID, entry.UserID, Present, markedBy); err == nil {
+			marked++
+		}
+	}
+	return marked
+}
+
+// Entries devuelve la asistencia de cada usuario confirmado, uno por usuario
+// aunque esté inscrito en varios roles
+func Entries(event *storage.Event) []Entry {
+	byUser := make(map[string]*Entry)
+	for _, role := range event.Roles {
+		for _, signup := range event.Signups[role.Name] {
+			if signup.Status != "confirmed" {
+				continue
+			}
+			entry, ok := byUser[signup.UserID]
+			if !ok {
+				entry = &Entry{
+					UserID:     signup.UserID,
+					Username:   signup.Username,
+					Attendance: signup.Attendance,
+					MarkedBy:   signup.AttendanceBy,
+				}
+				byUser[signup.UserID] = entry
+			}
+			entry.Roles = append(entry.Roles, role.Name)
+		}
+	}
+
+	entries := make([]Entry, 0, len(byUser))
+	for _, entry := range byUser {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Username < entries[j].Username
+	})
+	return entries
+}
+
+// Summary cuenta cuántos usuarios hay en cada estado; la clave vacía son los sin marcar
+func Summary(event *storage.Event) map[string]int {
+	summary := make(map[string]int)
+	for _, entry := range Entries(event) {
+		summary[entry.Attendance]++
+	}
+	return summary
+}
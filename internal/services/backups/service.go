@@ -0,0 +1,276 @@
+package backups
+
+import (
+	"bytes"
+	"discord-event-bot/internal/storage"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	backupPrefix     = "backup-"
+	backupSuffix     = ".tar.gz"
+	backupTimeLayout = "20060102-150405.000000"
+	// Formato de los backups anteriores, con resolución de un segundo
+	legacyBackupTimeLayout = "20060102-150405"
+)
+
+// Config define dónde y cada cuánto se generan los backups
+type Config struct {
+	Dir       string
+	Schedule  string
+	Retention int
+}
+
+// BackupInfo describe un snapshot guardado en disco
+type BackupInfo struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var current Config
+
+// createMu serializa la elección del nombre y la escritura de los backups
+var createMu sync.Mutex
+
+// Start valida la configuración y lanza el ciclo de backups programados.
+// Un schedule vacío u "off" deja disponibles solo los backups manuales.
+func Start(cfg Config) error {
+	if cfg.Dir == "" {
+		return fmt.Errorf("el directorio de backups es obligatorio")
+	}
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return fmt.Errorf("error creando directorio de backups: %w", err)
+	}
+	current = cfg
+
+	interval, err := ParseSchedule(cfg.Schedule)
+	if err != nil {
+		return err
+	}
+	if interval == 0 {
+		log.Println("ℹ️ Backups programados deshabilitados")
+		return nil
+	}
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			if info, err := CreateBackup(); err != nil {
+				log.Printf("Error generando backup programado: %v", err)
+			} else {
+				log.Printf("💾 Backup generado: %s", info.Name)
+			}
+		}
+	}()
+
+	log.Printf("✅ Servicio de backups iniciado (cada %s, se conservan %d)", interval, cfg.Retention)
+	return nil
+}
+
+// ParseSchedule interpreta un intervalo estilo cron: @hourly, @daily, @weekly
+// o "@every <duración>" (por ejemplo "@every 6h"). Devuelve 0 si está deshabilitado.
+func ParseSchedule(spec string) (time.Duration, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "", "off", "none":
+		return 0, nil
+	case "@hourly":
+		return time.Hour, nil
+	case "@daily", "@midnight":
+		return 24 * time.Hour, nil
+	case "@weekly":
+		return 7 * 24 * time.Hour, nil
+	}
+
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return 0, fmt.Errorf("intervalo de backup inválido %q: %w", spec, err)
+		}
+		if interval < time.Minute {
+			return 0, fmt.Errorf("el intervalo de backup debe ser de al menos 1 minuto")
+		}
+		return interval, nil
+	}
+
+	return 0, fmt.Errorf("schedule de backup desconocido: %q (usa @hourly, @daily, @weekly o @every <duración>)", spec)
+}
+
+// CreateBackup genera un snapshot nuevo y aplica la política de retención
+func CreateBackup() (*BackupInfo, error) {
+	if current.Dir == "" {
+		return nil, fmt.Errorf("servicio de backups no inicializado")
+	}
+
+	createMu.Lock()
+	defer createMu.Unlock()
+
+	var buf bytes.Buffer
+	manifest, err := storage.WriteSnapshot(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	// Dos backups en el mismo instante no se pisan: el segundo lleva un contador
+	stamp := manifest.CreatedAt.Format(backupTimeLayout)
+	name := backupPrefix + stamp + backupSuffix
+	for n := 2; fileExists(filepath.Join(current.Dir, name)); n++ {
+		name = fmt.Sprintf("%s%s-%d%s", backupPrefix, stamp, n, backupSuffix)
+	}
+
+	// Temporal + fsync + rename para no dejar backups a medias tras un corte
+	if err := storage.WriteFileAtomic(filepath.Join(current.Dir, name), buf.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf("error guardando backup: %w", err)
+	}
+
+	if err := prune(); err != nil {
+		log.Printf("Error aplicando retención de backups: %v", err)
+	}
+
+	return &BackupInfo{Name: name, Size: int64(buf.Len()), CreatedAt: manifest.CreatedAt}, nil
+}
+
+// ListBackups devuelve los backups disponibles, del más reciente al más antiguo
+func ListBackups() ([]BackupInfo, error) {
+	if current.Dir == "" {
+		return nil, fmt.Errorf("servicio de backups no inicializado")
+	}
+
+	entries, err := os.ReadDir(current.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo directorio de backups: %w", err)
+	}
+
+	backups := make([]BackupInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !validName(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		createdAt, _, ok := parseBackupName(entry.Name())
+		if !ok {
+			createdAt = info.ModTime()
+		}
+		backups = append(backups, BackupInfo{Name: entry.Name(), Size: info.Size(), CreatedAt: createdAt})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].CreatedAt.Equal(backups[j].CreatedAt) {
+			return backups[i].CreatedAt.After(backups[j].CreatedAt)
+		}
+		_, ci, _ := parseBackupName(backups[i].Name)
+		_, cj, _ := parseBackupName(backups[j].Name)
+		return ci > cj
+	})
+
+	return backups, nil
+}
+
+// BackupPath devuelve la ruta de un backup validando que el nombre sea uno generado por el servicio
+func BackupPath(name string) (string, error) {
+	if current.Dir == "" {
+		return "", fmt.Errorf("servicio de backups no inicializado")
+	}
+	if !validName(name) {
+		return "", fmt.Errorf("nombre de backup inválido")
+	}
+
+	path := filepath.Join(current.Dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("backup no encontrado: %s", name)
+	}
+	return path, nil
+}
+
+// RestoreBackup restaura todos los datos del bot desde un backup. Antes de pisar
+// los datos actuales se guarda un backup de seguridad del estado vigente.
+func RestoreBackup(name string) (*storage.SnapshotManifest, error) {
+	path, err := BackupPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Leer primero: la retención del backup de seguridad podría borrarlo
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo backup: %w", err)
+	}
+
+	if safety, err := CreateBackup(); err != nil {
+		return nil, fmt.Errorf("error generando backup de seguridad: %w", err)
+	} else {
+		log.Printf("💾 Backup de seguridad antes de restaurar: %s", safety.Name)
+	}
+
+	manifest, err := storage.RestoreSnapshot(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("♻️ Restaurado backup %s (%d eventos, %d templates, %d tokens, %d perfiles, %d preferencias, %d servidores, %d calendarios)",
+		name, manifest.Events, manifest.Templates, manifest.Tokens, manifest.Profiles, manifest.Notifications, manifest.Guilds, manifest.Calendars)
+	return manifest, nil
+}
+
+// prune elimina los backups más antiguos que excedan la retención configurada
+func prune() error {
+	if current.Retention <= 0 {
+		return nil
+	}
+
+	backups, err := ListBackups()
+	if err != nil {
+		return err
+	}
+
+	for _, backup := range backups[min(current.Retention, len(backups)):] {
+		if err := os.Remove(filepath.Join(current.Dir, backup.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseBackupName lee la fecha y el contador (1 si no tiene) del nombre de
+// un backup, por ejemplo backup-20240101-120000.000000-2.tar.gz
+func parseBackupName(name string) (time.Time, int, bool) {
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupSuffix)
+	counter := 1
+	if i := strings.LastIndex(stamp, "-"); i > len("20060102") {
+		n, err := strconv.Atoi(stamp[i+1:])
+		if err != nil {
+			return time.Time{}, 0, false
+		}
+		stamp, counter = stamp[:i], n
+	}
+
+	for _, layout := range []string{backupTimeLayout, legacyBackupTimeLayout} {
+		if t, err := time.ParseInLocation(layout, stamp, time.Local); err == nil {
+			return t, counter, true
+		}
+	}
+	return time.Time{}, 0, false
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func validName(name string) bool {
+	return filepath.Base(name) == name &&
+		strings.HasPrefix(name, backupPrefix) &&
+		strings.HasSuffix(name, backupSuffix)
+}
@@ -0,0 +1,417 @@
+package calendar
+
+import (
+	"discord-event-bot/config"
+	"discord-event-bot/internal/services/guilds"
+	"discord-event-bot/internal/services/recurrence"
+	"discord-event-bot/internal/storage"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Los feeds incluyen los eventos de la última semana para que un evento no
+// desaparezca del calendario apenas termina
+const feedHistory = 7 * 24 * time.Hour
+
+// Duración de los eventos en el calendario, la misma que la del evento de Discord
+const eventDuration = 2 * time.Hour
+
+// Dominio de los UID de iCalendar
+const uidDomain = "discord-event-bot"
+
+const (
+	utcLayout   = "20060102T150405Z"
+	localLayout = "20060102T150405"
+)
+
+// FeedURLs son las direcciones de los calendarios de un jugador
+type FeedURLs struct {
+	Events   string // todos los eventos del servidor
+	Personal string // solo los eventos en los que está inscrito
+}
+
+// Feed devuelve el acceso del jugador a los calendarios del servidor,
+// creándolo la primera vez. Con reset se genera un token nuevo y las URLs
+// anteriores dejan de funcionar.
+func Feed(guildID, userID, username string, reset bool) (storage.CalendarFeed, error) {
+	guildID = guilds.Resolve(guildID)
+	if feed, ok := storage.Calendars.GetFeed(guildID, userID); ok && !reset {
+		return feed, nil
+	}
+
+	feed, err := storage.Calendars.ResetFeed(guildID, userID, username)
+	if err != nil {
+		return feed, fmt.Errorf("Error generando el calendario: %v", err)
+	}
+	return feed, nil
+}
+
+// URLs arma las direcciones de los feeds de un acceso
+func URLs(feed storage.CalendarFeed) FeedURLs {
+	base := config.AppConfig.PublicURL
+	if base == "" {
+		base = "http://localhost:" + config.AppConfig.Port
+	}
+	base += "/ical/" + url.PathEscape(feed.Token)
+
+	return FeedURLs{
+		Events:   base + "/events.ics",
+		Personal: base + "/mine.ics",
+	}
+}
+
+// TypeURL devuelve la dirección del feed de un tipo de evento
+func TypeURL(urls FeedURLs, eventType string) string {
+	return urls.Events + "?type=" + url.QueryEscape(eventType)
+}
+
+// GuildEvents devuelve los eventos próximos del servidor, opcionalmente
+// solo los de un tipo, ordenados por fecha
+func GuildEvents(guildID, eventType string, now time.Time) []*storage.Event {
+	var events []*storage.Event
+	for _, event := range guilds.FilterEvents(storage.Store.GetAllEvents(), guildID) {
+		if inFeed(event, eventType, now) {
+			events = append(events, event)
+		}
+	}
+	sortByDate(events)
+	return events
+}
+
+// UserEvents devuelve los eventos próximos del servidor en los que el
+// jugador está inscrito o en la banca
+func UserEvents(guildID, userID, eventType string, now time.Time) []*storage.Event {
+	var events []*storage.Event
+	for _, event := range guilds.FilterEvents(storage.Store.GetAllEvents(), guildID) {
+		if _, _, ok := userSignup(event, userID); ok && inFeed(event, eventType, now) {
+			events = append(events, event)
+		}
+	}
+	sortByDate(events)
+	return events
+}
+
+// Render genera el calendario iCalendar (RFC 5545) de los eventos. Con
+// userID es el calendario personal del jugador: cada evento indica su rol y
+// las series solo se repiten si mantienen a los inscritos.
+func Render(name string, events []*storage.Event, userID string, now time.Time) string {
+	var b strings.Builder
+	line := func(property, value string) {
+		b.WriteString(fold(property + ":" + value))
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//"+uidDomain+"//ES")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", escapeText(name))
+	if tz := timezoneID(); tz != "" {
+		line("X-WR-TIMEZONE", tz)
+		// Desde el año del evento más viejo del feed
+		for _, property := range timezone(tz, now.Add(-feedHistory).Year()) {
+			line(property[0], property[1])
+		}
+	}
+
+	for _, event := range events {
+		repeat := recurrence.IsRecurring(event) && event.Status != "completed"
+		var signup storage.Signup
+		var waitlisted bool
+		if userID != "" {
+			signup, waitlisted, _ = userSignup(event, userID)
+			repeat = repeat && event.CarryOverSignups
+		}
+
+		line("BEGIN", "VEVENT")
+		line("UID", uid(event, repeat))
+		line("DTSTAMP", now.UTC().Format(utcLayout))
+		line(dateProperty("DTSTART", event.DateTime))
+		line(dateProperty("DTEND", event.DateTime.Add(eventDuration)))
+		line("SUMMARY", escapeText(event.Name))
+		if event.Type != "" {
+			line("CATEGORIES", escapeText(event.Type))
+		}
+		line("DESCRIPTION", escapeText(description(event, userID, signup, waitlisted)))
+		if link := messageLink(event); link != "" {
+			line("URL", link)
+		}
+		line("STATUS", status(event, userID, signup, waitlisted))
+
+		if repeat {
+			rule := recurrence.RuleFor(event)
+			line("RRULE", recurrence.FormatFrom(rule, event.DateTime))
+			for _, exception := range exceptionDates(rule, event.DateTime) {
+				line(dateProperty("EXDATE", exception))
+			}
+		}
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return b.String()
+}
+
+func inFeed(event *storage.Event, eventType string, now time.Time) bool {
+	if eventType != "" && !strings.EqualFold(event.Type, eventType) {
+		return false
+	}
+	return event.DateTime.After(now.Add(-feedHistory))
+}
+
+func sortByDate(events []*storage.Event) {
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].DateTime.Before(events[j].DateTime)
+	})
+}
+
+// userSignup busca la inscripción del jugador (sin las rechazadas) o su lugar en la banca
+func userSignup(event *storage.Event, userID string) (storage.Signup, bool, bool) {
+	for _, role := range event.Roles {
+		for _, signup := range event.Signups[role.Name] {
+			if signup.UserID == userID && signup.Status != "declined" {
+				return signup, false, true
+			}
+		}
+	}
+	for _, role := range event.Roles {
+		for _, signup := range event.Waitlist[role.Name] {
+			if signup.UserID == userID {
+				return signup, true, true
+			}
+		}
+	}
+	return storage.Signup{}, false, false
+}
+
+// uid identifica al evento en el calendario. La ocurrencia vigente de una
+// serie usa el ID de la serie, así el calendario actualiza la misma serie
+// cuando se pasa a la siguiente ocurrencia.
+func uid(event *storage.Event, repeat bool) string {
+	if repeat {
+		seriesID := event.SeriesID
+		if seriesID == "" {
+			seriesID = event.ID
+		}
+		return "serie-" + seriesID + "@" + uidDomain
+	}
+	return event.ID + "@" + uidDomain
+}
+
+func status(event *storage.Event, userID string, signup storage.Signup, waitlisted bool) string {
+	switch {
+	case event.Status == "cancelled":
+		return "CANCELLED"
+	case userID != "" && (waitlisted || signup.Status == "pending"):
+		return "TENTATIVE"
+	}
+	return "CONFIRMED"
+}
+
+func description(event *storage.Event, userID string, signup storage.Signup, waitlisted bool) string {
+	var parts []string
+	if event.Description != "" {
+		parts = append(parts, event.Description)
+	}
+
+	if userID != "" {
+		role := signup.Role
+		if signup.Class != "" {
+			role += " - " + signup.Class
+		}
+		switch {
+		case waitlisted:
+			parts = append(parts, "En la banca como "+role)
+		case signup.Status == "pending":
+			parts = append(parts, "Inscrito como "+role+", pendiente de aprobación")
+		default:
+			parts = append(parts, "Inscrito como "+role)
+		}
+	} else {
+		parts = append(parts, signupSummary(event))
+	}
+
+	if event.Status == "cancelled" {
+		parts = append(parts, "❌ Evento cancelado")
+	}
+	if link := messageLink(event); link != "" {
+		parts = append(parts, link)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// signupSummary resume los lugares ocupados de cada rol, ej: "Tank 1/2, DPS 3/6"
+func signupSummary(event *storage.Event) string {
+	roles := make([]string, 0, len(event.Roles))
+	for _, role := range event.Roles {
+		taken := 0
+		for _, signup := range event.Signups[role.Name] {
+			if signup.Status != "declined" {
+				taken++
+			}
+		}
+		if role.Limit > 0 {
+			roles = append(roles, fmt.Sprintf("%s %d/%d", role.Name, taken, role.Limit))
+		} else {
+			roles = append(roles, fmt.Sprintf("%s %d", role.Name, taken))
+		}
+	}
+	return "Inscritos: " + strings.Join(roles, ", ")
+}
+
+func messageLink(event *storage.Event) string {
+	if event.MessageID == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guilds.EventGuild(event), event.Channel, event.MessageID)
+}
+
+// exceptionDates devuelve las fechas salteadas de la serie desde la ocurrencia
+// vigente, a la misma hora que esta
+func exceptionDates(rule *storage.Recurrence, from time.Time) []time.Time {
+	loc := recurrence.Location()
+	from = from.In(loc)
+
+	var dates []time.Time
+	for _, exception := range rule.Exceptions {
+		day, err := time.ParseInLocation("2006-01-02", exception, loc)
+		if err != nil {
+			continue
+		}
+		date := time.Date(day.Year(), day.Month(), day.Day(), from.Hour(), from.Minute(), 0, 0, loc)
+		if date.After(from) {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
+
+// dateProperty arma una fecha en la zona horaria configurada, para que las
+// repeticiones respeten el horario de verano; sin zona conocida usa UTC
+func dateProperty(property string, t time.Time) (string, string) {
+	if tz := timezoneID(); tz != "" {
+		return property + ";TZID=" + tz, t.In(recurrence.Location()).Format(localLayout)
+	}
+	return property, t.UTC().Format(utcLayout)
+}
+
+// timezone arma el VTIMEZONE de la zona configurada, que exigen las fechas
+// con TZID. Los cambios de horario del año se repiten cada año con una regla
+// "n-ésimo día de la semana del mes", que es como los definen casi todas las zonas.
+// Antes de los cambios va el horario de principio de año desde 1970, así las
+// fechas anteriores al primer cambio (o una zona sin cambios) tienen desfase.
+func timezone(tz string, year int) [][2]string {
+	loc := recurrence.Location()
+	properties := [][2]string{{"BEGIN", "VTIMEZONE"}, {"TZID", tz}}
+
+	name, offset := time.Date(year, time.January, 1, 0, 0, 0, 0, loc).Zone()
+	properties = append(properties,
+		[2]string{"BEGIN", "STANDARD"},
+		[2]string{"DTSTART", "19700101T000000"},
+		[2]string{"TZOFFSETFROM", formatOffset(offset)},
+		[2]string{"TZOFFSETTO", formatOffset(offset)},
+		[2]string{"TZNAME", name},
+		[2]string{"END", "STANDARD"})
+
+	for _, at := range zoneTransitions(loc, year) {
+		_, from := at.Add(-time.Second).In(loc).Zone()
+		name, to := at.In(loc).Zone()
+		component := "STANDARD"
+		if at.In(loc).IsDST() {
+			component = "DAYLIGHT"
+		}
+
+		// DTSTART va en la hora local de antes del cambio
+		wall := at.UTC().Add(time.Duration(from) * time.Second)
+		properties = append(properties,
+			[2]string{"BEGIN", component},
+			[2]string{"DTSTART", wall.Format(localLayout)},
+			[2]string{"TZOFFSETFROM", formatOffset(from)},
+			[2]string{"TZOFFSETTO", formatOffset(to)},
+			[2]string{"TZNAME", name},
+			[2]string{"RRULE", yearlyRule(wall)},
+			[2]string{"END", component})
+	}
+
+	return append(properties, [2]string{"END", "VTIMEZONE"})
+}
+
+// zoneTransitions devuelve los instantes del año en que cambia el desfase de la zona
+func zoneTransitions(loc *time.Location, year int) []time.Time {
+	var transitions []time.Time
+	for day := time.Date(year, time.January, 1, 0, 0, 0, 0, loc); day.Year() == year; day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+		_, before := day.Zone()
+		if _, after := next.Zone(); after == before {
+			continue
+		}
+
+		// Buscar el segundo exacto del cambio dentro del día
+		low, high := day.Unix(), next.Unix()
+		for high-low > 1 {
+			mid := low + (high-low)/2
+			if _, offset := time.Unix(mid, 0).In(loc).Zone(); offset == before {
+				low = mid
+			} else {
+				high = mid
+			}
+		}
+		transitions = append(transitions, time.Unix(high, 0))
+	}
+	return transitions
+}
+
+// yearlyRule arma la regla anual del cambio de horario, ej: el último domingo de marzo
+func yearlyRule(wall time.Time) string {
+	week := (wall.Day()-1)/7 + 1
+	if wall.AddDate(0, 0, 7).Month() != wall.Month() {
+		week = -1
+	}
+	weekday := strings.ToUpper(wall.Weekday().String()[:2])
+	return fmt.Sprintf("FREQ=YEARLY;BYMONTH=%d;BYDAY=%d%s", int(wall.Month()), week, weekday)
+}
+
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds/60%60)
+}
+
+func timezoneID() string {
+	if tz := recurrence.Location().String(); tz != "Local" && tz != "UTC" {
+		return tz
+	}
+	return ""
+}
+
+// escapeText escapa un valor de texto según RFC 5545
+func escapeText(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(text)
+}
+
+// fold corta la línea en tramos de 75 bytes sin partir caracteres UTF-8;
+// las continuaciones empiezan con un espacio
+func fold(line string) string {
+	const limit = 75
+
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+	return b.String()
+}
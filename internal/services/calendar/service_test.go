@@ -0,0 +1,161 @@
+package calendar
+
+import (
+	"discord-event-bot/config"
+	"discord-event-bot/internal/storage"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "sin caracteres especiales", text: "Raid semanal", want: "Raid semanal"},
+		{name: "coma y punto y coma", text: "Tank, DPS; Healer", want: `Tank\, DPS\; Healer`},
+		{name: "barra invertida primero", text: `C:\raid;1`, want: `C:\\raid\;1`},
+		{name: "saltos de línea", text: "línea 1\nlínea 2\r\nlínea 3", want: `línea 1\nlínea 2\nlínea 3`},
+		{name: "dos puntos sin escapar", text: "Hora: 21:00", want: "Hora: 21:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapeText(tt.text); got != tt.want {
+				t.Errorf("escapeText(%q) = %q, se esperaba %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		lines int
+	}{
+		{name: "línea corta", line: "SUMMARY:Raid", lines: 1},
+		{name: "justo 75 bytes", line: "SUMMARY:" + strings.Repeat("a", 67), lines: 1},
+		{name: "76 bytes", line: "SUMMARY:" + strings.Repeat("a", 68), lines: 2},
+		{name: "texto largo", line: "DESCRIPTION:" + strings.Repeat("b", 300), lines: 5},
+		{name: "no parte caracteres UTF-8", line: "SUMMARY:" + strings.Repeat("ñ", 80), lines: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folded := fold(tt.line)
+			if !strings.HasSuffix(folded, "\r\n") {
+				t.Fatalf("la línea no termina en CRLF: %q", folded)
+			}
+
+			parts := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n")
+			if len(parts) != tt.lines {
+				t.Errorf("%d líneas, se esperaban %d", len(parts), tt.lines)
+			}
+
+			var unfolded strings.Builder
+			for i, part := range parts {
+				if len(part) > 75 {
+					t.Errorf("línea %d de %d bytes", i, len(part))
+				}
+				if !utf8.ValidString(part) {
+					t.Errorf("línea %d con UTF-8 partido: %q", i, part)
+				}
+				if i > 0 {
+					if !strings.HasPrefix(part, " ") {
+						t.Errorf("la continuación %d no empieza con espacio", i)
+					}
+					part = part[1:]
+				}
+				unfolded.WriteString(part)
+			}
+			if unfolded.String() != tt.line {
+				t.Errorf("al desplegar se obtiene %q, se esperaba %q", unfolded.String(), tt.line)
+			}
+		})
+	}
+}
+
+func TestTimezone(t *testing.T) {
+	tests := []struct {
+		tz   string
+		want []string
+	}{
+		{
+			tz: "Europe/Madrid",
+			want: []string{
+				"BEGIN:STANDARD\nDTSTART:19700101T000000\nTZOFFSETFROM:+0100\nTZOFFSETTO:+0100",
+				"BEGIN:DAYLIGHT", "DTSTART:20260329T020000", "TZOFFSETFROM:+0100", "TZOFFSETTO:+0200", "RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU",
+				"BEGIN:STANDARD", "DTSTART:20261025T030000", "TZOFFSETFROM:+0200", "TZOFFSETTO:+0100", "RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU",
+			},
+		},
+		{
+			tz:   "America/New_York",
+			want: []string{"DTSTART:19700101T000000\nTZOFFSETFROM:-0500", "RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU", "RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU", "TZOFFSETTO:-0400"},
+		},
+		{
+			tz:   "America/Argentina/Buenos_Aires",
+			want: []string{"BEGIN:STANDARD\nDTSTART:19700101T000000\nTZOFFSETFROM:-0300\nTZOFFSETTO:-0300"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tz, func(t *testing.T) {
+			useTimezone(t, tt.tz)
+
+			var lines []string
+			for _, property := range timezone(tt.tz, 2026) {
+				lines = append(lines, property[0]+":"+property[1])
+			}
+			ics := strings.Join(lines, "\n")
+
+			if !strings.HasPrefix(ics, "BEGIN:VTIMEZONE\nTZID:"+tt.tz+"\n") || !strings.HasSuffix(ics, "END:VTIMEZONE") {
+				t.Fatalf("VTIMEZONE mal armado:\n%s", ics)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(ics, want) {
+					t.Errorf("falta %q en:\n%s", want, ics)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderDeclaresTimezone(t *testing.T) {
+	useTimezone(t, "Europe/Madrid")
+	loc, _ := time.LoadLocation("Europe/Madrid")
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, loc)
+
+	event := &storage.Event{
+		ID:          "e1",
+		Name:        "Raid; semanal, con notas",
+		Description: "Traer pociones\nY comida",
+		DateTime:    time.Date(2026, time.March, 10, 21, 0, 0, 0, loc),
+		Status:      "active",
+	}
+	ics := Render("Eventos", []*storage.Event{event}, "", now)
+
+	for _, want := range []string{
+		"BEGIN:VTIMEZONE\r\nTZID:Europe/Madrid\r\n",
+		"DTSTART;TZID=Europe/Madrid:20260310T210000\r\n",
+		`SUMMARY:Raid\; semanal\, con notas` + "\r\n",
+		`DESCRIPTION:Traer pociones\nY comida`,
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("falta %q en:\n%s", want, ics)
+		}
+	}
+	if strings.Index(ics, "BEGIN:VTIMEZONE") > strings.Index(ics, "BEGIN:VEVENT") {
+		t.Error("el VTIMEZONE tiene que ir antes de los eventos")
+	}
+}
+
+func useTimezone(t *testing.T, tz string) {
+	t.Helper()
+	previous := config.AppConfig
+	config.AppConfig = &config.Config{Timezone: tz}
+	t.Cleanup(func() { config.AppConfig = previous })
+}
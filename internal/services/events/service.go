@@ -1,9 +1,14 @@
 package events
 
 import (
-	"discord-event-bot/config"
+	"discord-event-bot/internal/services/guilds"
+	"discord-event-bot/internal/services/recurrence"
+	remindersvc "discord-event-bot/internal/services/reminders"
+	signupsvc "discord-event-bot/internal/services/signups"
 	"discord-event-bot/internal/storage"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,6 +17,7 @@ import (
 // CreateEventInput contiene los datos necesarios para crear un evento
 // independientemente de si viene de la web, Discord, etc.
 type CreateEventInput struct {
+	GuildID                 string // vacío = el servidor principal
 	Name                    string
 	Type                    string
 	Description             string
@@ -25,6 +31,12 @@ type CreateEventInput struct {
 	ReminderOffsetMinutes   int
 	DeleteAfterHours        int
 	AnnouncementOffsetHours int
+	RequireApproval         bool
+	Recurrence              *storage.Recurrence
+	CarryOverSignups        bool
+	Requirements            *storage.SignupRequirements // nil = los del template
+	ReminderOffsets         []int                       // etapas en minutos; vacío = las del template
+	FillIn                  *storage.FillInSettings     // nil = el del template o el global
 }
 
 // CreateEvent aplica las reglas de negocio para crear un evento MMO
@@ -39,6 +51,24 @@ func CreateEvent(input CreateEventInput) (*storage.Event, error) {
 	if input.ChannelID == "" {
 		return nil, fmt.Errorf("el canal es obligatorio")
 	}
+	if !guilds.Known(input.GuildID) {
+		return nil, fmt.Errorf("el bot no está en el servidor %s", input.GuildID)
+	}
+
+	requirements, err := NormalizeRequirements(input.Requirements)
+	if err != nil {
+		return nil, err
+	}
+
+	reminderOffsets, err := remindersvc.NormalizeOffsets(input.ReminderOffsets)
+	if err != nil {
+		return nil, err
+	}
+
+	fillIn, err := NormalizeFillIn(input.FillIn)
+	if err != nil {
+		return nil, err
+	}
 
 	announceHours := input.AnnounceHours
 	if announceHours < 0 {
@@ -47,6 +77,7 @@ func CreateEvent(input CreateEventInput) (*storage.Event, error) {
 
 	event := &storage.Event{
 		ID:                      uuid.New().String(),
+		GuildID:                 input.GuildID,
 		Name:                    input.Name,
 		Type:                    input.Type,
 		Description:             input.Description,
@@ -56,12 +87,31 @@ func CreateEvent(input CreateEventInput) (*storage.Event, error) {
 		CreatedAt:               time.Now(),
 		CreatedBy:               input.CreatedBy,
 		AllowMultiSignup:        false,
+		RequireApproval:         input.RequireApproval,
 		Signups:                 make(map[string][]storage.Signup),
 		RepeatEveryDays:         input.RepeatEveryDays,
 		CreateDiscordEvent:      input.CreateDiscordEvent,
 		ReminderOffsetMinutes:   input.ReminderOffsetMinutes,
 		DeleteAfterHours:        input.DeleteAfterHours,
 		AnnouncementOffsetHours: 0,
+		CarryOverSignups:        input.CarryOverSignups,
+		Requirements:            requirements,
+		ReminderOffsets:         reminderOffsets,
+		FillIn:                  fillIn,
+	}
+
+	// Con una regla de repetición la primera fecha es la primera ocurrencia
+	// real (por ejemplo el próximo martes si la regla es "martes y jueves")
+	if input.Recurrence != nil {
+		input.Recurrence.Start = input.DateTime
+		first, ok := recurrence.First(input.Recurrence)
+		if !ok {
+			return nil, fmt.Errorf("la regla de repetición no genera ninguna fecha")
+		}
+		input.Recurrence.Start = first
+		event.DateTime = first
+		event.Recurrence = input.Recurrence
+		event.RepeatEveryDays = 0
 	}
 
 	if announceHours > 0 {
@@ -71,20 +121,19 @@ func CreateEvent(input CreateEventInput) (*storage.Event, error) {
 
 	// Si se especificó un template, delegar en el store
 	if input.TemplateName != "" {
-		var err error
-		event, err = storage.Store.CreateEventFromTemplate(input.TemplateName, event)
+		// Los templates de otro servidor no se pueden usar
+		template, err := guilds.FindTemplate(input.GuildID, input.TemplateName)
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		// Roles por defecto
-		for _, role := range config.AppConfig.DefaultRoles {
-			event.Roles = append(event.Roles, storage.RoleSignup{
-				Name:  role.Name,
-				Emoji: role.Emoji,
-				Limit: role.Limit,
-			})
+
+		event, err = storage.Store.CreateEventFromTemplate(template, event)
+		if err != nil {
+			return nil, err
 		}
+	} else {
+		// Roles por defecto del servidor
+		event.Roles = guilds.DefaultRoles(input.GuildID)
 
 		if err := storage.Store.SaveEvent(event); err != nil {
 			return nil, err
@@ -93,3 +142,508 @@ func CreateEvent(input CreateEventInput) (*storage.Event, error) {
 
 	return event, nil
 }
+
+// EditEventInput contiene los campos editables de un evento.
+// Los campos nil se dejan como están.
+type EditEventInput struct {
+	Name                  *string
+	Type                  *string
+	Description           *string
+	DateTime              *time.Time
+	ChannelID             *string
+	Roles                 []storage.RoleSignup // nil = no cambian
+	AllowMultiSignup      *bool
+	RequireApproval       *bool
+	ReminderOffsetMinutes *int
+	DeleteAfterHours      *int
+	Requirements          *storage.SignupRequirements // vacío = sin requisitos
+	ReminderOffsets       []int                       // nil = no cambia, vacío = una sola etapa
+	FillIn                *storage.FillInSettings     // vacío = el global
+}
+
+// EditResult describe cómo quedó un evento editado para que Discord pueda
+// sincronizar el mensaje, el evento programado y avisar a los inscritos
+type EditResult struct {
+	Event *storage.Event
+
+	// Changes son los datos visibles para los jugadores que cambiaron
+	// ("nombre", "fecha", "canal", ...), en el orden del formulario
+	Changes      []string
+	DateChanged  bool
+	PreviousDate time.Time
+
+	// Si el evento cambió de canal, el mensaje y el hilo anteriores hay que
+	// borrarlos y volver a publicar el evento en el canal nuevo
+	PreviousChannelID string
+	PreviousMessageID string
+	PreviousThreadID  string
+
+	// Promoted son los jugadores que salieron de la banca porque un rol
+	// tiene ahora más lugares
+	Promoted []storage.Signup
+}
+
+// Changed indica si cambió alguno de los datos indicados
+func (r *EditResult) Changed(fields ...string) bool {
+	for _, change := range r.Changes {
+		for _, field := range fields {
+			if change == field {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ChannelMoved indica si el evento se movió a otro canal
+func (r *EditResult) ChannelMoved() bool {
+	return r.PreviousChannelID != ""
+}
+
+// EditEvent aplica cambios parciales sobre un evento activo. Primero se
+// valida todo y recién después se modifica el evento, así un error no deja
+// cambios a medias.
+func EditEvent(eventID string, input EditEventInput) (*EditResult, error) {
+	event, err := storage.Store.GetEvent(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("evento no encontrado")
+	}
+	if event.Status != "active" {
+		return nil, fmt.Errorf("solo se pueden editar eventos activos")
+	}
+
+	if input.Name != nil {
+		trimmed := strings.TrimSpace(*input.Name)
+		if trimmed == "" {
+			return nil, fmt.Errorf("el nombre del evento es obligatorio")
+		}
+		input.Name = &trimmed
+	}
+	if input.Type != nil {
+		trimmed := strings.TrimSpace(*input.Type)
+		if trimmed == "" {
+			return nil, fmt.Errorf("el tipo de evento es obligatorio")
+		}
+		input.Type = &trimmed
+	}
+	if input.ChannelID != nil {
+		channelID := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(*input.ChannelID), "<#"), ">")
+		if channelID == "" || strings.Trim(channelID, "0123456789") != "" {
+			return nil, fmt.Errorf("canal inválido: %s (usa el ID numérico del canal)", *input.ChannelID)
+		}
+		input.ChannelID = &channelID
+	}
+	if input.ReminderOffsetMinutes != nil && *input.ReminderOffsetMinutes < 0 {
+		return nil, fmt.Errorf("el recordatorio no puede ser negativo")
+	}
+	if input.DeleteAfterHours != nil && *input.DeleteAfterHours < 0 {
+		return nil, fmt.Errorf("el borrado automático no puede ser negativo")
+	}
+
+	var roles []storage.RoleSignup
+	if input.Roles != nil {
+		if roles, err = normalizeEditedRoles(event, input.Roles); err != nil {
+			return nil, err
+		}
+	}
+
+	var offsets []int
+	if input.ReminderOffsets != nil {
+		if offsets, err = remindersvc.NormalizeOffsets(input.ReminderOffsets); err != nil {
+			return nil, err
+		}
+	}
+	var requirements *storage.SignupRequirements
+	if input.Requirements != nil {
+		if requirements, err = NormalizeRequirements(input.Requirements); err != nil {
+			return nil, err
+		}
+	}
+	var fillIn *storage.FillInSettings
+	if input.FillIn != nil {
+		if fillIn, err = NormalizeFillIn(input.FillIn); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &EditResult{Event: event}
+
+	if input.Name != nil && *input.Name != event.Name {
+		event.Name = *input.Name
+		result.Changes = append(result.Changes, "nombre")
+	}
+	if input.Type != nil && *input.Type != event.Type {
+		event.Type = *input.Type
+		result.Changes = append(result.Changes, "tipo")
+	}
+	if input.Description != nil && *input.Description != event.Description {
+		event.Description = *input.Description
+		result.Changes = append(result.Changes, "descripción")
+	}
+	if input.DateTime != nil && !input.DateTime.Equal(event.DateTime) {
+		result.DateChanged = true
+		result.PreviousDate = event.DateTime
+		result.Changes = append(result.Changes, "fecha")
+
+		event.DateTime = *input.DateTime
+		// En una serie la regla pasa a contar desde la nueva fecha; si no, la
+		// próxima ocurrencia volvería al horario anterior
+		if event.Recurrence != nil {
+			event.Recurrence = recurrence.Rebase(event.Recurrence, event.DateTime)
+		}
+		// Con la nueva fecha el recordatorio y el anuncio vuelven a programarse
+		event.ReminderSent = false
+		event.RemindersSent = nil
+		event.DMRemindersSent = nil
+		event.FillInSent = false
+		event.FillInRoles = nil
+		event.AttendancePanelSent = false
+		// Un evento de Discord ya iniciado vuelve a quedar programado: la
+		// sincronización de inicio y fin tiene que correr para la nueva fecha
+		if event.DiscordEventStatus == "active" {
+			event.DiscordEventStatus = ""
+		}
+		if event.AnnouncementOffsetHours > 0 {
+			event.AnnouncementTime = event.DateTime.Add(-time.Duration(event.AnnouncementOffsetHours) * time.Hour)
+		}
+	}
+	if input.ChannelID != nil && *input.ChannelID != event.Channel {
+		result.PreviousChannelID = event.Channel
+		result.PreviousMessageID = event.MessageID
+		result.PreviousThreadID = event.ThreadID
+		result.Changes = append(result.Changes, "canal")
+
+		// El mensaje se vuelve a publicar en el canal nuevo
+		event.Channel = *input.ChannelID
+		event.MessageID = ""
+		event.ThreadID = ""
+	}
+	if input.Roles != nil && !sameRoles(event.Roles, roles) {
+		event.Roles = roles
+		result.Changes = append(result.Changes, "roles")
+	}
+
+	if input.AllowMultiSignup != nil {
+		event.AllowMultiSignup = *input.AllowMultiSignup
+	}
+	if input.RequireApproval != nil {
+		event.RequireApproval = *input.RequireApproval
+	}
+	if input.ReminderOffsetMinutes != nil {
+		event.ReminderOffsetMinutes = *input.ReminderOffsetMinutes
+	}
+	if input.DeleteAfterHours != nil {
+		event.DeleteAfterHours = *input.DeleteAfterHours
+	}
+	if input.ReminderOffsets != nil {
+		event.ReminderOffsets = offsets
+	}
+	if input.Requirements != nil {
+		event.Requirements = requirements
+	}
+	if input.FillIn != nil {
+		event.FillIn = fillIn
+	}
+
+	if err := storage.Store.SaveEvent(event); err != nil {
+		return nil, err
+	}
+
+	// Si algún rol ganó lugares, entran los primeros de la banca
+	if result.Changed("roles") {
+		result.Promoted = signupsvc.FillOpenSlots(event)
+	}
+
+	return result, nil
+}
+
+// normalizeEditedRoles valida los roles nuevos de un evento. No se puede
+// quitar un rol con inscritos ni bajar su límite o el de una de sus clases
+// por debajo de los lugares ocupados. Los roles que siguen conservan sus
+// clases si no se indicaron otras.
+func normalizeEditedRoles(event *storage.Event, roles []storage.RoleSignup) ([]storage.RoleSignup, error) {
+	if len(roles) == 0 {
+		return nil, fmt.Errorf("el evento necesita al menos un rol")
+	}
+
+	current := make(map[string]storage.RoleSignup, len(event.Roles))
+	for _, role := range event.Roles {
+		current[role.Name] = role
+	}
+
+	normalized := make([]storage.RoleSignup, 0, len(roles))
+	seen := make(map[string]bool, len(roles))
+	kept := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		role.Name = strings.TrimSpace(role.Name)
+		role.Emoji = strings.TrimSpace(role.Emoji)
+		if role.Name == "" {
+			return nil, fmt.Errorf("los roles necesitan nombre")
+		}
+		if seen[strings.ToLower(role.Name)] {
+			return nil, fmt.Errorf("el rol %s está repetido", role.Name)
+		}
+		seen[strings.ToLower(role.Name)] = true
+		kept[role.Name] = true
+		if role.Limit < 0 {
+			return nil, fmt.Errorf("el límite del rol %s no puede ser negativo", role.Name)
+		}
+
+		if previous, ok := current[role.Name]; ok && len(role.Classes) == 0 {
+			role.Classes = previous.Classes
+		}
+		if occupied := signupsvc.OccupiedSlots(event, role.Name); role.Limit > 0 && occupied > role.Limit {
+			return nil, fmt.Errorf("el rol %s tiene %d inscritos, no se puede bajar el límite a %d", role.Name, occupied, role.Limit)
+		}
+		for _, class := range role.Classes {
+			if class.Limit < 0 {
+				return nil, fmt.Errorf("el límite de la clase %s no puede ser negativo", class.Name)
+			}
+			if role.Limit > 0 && class.Limit > role.Limit {
+				return nil, fmt.Errorf("el límite de la clase %s (%d) excede el límite del rol %s (%d)", class.Name, class.Limit, role.Name, role.Limit)
+			}
+			if occupied := signupsvc.OccupiedClassSlots(event, role.Name, class.Name); class.Limit > 0 && occupied > class.Limit {
+				return nil, fmt.Errorf("la clase %s tiene %d inscritos, no se puede bajar el límite a %d", class.Name, occupied, class.Limit)
+			}
+		}
+		normalized = append(normalized, role)
+	}
+
+	// Las inscripciones se guardan por nombre de rol: renombrar un rol con
+	// inscritos también cuenta como quitarlo
+	for _, role := range event.Roles {
+		if kept[role.Name] {
+			continue
+		}
+		if signupsvc.OccupiedSlots(event, role.Name) > 0 || len(event.Waitlist[role.Name]) > 0 {
+			return nil, fmt.Errorf("el rol %s tiene inscritos, no se puede quitar", role.Name)
+		}
+	}
+
+	return normalized, nil
+}
+
+// sameRoles indica si dos listas de roles son iguales en nombre, emoji,
+// límite y clases
+func sameRoles(a, b []storage.RoleSignup) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || a[i].Emoji != b[i].Emoji || a[i].Limit != b[i].Limit {
+			return false
+		}
+		if len(a[i].Classes) != len(b[i].Classes) {
+			return false
+		}
+		for j := range a[i].Classes {
+			if a[i].Classes[j] != b[i].Classes[j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ParseRoles lee roles escritos como "Nombre:emoji:límite" separados por
+// coma, por ejemplo "Tank:🛡️:2, DPS:⚔️:6". El emoji y el límite son
+// opcionales; sin límite el rol no tiene tope.
+func ParseRoles(text string) ([]storage.RoleSignup, error) {
+	var roles []storage.RoleSignup
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fields := strings.Split(part, ":")
+		if len(fields) > 3 {
+			return nil, fmt.Errorf("rol inválido: %s (usa Nombre:emoji:límite)", part)
+		}
+		role := storage.RoleSignup{Name: strings.TrimSpace(fields[0])}
+		if len(fields) > 1 {
+			role.Emoji = strings.TrimSpace(fields[1])
+		}
+		if len(fields) > 2 && strings.TrimSpace(fields[2]) != "" {
+			limit, err := strconv.Atoi(strings.TrimSpace(fields[2]))
+			if err != nil || limit < 0 {
+				return nil, fmt.Errorf("límite inválido en el rol %s: %s", role.Name, fields[2])
+			}
+			role.Limit = limit
+		}
+		roles = append(roles, role)
+	}
+	if len(roles) == 0 {
+		return nil, fmt.Errorf("no se indicó ningún rol")
+	}
+	return roles, nil
+}
+
+// FormatRoles escribe los roles en el formato que acepta ParseRoles
+func FormatRoles(roles []storage.RoleSignup) string {
+	parts := make([]string, 0, len(roles))
+	for _, role := range roles {
+		parts = append(parts, fmt.Sprintf("%s:%s:%d", role.Name, role.Emoji, role.Limit))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// NormalizeRequirements limpia y valida los requisitos de inscripción.
+// Devuelve nil si no queda ningún requisito.
+func NormalizeRequirements(req *storage.SignupRequirements) (*storage.SignupRequirements, error) {
+	if req == nil {
+		return nil, nil
+	}
+	if req.MinItemLevel < 0 {
+		return nil, fmt.Errorf("el item level mínimo no puede ser negativo")
+	}
+
+	normalized := &storage.SignupRequirements{MinItemLevel: req.MinItemLevel}
+	var err error
+	if normalized.RequiredRoles, err = normalizeRoleIDs(req.RequiredRoles); err != nil {
+		return nil, err
+	}
+	if normalized.ForbiddenRoles, err = normalizeRoleIDs(req.ForbiddenRoles); err != nil {
+		return nil, err
+	}
+
+	if normalized.IsEmpty() {
+		return nil, nil
+	}
+	return normalized, nil
+}
+
+// NormalizeFillIn limpia y valida la configuración del llamado a completar
+// roles. Los roles de Discord se aceptan como ID o mención (<@&id>).
+func NormalizeFillIn(fillIn *storage.FillInSettings) (*storage.FillInSettings, error) {
+	if fillIn == nil {
+		return nil, nil
+	}
+	if fillIn.LeadMinutes < 0 {
+		return nil, fmt.Errorf("la anticipación del llamado a completar no puede ser negativa")
+	}
+
+	normalized := &storage.FillInSettings{LeadMinutes: fillIn.LeadMinutes}
+	for role, discordRole := range fillIn.RolePings {
+		role = strings.TrimSpace(role)
+		ids, err := normalizeRoleIDs([]string{discordRole})
+		if err != nil {
+			return nil, err
+		}
+		if role == "" || len(ids) == 0 {
+			continue
+		}
+		if normalized.RolePings == nil {
+			normalized.RolePings = make(map[string]string)
+		}
+		normalized.RolePings[role] = ids[0]
+	}
+
+	if normalized.IsEmpty() {
+		return nil, nil
+	}
+	return normalized, nil
+}
+
+// normalizeRoleIDs acepta IDs de roles o menciones (<@&id>) y descarta vacíos
+func normalizeRoleIDs(roles []string) ([]string, error) {
+	var ids []string
+	for _, role := range roles {
+		role = strings.TrimSpace(role)
+		role = strings.TrimSuffix(strings.TrimPrefix(role, "<@&"), ">")
+		if role == "" {
+			continue
+		}
+		if strings.Trim(role, "0123456789") != "" {
+			return nil, fmt.Errorf("rol de Discord inválido: %s (usa el ID numérico del rol)", role)
+		}
+		ids = append(ids, role)
+	}
+	return ids, nil
+}
+
+// ImportEventInput son los datos de un evento creado desde Discord
+type ImportEventInput struct {
+	GuildID        string
+	DiscordEventID string
+	Name           string
+	Description    string
+	DateTime       time.Time
+	CreatedBy      string
+}
+
+// ImportedEventType es el tipo de los eventos importados desde Discord, que
+// no tienen tipo propio
+const ImportedEventType = "Discord"
+
+// ImportEvent crea el evento del bot que acompaña a un evento creado desde
+// Discord, con los roles por defecto del servidor, en el canal configurado
+// para los eventos importados
+func ImportEvent(input ImportEventInput) (*storage.Event, error) {
+	if input.DiscordEventID == "" {
+		return nil, fmt.Errorf("falta el ID del evento de Discord")
+	}
+	if _, ok := FindByDiscordEventID(input.DiscordEventID); ok {
+		return nil, fmt.Errorf("el evento de Discord %s ya está importado", input.DiscordEventID)
+	}
+
+	channelID := guilds.ImportEventsChannel(input.GuildID)
+	if channelID == "" {
+		return nil, fmt.Errorf("no hay canal para los eventos importados (IMPORT_EVENTS_CHANNEL)")
+	}
+
+	event, err := CreateEvent(CreateEventInput{
+		GuildID:     input.GuildID,
+		Name:        input.Name,
+		Type:        ImportedEventType,
+		Description: input.Description,
+		DateTime:    input.DateTime,
+		ChannelID:   channelID,
+		CreatedBy:   input.CreatedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	event.DiscordEventID = input.DiscordEventID
+	event.Imported = true
+	if err := storage.Store.SaveEvent(event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// FindByDiscordEventID busca el evento vinculado a un evento de Discord,
+// prefiriendo el activo si hay varios
+func FindByDiscordEventID(discordEventID string) (*storage.Event, bool) {
+	if discordEventID == "" {
+		return nil, false
+	}
+
+	var found *storage.Event
+	for _, event := range storage.Store.GetAllEvents() {
+		if event.DiscordEventID != discordEventID {
+			continue
+		}
+		if event.Status == "active" {
+			return event, true
+		}
+		found = event
+	}
+	return found, found != nil
+}
+
+// CancelEvent marca un evento como cancelado
+func CancelEvent(eventID string) (*storage.Event, error) {
+	event, err := storage.Store.GetEvent(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("evento no encontrado")
+	}
+
+	event.Status = "cancelled"
+	if err := storage.Store.SaveEvent(event); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
@@ -0,0 +1,145 @@
+package events
+
+import (
+	"discord-event-bot/internal/storage"
+	"reflect"
+	"testing"
+)
+
+func TestParseRoles(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    []storage.RoleSignup
+		wantErr bool
+	}{
+		{
+			text: "Tank:🛡️:2, DPS:⚔️:6",
+			want: []storage.RoleSignup{{Name: "Tank", Emoji: "🛡️", Limit: 2}, {Name: "DPS", Emoji: "⚔️", Limit: 6}},
+		},
+		{
+			text: "Healer, Support::",
+			want: []storage.RoleSignup{{Name: "Healer"}, {Name: "Support"}},
+		},
+		{
+			text: " Tank : 🛡️ : 0 ,,",
+			want: []storage.RoleSignup{{Name: "Tank", Emoji: "🛡️"}},
+		},
+		{text: "", wantErr: true},
+		{text: " , ", wantErr: true},
+		{text: "Tank:🛡️:dos", wantErr: true},
+		{text: "Tank:🛡️:-1", wantErr: true},
+		{text: "Tank:🛡️:2:extra", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseRoles(tt.text)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseRoles(%q) = %v, se esperaba error", tt.text, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRoles(%q): %v", tt.text, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRoles(%q) = %+v, se esperaba %+v", tt.text, got, tt.want)
+			}
+			if back, err := ParseRoles(FormatRoles(got)); err != nil || !reflect.DeepEqual(back, got) {
+				t.Errorf("FormatRoles(%+v) = %q no se vuelve a leer igual: %+v, %v", got, FormatRoles(got), back, err)
+			}
+		})
+	}
+}
+
+func TestNormalizeRequirements(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *storage.SignupRequirements
+		want    *storage.SignupRequirements
+		wantErr bool
+	}{
+		{name: "sin requisitos", req: nil, want: nil},
+		{name: "vacíos quedan en nil", req: &storage.SignupRequirements{RequiredRoles: []string{" ", ""}}, want: nil},
+		{
+			name: "menciones e IDs",
+			req:  &storage.SignupRequirements{RequiredRoles: []string{"<@&123>", " 456 "}, ForbiddenRoles: []string{"<@&789>"}},
+			want: &storage.SignupRequirements{RequiredRoles: []string{"123", "456"}, ForbiddenRoles: []string{"789"}},
+		},
+		{
+			name: "solo item level",
+			req:  &storage.SignupRequirements{MinItemLevel: 450},
+			want: &storage.SignupRequirements{MinItemLevel: 450},
+		},
+		{name: "rol con nombre", req: &storage.SignupRequirements{RequiredRoles: []string{"Raiders"}}, wantErr: true},
+		{name: "item level negativo", req: &storage.SignupRequirements{MinItemLevel: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeRequirements(tt.req)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NormalizeRequirements = %+v, se esperaba error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeRequirements: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeRequirements = %+v, se esperaba %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeEditedRoles(t *testing.T) {
+	healer := []storage.ClassInfo{{Name: "Druid", Limit: 2}, {Name: "Priest", Limit: 1}}
+	event := &storage.Event{
+		Roles: []storage.RoleSignup{{Name: "Healer", Limit: 3, Classes: healer}, {Name: "Tank", Limit: 2}},
+		Signups: map[string][]storage.Signup{
+			"Healer": {
+				{UserID: "1", Class: "Druid", Status: "confirmed"},
+				{UserID: "2", Class: "Druid", Status: "pending"},
+				{UserID: "3", Class: "Priest", Status: "declined"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		roles   []storage.RoleSignup
+		wantErr bool
+	}{
+		{name: "conserva las clases", roles: []storage.RoleSignup{{Name: "Healer", Limit: 3}, {Name: "Tank", Limit: 2}}},
+		{name: "sube el límite de una clase", roles: []storage.RoleSignup{{Name: "Healer", Limit: 3, Classes: []storage.ClassInfo{{Name: "Druid", Limit: 3}}}}},
+		{name: "clase con declinados", roles: []storage.RoleSignup{{Name: "Healer", Limit: 3, Classes: []storage.ClassInfo{{Name: "Druid", Limit: 2}, {Name: "Priest", Limit: 0}}}}},
+		{name: "sin roles", roles: nil, wantErr: true},
+		{name: "quita un rol con inscritos", roles: []storage.RoleSignup{{Name: "Tank", Limit: 2}}, wantErr: true},
+		{name: "límite del rol bajo los inscritos", roles: []storage.RoleSignup{{Name: "Healer", Limit: 1}}, wantErr: true},
+		{name: "clase que excede al rol", roles: []storage.RoleSignup{{Name: "Healer", Limit: 3, Classes: []storage.ClassInfo{{Name: "Druid", Limit: 4}}}}, wantErr: true},
+		{name: "rol bajo el límite de sus clases", roles: []storage.RoleSignup{{Name: "Healer", Limit: 2, Classes: []storage.ClassInfo{{Name: "Druid", Limit: 2}, {Name: "Priest", Limit: 3}}}}, wantErr: true},
+		{name: "clase bajo sus inscritos", roles: []storage.RoleSignup{{Name: "Healer", Limit: 3, Classes: []storage.ClassInfo{{Name: "Druid", Limit: 1}}}}, wantErr: true},
+		{name: "clase con límite negativo", roles: []storage.RoleSignup{{Name: "Healer", Classes: []storage.ClassInfo{{Name: "Druid", Limit: -1}}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeEditedRoles(event, tt.roles)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("normalizeEditedRoles = %+v, se esperaba error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizeEditedRoles: %v", err)
+			}
+			if len(got[0].Classes) == 0 {
+				t.Errorf("el rol %s perdió sus clases: %+v", got[0].Name, got[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package guilds
+
+import (
+	"discord-event-bot/config"
+	"discord-event-bot/internal/storage"
+	"fmt"
+	"strings"
+)
+
+// Resolve devuelve el servidor indicado o, si está vacío, el principal
+// (GUILD_ID). Los eventos y templates anteriores al soporte de varios
+// servidores no tienen servidor y pertenecen al principal.
+func Resolve(guildID string) string {
+	if guildID == "" {
+		return config.AppConfig.GuildID
+	}
+	return guildID
+}
+
+// Allowed indica si el bot atiende al servidor. Sin GUILD_IDS se atiende a
+// todos los servidores a los que se unió.
+func Allowed(guildID string) bool {
+	if guildID == "" || guildID == config.AppConfig.GuildID || len(config.AppConfig.GuildIDs) == 0 {
+		return true
+	}
+	for _, id := range config.AppConfig.GuildIDs {
+		if id == guildID {
+			return true
+		}
+	}
+	return false
+}
+
+// EventGuild devuelve el servidor al que pertenece un evento
+func EventGuild(event *storage.Event) string {
+	return Resolve(event.GuildID)
+}
+
+// InGuild indica si el evento pertenece al servidor
+func InGuild(event *storage.Event, guildID string) bool {
+	return EventGuild(event) == Resolve(guildID)
+}
+
+// FilterEvents devuelve los eventos del servidor
+func FilterEvents(events []*storage.Event, guildID string) []*storage.Event {
+	filtered := make([]*storage.Event, 0, len(events))
+	for _, event := range events {
+		if InGuild(event, guildID) {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered
+}
+
+// TemplateVisible indica si el template se puede usar en el servidor: los
+// templates sin servidor son compartidos
+func TemplateVisible(template *storage.EventTemplate, guildID string) bool {
+	return template.GuildID == "" || template.GuildID == Resolve(guildID)
+}
+
+// FilterTemplates devuelve los templates que se pueden usar en el servidor.
+// Un template propio oculta al compartido con el mismo nombre.
+func FilterTemplates(templates []*storage.EventTemplate, guildID string) []*storage.EventTemplate {
+	own := make(map[string]bool)
+	for _, template := range templates {
+		if template.GuildID != "" && template.GuildID == Resolve(guildID) {
+			own[template.Name] = true
+		}
+	}
+
+	filtered := make([]*storage.EventTemplate, 0, len(templates))
+	for _, template := range templates {
+		if TemplateVisible(template, guildID) && !(template.GuildID == "" && own[template.Name]) {
+			filtered = append(filtered, template)
+		}
+	}
+	return filtered
+}
+
+// FindTemplate busca por nombre un template que se pueda usar en el
+// servidor: primero el propio y si no hay, el compartido
+func FindTemplate(guildID, name string) (*storage.EventTemplate, error) {
+	if template, err := storage.Templates.GetTemplate(Resolve(guildID), name); err == nil {
+		return template, nil
+	}
+	return storage.Templates.GetTemplate("", name)
+}
+
+// List devuelve los servidores conocidos. El principal siempre aparece,
+// aunque el bot todavía no se haya conectado.
+func List() []storage.GuildSettings {
+	var list []storage.GuildSettings
+	hasMain := false
+	for _, settings := range storage.Guilds.GetAllSettings() {
+		if !Allowed(settings.GuildID) {
+			continue
+		}
+		if settings.GuildID == config.AppConfig.GuildID {
+			hasMain = true
+		}
+		list = append(list, settings)
+	}
+	if !hasMain {
+		main, _ := storage.Guilds.GetSettings(config.AppConfig.GuildID)
+		list = append([]storage.GuildSettings{main}, list...)
+	}
+	return list
+}
+
+// Name devuelve el nombre del servidor o, si no se conoce, su ID
+func Name(guildID string) string {
+	guildID = Resolve(guildID)
+	if settings, ok := storage.Guilds.GetSettings(guildID); ok && settings.Name != "" {
+		return settings.Name
+	}
+	return guildID
+}
+
+// Known indica si el bot está en el servidor (o es el principal)
+func Known(guildID string) bool {
+	if !Allowed(guildID) {
+		return false
+	}
+	if Resolve(guildID) == config.AppConfig.GuildID {
+		return true
+	}
+	_, ok := storage.Guilds.GetSettings(guildID)
+	return ok
+}
+
+// DefaultRoles devuelve los roles por defecto de los eventos del servidor
+func DefaultRoles(guildID string) []storage.RoleSignup {
+	if settings, _ := storage.Guilds.GetSettings(Resolve(guildID)); len(settings.DefaultRoles) > 0 {
+		return settings.DefaultRoles
+	}
+
+	roles := make([]storage.RoleSignup, 0, len(config.AppConfig.DefaultRoles))
+	for _, role := range config.AppConfig.DefaultRoles {
+		roles = append(roles, storage.RoleSignup{Name: role.Name, Emoji: role.Emoji, Limit: role.Limit})
+	}
+	return roles
+}
+
+// ReminderOffsetMinutes devuelve la anticipación por defecto del recordatorio
+func ReminderOffsetMinutes(guildID string) int {
+	if settings, _ := storage.Guilds.GetSettings(Resolve(guildID)); settings.ReminderOffsetMinutes > 0 {
+		return settings.ReminderOffsetMinutes
+	}
+	return config.AppConfig.ReminderOffsetMinutes
+}
+
+// DiscordEventsEnabled indica si se pueden crear eventos programados de Discord
+func DiscordEventsEnabled(guildID string) bool {
+	if settings, _ := storage.Guilds.GetSettings(Resolve(guildID)); settings.EnableDiscordEvents != nil {
+		return *settings.EnableDiscordEvents
+	}
+	return config.AppConfig.EnableDiscordEvents
+}
+
+// AttendanceVoiceChannel devuelve el canal de voz para marcar asistencia
+func AttendanceVoiceChannel(guildID string) string {
+	if settings, _ := storage.Guilds.GetSettings(Resolve(guildID)); settings.AttendanceVoiceChannelID != "" {
+		return settings.AttendanceVoiceChannelID
+	}
+	return config.AppConfig.AttendanceVoiceChannelID
+}
+
+// FillInLeadMinutes devuelve la anticipación del llamado a completar roles
+func FillInLeadMinutes(guildID string) int {
+	if settings, _ := storage.Guilds.GetSettings(Resolve(guildID)); settings.FillInLeadMinutes > 0 {
+		return settings.FillInLeadMinutes
+	}
+	return config.AppConfig.FillInLeadMinutes
+}
+
+// FillInRolePing devuelve el rol de Discord a mencionar en el llamado a
+// completar un rol del juego
+func FillInRolePing(guildID, role string) string {
+	settings, _ := storage.Guilds.GetSettings(Resolve(guildID))
+	if id := LookupRolePing(settings.FillInRolePings, role); id != "" {
+		return id
+	}
+	return LookupRolePing(config.AppConfig.FillInRolePings, role)
+}
+
+// ImportDiscordEvents indica si se copian los eventos creados desde Discord
+func ImportDiscordEvents(guildID string) bool {
+	if settings, _ := storage.Guilds.GetSettings(Resolve(guildID)); settings.ImportDiscordEvents != nil {
+		return *settings.ImportDiscordEvents
+	}
+	return config.AppConfig.ImportDiscordEvents
+}
+
+// ImportEventsChannel devuelve el canal donde se publican los eventos importados
+func ImportEventsChannel(guildID string) string {
+	if settings, _ := storage.Guilds.GetSettings(Resolve(guildID)); settings.ImportEventsChannelID != "" {
+		return settings.ImportEventsChannelID
+	}
+	return config.AppConfig.ImportEventsChannelID
+}
+
+// ImportInterestedSignups indica si "me interesa" en un evento importado
+// cuenta como inscripción pendiente
+func ImportInterestedSignups(guildID string) bool {
+	if settings, _ := storage.Guilds.GetSettings(Resolve(guildID)); settings.ImportInterestedSignups != nil {
+		return *settings.ImportInterestedSignups
+	}
+	return config.AppConfig.ImportInterestedSignups
+}
+
+// LookupRolePing busca el rol de Discord asociado a un rol del juego sin
+// distinguir mayúsculas
+func LookupRolePing(pings map[string]string, role string) string {
+	if id, ok := pings[role]; ok {
+		return id
+	}
+	for name, id := range pings {
+		if strings.EqualFold(name, role) {
+			return id
+		}
+	}
+	return ""
+}
+
+// UpdateSettings valida y guarda la configuración de un servidor
+func UpdateSettings(settings storage.GuildSettings) (storage.GuildSettings, error) {
+	if !Known(settings.GuildID) {
+		return settings, fmt.Errorf("servidor desconocido: %s", settings.GuildID)
+	}
+	if settings.ReminderOffsetMinutes < 0 || settings.FillInLeadMinutes < 0 {
+		return settings, fmt.Errorf("las anticipaciones no pueden ser negativas")
+	}
+	if settings.AttendanceVoiceChannelID != "" && !numeric(settings.AttendanceVoiceChannelID) {
+		return settings, fmt.Errorf("canal de voz inválido: %s (usa el ID numérico del canal)", settings.AttendanceVoiceChannelID)
+	}
+	if settings.ImportEventsChannelID != "" && !numeric(settings.ImportEventsChannelID) {
+		return settings, fmt.Errorf("canal de eventos importados inválido: %s (usa el ID numérico del canal)", settings.ImportEventsChannelID)
+	}
+
+	for _, role := range settings.DefaultRoles {
+		if strings.TrimSpace(role.Name) == "" {
+			return settings, fmt.Errorf("los roles por defecto necesitan nombre")
+		}
+		if role.Limit < 0 {
+			return settings, fmt.Errorf("el límite del rol %s no puede ser negativo", role.Name)
+		}
+	}
+
+	pings := make(map[string]string, len(settings.FillInRolePings))
+	for role, id := range settings.FillInRolePings {
+		role = strings.TrimSpace(role)
+		id = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(id), "<@&"), ">")
+		if role == "" || id == "" {
+			continue
+		}
+		if !numeric(id) {
+			return settings, fmt.Errorf("rol de Discord inválido: %s (usa el ID numérico del rol)", id)
+		}
+		pings[role] = id
+	}
+	settings.FillInRolePings = pings
+	if len(pings) == 0 {
+		settings.FillInRolePings = nil
+	}
+
+	saved, err := storage.Guilds.SaveSettings(settings)
+	if err != nil {
+		return settings, fmt.Errorf("Error guardando configuración del servidor: %v", err)
+	}
+	return saved, nil
+}
+
+func numeric(id string) bool {
+	return id != "" && strings.Trim(id, "0123456789") == ""
+}
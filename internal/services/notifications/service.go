@@ -0,0 +1,145 @@
+package notifications
+
+import (
+	"discord-event-bot/internal/storage"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AllTypes es el valor de AnnounceTypes que suscribe a todos los tipos de evento
+const AllTypes = "*"
+
+// MaxLeadMinutes es la anticipación máxima de un recordatorio privado (7 días)
+const MaxLeadMinutes = 7 * 24 * 60
+
+// ReminderInput representa los cambios en los recordatorios privados de un jugador
+type ReminderInput struct {
+	UserID      string
+	Username    string
+	Enabled     bool
+	LeadMinutes *int // nil = no cambia
+}
+
+// SetReminders activa o desactiva los recordatorios por mensaje privado
+func SetReminders(input ReminderInput) (storage.NotificationPrefs, error) {
+	prefs, _ := storage.Notifications.GetPrefs(input.UserID)
+	if input.Username != "" {
+		prefs.Username = input.Username
+	}
+	prefs.DMReminders = input.Enabled
+
+	if input.LeadMinutes != nil {
+		if *input.LeadMinutes < 0 || *input.LeadMinutes > MaxLeadMinutes {
+			return prefs, fmt.Errorf("La anticipación tiene que estar entre 0 y %d minutos", MaxLeadMinutes)
+		}
+		prefs.LeadMinutes = *input.LeadMinutes
+	}
+
+	return save(prefs)
+}
+
+// SetAnnouncement suscribe o desuscribe al jugador de los avisos de nuevos
+// eventos de un tipo. El tipo "todos" (o "*") cubre cualquier tipo.
+func SetAnnouncement(userID, username, eventType string, enabled bool) (storage.NotificationPrefs, error) {
+	eventType = normalizeType(eventType)
+	if eventType == "" {
+		return storage.NotificationPrefs{}, fmt.Errorf("El tipo de evento es obligatorio")
+	}
+
+	prefs, _ := storage.Notifications.GetPrefs(userID)
+	if username != "" {
+		prefs.Username = username
+	}
+
+	types := make([]string, 0, len(prefs.AnnounceTypes)+1)
+	for _, t := range prefs.AnnounceTypes {
+		if !strings.EqualFold(t, eventType) {
+			types = append(types, t)
+		}
+	}
+	if enabled {
+		types = append(types, eventType)
+		sort.Strings(types)
+	}
+	prefs.AnnounceTypes = types
+
+	return save(prefs)
+}
+
+// LeadTime devuelve con cuánta anticipación recordar un evento a un jugador
+func LeadTime(prefs storage.NotificationPrefs, eventOffsetMinutes int) time.Duration {
+	minutes := prefs.LeadMinutes
+	if minutes <= 0 {
+		minutes = eventOffsetMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// WantsAnnouncement indica si el jugador quiere aviso de nuevos eventos del tipo dado
+func WantsAnnouncement(prefs storage.NotificationPrefs, eventType string) bool {
+	for _, t := range prefs.AnnounceTypes {
+		if t == AllTypes || strings.EqualFold(t, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnnouncementRecipients devuelve los usuarios a avisar por un evento nuevo
+func AnnouncementRecipients(event *storage.Event) []string {
+	var userIDs []string
+	for _, prefs := range storage.Notifications.GetAllPrefs() {
+		if WantsAnnouncement(prefs, event.Type) {
+			userIDs = append(userIDs, prefs.UserID)
+		}
+	}
+	sort.Strings(userIDs)
+	return userIDs
+}
+
+// Describe resume las preferencias en texto para mostrarlas al jugador
+func Describe(prefs storage.NotificationPrefs) string {
+	var b strings.Builder
+	if prefs.DMReminders {
+		if prefs.LeadMinutes > 0 {
+			fmt.Fprintf(&b, "🔔 Recordatorios por privado: activados, %d minutos antes\n", prefs.LeadMinutes)
+		} else {
+			b.WriteString("🔔 Recordatorios por privado: activados, con la anticipación de cada evento\n")
+		}
+	} else {
+		b.WriteString("🔕 Recordatorios por privado: desactivados\n")
+	}
+
+	switch {
+	case len(prefs.AnnounceTypes) == 0:
+		b.WriteString("📢 Avisos de eventos nuevos: ninguno\n")
+	case WantsAnnouncement(prefs, AllTypes):
+		b.WriteString("📢 Avisos de eventos nuevos: todos los tipos\n")
+	default:
+		fmt.Fprintf(&b, "📢 Avisos de eventos nuevos: %s\n", strings.Join(prefs.AnnounceTypes, ", "))
+	}
+
+	if prefs.DMFailures > 0 {
+		fmt.Fprintf(&b, "⚠️ Fallos de entrega seguidos: %d (último: %s). Revisa que tengas habilitados los mensajes directos del servidor.\n",
+			prefs.DMFailures, prefs.LastDMError)
+	}
+	return b.String()
+}
+
+func save(prefs storage.NotificationPrefs) (storage.NotificationPrefs, error) {
+	saved, err := storage.Notifications.SavePrefs(prefs)
+	if err != nil {
+		return prefs, fmt.Errorf("Error guardando preferencias: %v", err)
+	}
+	return saved, nil
+}
+
+func normalizeType(eventType string) string {
+	eventType = strings.TrimSpace(eventType)
+	if strings.EqualFold(eventType, "todos") {
+		return AllTypes
+	}
+	return eventType
+}
@@ -0,0 +1,125 @@
+package profiles
+
+import (
+	"discord-event-bot/internal/storage"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Máximo de personajes por jugador (Discord muestra hasta 25 opciones por menú)
+const MaxCharacters = 10
+
+// CharacterInput representa los datos de un personaje a crear o actualizar
+type CharacterInput struct {
+	UserID    string
+	Username  string
+	Name      string
+	Class     string
+	Role      string
+	ItemLevel int
+	Notes     string
+	Main      bool
+}
+
+// SaveCharacter valida y guarda un personaje en el perfil del jugador.
+// Si ya existe un personaje con el mismo nombre se actualiza.
+func SaveCharacter(input CharacterInput) (*storage.Profile, error) {
+	name := strings.TrimSpace(input.Name)
+	class := strings.TrimSpace(input.Class)
+	if name == "" {
+		return nil, fmt.Errorf("El nombre del personaje es obligatorio")
+	}
+	if class == "" {
+		return nil, fmt.Errorf("La clase del personaje es obligatoria")
+	}
+	if input.ItemLevel < 0 {
+		return nil, fmt.Errorf("El item level no puede ser negativo")
+	}
+
+	if profile, err := storage.Profiles.GetProfile(input.UserID); err == nil {
+		if _, exists := profile.FindCharacter(name); !exists && len(profile.Characters) >= MaxCharacters {
+			return nil, fmt.Errorf("Ya tienes %d personajes, elimina uno antes de agregar otro", MaxCharacters)
+		}
+	}
+
+	profile, err := storage.Profiles.SaveCharacter(input.UserID, input.Username, storage.Character{
+		Name:      name,
+		Class:     class,
+		Role:      strings.TrimSpace(input.Role),
+		ItemLevel: input.ItemLevel,
+		Notes:     strings.TrimSpace(input.Notes),
+		Main:      input.Main,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error guardando personaje: %v", err)
+	}
+	return profile, nil
+}
+
+// RemoveCharacter elimina un personaje del perfil del jugador
+func RemoveCharacter(userID, name string) (*storage.Profile, error) {
+	profile, err := storage.Profiles.RemoveCharacter(userID, strings.TrimSpace(name))
+	if err != nil {
+		return nil, fmt.Errorf("No tienes un personaje llamado %s", name)
+	}
+	return profile, nil
+}
+
+// SetMain marca un personaje como main del jugador
+func SetMain(userID, name string) (*storage.Profile, error) {
+	profile, err := storage.Profiles.SetMainCharacter(userID, strings.TrimSpace(name))
+	if err != nil {
+		return nil, fmt.Errorf("No tienes un personaje llamado %s", name)
+	}
+	return profile, nil
+}
+
+// CharactersForSignup devuelve los personajes del jugador para ofrecer al
+// inscribirse en un rol: primero los que juegan ese rol y, entre ellos, el main
+func CharactersForSignup(userID, role string) []storage.Character {
+	profile, err := storage.Profiles.GetProfile(userID)
+	if err != nil {
+		return nil
+	}
+
+	characters := append([]storage.Character(nil), profile.Characters...)
+	rank := func(c storage.Character) int {
+		r := 0
+		if !strings.EqualFold(c.Role, role) {
+			r += 2
+		}
+		if !c.Main {
+			r++
+		}
+		return r
+	}
+	sort.SliceStable(characters, func(i, j int) bool {
+		return rank(characters[i]) < rank(characters[j])
+	})
+	return characters
+}
+
+// FindCharacter busca un personaje de un jugador por ID o nombre
+func FindCharacter(userID, idOrName string) (storage.Character, bool) {
+	profile, err := storage.Profiles.GetProfile(userID)
+	if err != nil {
+		return storage.Character{}, false
+	}
+	return profile.FindCharacter(idOrName)
+}
+
+// Describe devuelve una línea corta con los datos de un personaje
+func Describe(character storage.Character) string {
+	text := fmt.Sprintf("%s — %s", character.Name, character.Class)
+	if character.Role != "" {
+		text += fmt.Sprintf(" (%s)", character.Role)
+	}
+	if character.ItemLevel > 0 {
+		text += fmt.Sprintf(" · iLvl %d", character.ItemLevel)
+	}
+	if character.Main {
+		text += " · main"
+	}
+	return text
+}
@@ -0,0 +1,456 @@
+package recurrence
+
+import (
+	"discord-event-bot/config"
+	"discord-event-bot/internal/storage"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frecuencias soportadas
+const (
+	FreqDaily   = "daily"
+	FreqWeekly  = "weekly"
+	FreqMonthly = "monthly"
+)
+
+const dateLayout = "2006-01-02"
+
+// Códigos RRULE de los días de la semana, en el orden de time.Weekday
+var weekdayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+var weekdayNames = map[string]string{
+	"MO": "lunes", "TU": "martes", "WE": "miércoles", "TH": "jueves",
+	"FR": "viernes", "SA": "sábado", "SU": "domingo",
+}
+
+// Parse interpreta una regla estilo RRULE (por ejemplo
+// "FREQ=WEEKLY;BYDAY=TU,TH" o "FREQ=MONTHLY;BYDAY=-1FR;COUNT=6") y una lista
+// de fechas a saltear separadas por coma. start es la primera ocurrencia.
+func Parse(rule, exceptions string, start time.Time) (*storage.Recurrence, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if rule == "" {
+		return nil, fmt.Errorf("la regla de repetición está vacía")
+	}
+
+	loc := Location()
+	rec := &storage.Recurrence{Interval: 1, Start: start.In(loc)}
+
+	for _, part := range strings.Split(rule, ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("parte inválida en la regla: %s", part)
+		}
+		key = strings.ToUpper(strings.TrimSpace(key))
+		value = strings.ToUpper(strings.TrimSpace(value))
+
+		switch key {
+		case "FREQ":
+			switch value {
+			case "DAILY":
+				rec.Freq = FreqDaily
+			case "WEEKLY":
+				rec.Freq = FreqWeekly
+			case "MONTHLY":
+				rec.Freq = FreqMonthly
+			default:
+				return nil, fmt.Errorf("frecuencia no soportada: %s (usa DAILY, WEEKLY o MONTHLY)", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("INTERVAL debe ser un número mayor a 0")
+			}
+			rec.Interval = n
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				if err := parseByDay(rec, strings.TrimSpace(day)); err != nil {
+					return nil, err
+				}
+			}
+		case "UNTIL":
+			until, err := parseUntil(value, loc)
+			if err != nil {
+				return nil, err
+			}
+			rec.Until = &until
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("COUNT debe ser un número mayor a 0")
+			}
+			rec.Count = n
+		default:
+			return nil, fmt.Errorf("parámetro no soportado en la regla: %s", key)
+		}
+	}
+
+	for _, raw := range strings.Split(exceptions, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if _, err := time.ParseInLocation(dateLayout, raw, loc); err != nil {
+			return nil, fmt.Errorf("fecha de excepción inválida: %s (formato YYYY-MM-DD)", raw)
+		}
+		rec.Exceptions = append(rec.Exceptions, raw)
+	}
+	sort.Strings(rec.Exceptions)
+
+	if err := Validate(rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// FromRepeatDays convierte el viejo RepeatEveryDays en una regla diaria
+func FromRepeatDays(days int, start time.Time) *storage.Recurrence {
+	return &storage.Recurrence{Freq: FreqDaily, Interval: days, Start: start.In(Location())}
+}
+
+// Validate verifica que la regla sea coherente
+func Validate(rec *storage.Recurrence) error {
+	switch rec.Freq {
+	case FreqDaily, FreqWeekly, FreqMonthly:
+	case "":
+		return fmt.Errorf("la regla debe indicar FREQ")
+	default:
+		return fmt.Errorf("frecuencia no soportada: %s", rec.Freq)
+	}
+
+	if rec.Interval < 1 {
+		return fmt.Errorf("el intervalo debe ser mayor a 0")
+	}
+	if len(rec.Weekdays) > 0 && rec.Freq != FreqWeekly {
+		return fmt.Errorf("los días de la semana solo aplican a reglas semanales")
+	}
+	if rec.MonthWeekday != "" && rec.Freq != FreqMonthly {
+		return fmt.Errorf("el día N del mes solo aplica a reglas mensuales")
+	}
+	if rec.MonthWeek < -1 || rec.MonthWeek > 4 || (rec.MonthWeekday != "" && rec.MonthWeek == 0) {
+		return fmt.Errorf("la semana del mes debe ser 1-4 o -1 (última)")
+	}
+	if rec.Until != nil && rec.Until.Before(rec.Start) {
+		return fmt.Errorf("la fecha de fin es anterior al inicio")
+	}
+	if rec.Until != nil && rec.Count > 0 {
+		return fmt.Errorf("usa UNTIL o COUNT, no ambos")
+	}
+	return nil
+}
+
+// IsRecurring indica si el evento se repite, con la regla nueva o con RepeatEveryDays
+func IsRecurring(event *storage.Event) bool {
+	return event.Recurrence != nil || event.RepeatEveryDays > 0
+}
+
+// RuleFor devuelve la regla efectiva del evento (convirtiendo RepeatEveryDays si hace falta)
+func RuleFor(event *storage.Event) *storage.Recurrence {
+	if event.Recurrence != nil {
+		return event.Recurrence
+	}
+	if event.RepeatEveryDays > 0 {
+		return FromRepeatDays(event.RepeatEveryDays, event.DateTime)
+	}
+	return nil
+}
+
+// First devuelve la primera ocurrencia en o después de rec.Start. Permite
+// crear "todos los martes y jueves" con una fecha de inicio que cae otro día.
+func First(rec *storage.Recurrence) (time.Time, bool) {
+	return next(rec, rec.Start.Add(-time.Second))
+}
+
+// Next devuelve la primera ocurrencia estrictamente posterior a after,
+// salteando excepciones y respetando UNTIL/COUNT
+func Next(rec *storage.Recurrence, after time.Time) (time.Time, bool) {
+	return next(rec, after)
+}
+
+func next(rec *storage.Recurrence, after time.Time) (time.Time, bool) {
+	loc := Location()
+	start := rec.Start.In(loc)
+	after = after.In(loc)
+
+	// Si ninguna fecha coincide en este margen la regla ya no produce ocurrencias
+	horizon := after.AddDate(0, 0, (rec.Interval+1)*366)
+
+	count := 0
+	for day := dateOnly(start); !day.After(horizon); day = day.AddDate(0, 0, 1) {
+		if !matches(rec, start, day) {
+			continue
+		}
+
+		// Construir la hora local en cada fecha: así los cambios de horario
+		// de verano no desplazan el evento
+		occurrence := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+		if occurrence.Before(start) {
+			continue
+		}
+
+		count++
+		if rec.Count > 0 && count > rec.Count {
+			return time.Time{}, false
+		}
+		if rec.Until != nil && occurrence.After(*rec.Until) {
+			return time.Time{}, false
+		}
+		if !occurrence.After(after) || isException(rec, occurrence) {
+			continue
+		}
+		return occurrence, true
+	}
+
+	return time.Time{}, false
+}
+
+// matches indica si una fecha local cumple la regla (sin mirar la hora)
+func matches(rec *storage.Recurrence, start, day time.Time) bool {
+	switch rec.Freq {
+	case FreqDaily:
+		return daysBetween(dateOnly(start), day)%rec.Interval == 0
+	case FreqWeekly:
+		weeks := daysBetween(weekStart(start), weekStart(day)) / 7
+		if weeks%rec.Interval != 0 {
+			return false
+		}
+		if len(rec.Weekdays) == 0 {
+			return day.Weekday() == start.Weekday()
+		}
+		for _, code := range rec.Weekdays {
+			if weekdayCodes[day.Weekday()] == code {
+				return true
+			}
+		}
+		return false
+	case FreqMonthly:
+		months := (day.Year()-start.Year())*12 + int(day.Month()) - int(start.Month())
+		if months%rec.Interval != 0 {
+			return false
+		}
+		if rec.MonthWeekday == "" {
+			return day.Day() == start.Day()
+		}
+		if weekdayCodes[day.Weekday()] != rec.MonthWeekday {
+			return false
+		}
+		if rec.MonthWeek == -1 {
+			return day.AddDate(0, 0, 7).Month() != day.Month()
+		}
+		return (day.Day()-1)/7+1 == rec.MonthWeek
+	}
+	return false
+}
+
+// Format devuelve la regla en formato RRULE (sin excepciones)
+func Format(rec *storage.Recurrence) string {
+	parts := []string{"FREQ=" + strings.ToUpper(rec.Freq)}
+	if rec.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", rec.Interval))
+	}
+	if len(rec.Weekdays) > 0 {
+		parts = append(parts, "BYDAY="+strings.Join(rec.Weekdays, ","))
+	}
+	if rec.MonthWeekday != "" {
+		parts = append(parts, fmt.Sprintf("BYDAY=%d%s", rec.MonthWeek, rec.MonthWeekday))
+	}
+	if rec.Until != nil {
+		parts = append(parts, "UNTIL="+rec.Until.UTC().Format("20060102T150405Z"))
+	}
+	if rec.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", rec.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+// FormatFrom devuelve la regla en formato RRULE para la serie que sigue desde
+// la ocurrencia from: COUNT descuenta las ocurrencias anteriores
+func FormatFrom(rec *storage.Recurrence, from time.Time) string {
+	return Format(Rebase(rec, from))
+}
+
+// Rebase devuelve una copia de la regla que empieza en from, por ejemplo
+// cuando se cambia la fecha de la ocurrencia vigente. COUNT descuenta las
+// ocurrencias anteriores para que la serie termine en la misma cantidad.
+func Rebase(rec *storage.Recurrence, from time.Time) *storage.Recurrence {
+	rebased := Copy(rec)
+	if rec.Count > 0 {
+		rebased.Count = rec.Count - countBefore(rec, from)
+		if rebased.Count < 1 {
+			rebased.Count = 1
+		}
+	}
+	rebased.Start = from
+	return rebased
+}
+
+// Copy devuelve una copia de la regla que no comparte listas ni fechas con
+// la original
+func Copy(rec *storage.Recurrence) *storage.Recurrence {
+	if rec == nil {
+		return nil
+	}
+	copied := *rec
+	copied.Weekdays = append([]string(nil), rec.Weekdays...)
+	copied.Exceptions = append([]string(nil), rec.Exceptions...)
+	if rec.Until != nil {
+		until := *rec.Until
+		copied.Until = &until
+	}
+	return &copied
+}
+
+// Describe devuelve una descripción legible de la regla
+func Describe(rec *storage.Recurrence) string {
+	var text string
+	switch rec.Freq {
+	case FreqDaily:
+		text = "Cada día"
+		if rec.Interval > 1 {
+			text = fmt.Sprintf("Cada %d días", rec.Interval)
+		}
+	case FreqWeekly:
+		text = "Cada semana"
+		if rec.Interval > 1 {
+			text = fmt.Sprintf("Cada %d semanas", rec.Interval)
+		}
+		days := rec.Weekdays
+		if len(days) == 0 {
+			days = []string{weekdayCodes[rec.Start.In(Location()).Weekday()]}
+		}
+		names := make([]string, 0, len(days))
+		for _, code := range days {
+			names = append(names, weekdayNames[code])
+		}
+		text += " los " + joinSpanish(names)
+	case FreqMonthly:
+		text = "Cada mes"
+		if rec.Interval > 1 {
+			text = fmt.Sprintf("Cada %d meses", rec.Interval)
+		}
+		if rec.MonthWeekday != "" {
+			nth := map[int]string{1: "primer", 2: "segundo", 3: "tercer", 4: "cuarto", -1: "último"}[rec.MonthWeek]
+			text += fmt.Sprintf(" el %s %s", nth, weekdayNames[rec.MonthWeekday])
+		} else {
+			text += fmt.Sprintf(" el día %d", rec.Start.In(Location()).Day())
+		}
+	}
+
+	if rec.Until != nil {
+		text += " hasta el " + rec.Until.In(Location()).Format("02/01/2006")
+	}
+	if rec.Count > 0 {
+		text += fmt.Sprintf(" (%d veces)", rec.Count)
+	}
+	if len(rec.Exceptions) > 0 {
+		text += fmt.Sprintf(", salvo %d fecha(s)", len(rec.Exceptions))
+	}
+	return text
+}
+
+// Location devuelve la zona horaria configurada
+func Location() *time.Location {
+	if config.AppConfig != nil {
+		if loc, err := time.LoadLocation(config.AppConfig.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+func parseByDay(rec *storage.Recurrence, value string) error {
+	if len(value) < 2 {
+		return fmt.Errorf("día inválido en BYDAY: %s", value)
+	}
+	code := value[len(value)-2:]
+	if _, ok := weekdayNames[code]; !ok {
+		return fmt.Errorf("día inválido en BYDAY: %s (usa MO, TU, WE, TH, FR, SA, SU)", value)
+	}
+
+	prefix := value[:len(value)-2]
+	if prefix == "" {
+		rec.Weekdays = append(rec.Weekdays, code)
+		return nil
+	}
+
+	// Prefijo numérico: N-ésimo día del mes (2TH = segundo jueves, -1FR = último viernes)
+	n, err := strconv.Atoi(strings.TrimPrefix(prefix, "+"))
+	if err != nil || n == 0 || n < -1 || n > 4 {
+		return fmt.Errorf("día inválido en BYDAY: %s (usa 1-4 o -1 antes del día)", value)
+	}
+	if rec.MonthWeekday != "" {
+		return fmt.Errorf("solo se admite un día N del mes por regla")
+	}
+	rec.MonthWeek = n
+	rec.MonthWeekday = code
+	return nil
+}
+
+func parseUntil(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"20060102", dateLayout} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			// Una fecha sin hora incluye todo ese día
+			return t.AddDate(0, 0, 1).Add(-time.Second), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("UNTIL inválido: %s (usa YYYYMMDD)", value)
+}
+
+// countBefore cuenta las ocurrencias de la regla en días anteriores al de t,
+// incluidas las excepciones (igual que COUNT)
+func countBefore(rec *storage.Recurrence, t time.Time) int {
+	loc := Location()
+	start := rec.Start.In(loc)
+	limit := dateOnly(t.In(loc))
+
+	count := 0
+	for day := dateOnly(start); day.Before(limit); day = day.AddDate(0, 0, 1) {
+		if matches(rec, start, day) {
+			count++
+		}
+	}
+	return count
+}
+
+func isException(rec *storage.Recurrence, occurrence time.Time) bool {
+	date := occurrence.In(Location()).Format(dateLayout)
+	for _, exception := range rec.Exceptions {
+		if exception == date {
+			return true
+		}
+	}
+	return false
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// weekStart devuelve el lunes de la semana de t
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return dateOnly(t).AddDate(0, 0, -offset)
+}
+
+// daysBetween cuenta días de calendario, sin verse afectado por el horario de verano
+func daysBetween(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+func joinSpanish(items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " y " + items[len(items)-1]
+}
@@ -0,0 +1,239 @@
+package recurrence
+
+import (
+	"discord-event-bot/config"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func useTimezone(t *testing.T, tz string) *time.Location {
+	t.Helper()
+	previous := config.AppConfig
+	config.AppConfig = &config.Config{Timezone: tz}
+	t.Cleanup(func() { config.AppConfig = previous })
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		t.Fatalf("zona horaria %s: %v", tz, err)
+	}
+	return loc
+}
+
+func TestParse(t *testing.T) {
+	loc := useTimezone(t, "Europe/Madrid")
+	start := time.Date(2026, time.March, 3, 21, 0, 0, 0, loc)
+
+	tests := []struct {
+		name         string
+		rule         string
+		exceptions   string
+		freq         string
+		interval     int
+		weekdays     []string
+		monthWeek    int
+		monthWeekday string
+		count        int
+		until        string
+		skip         []string
+		wantErr      bool
+	}{
+		{name: "semanal con días", rule: "FREQ=WEEKLY;BYDAY=TU,TH", freq: FreqWeekly, interval: 1, weekdays: []string{"TU", "TH"}},
+		{name: "prefijo RRULE y minúsculas", rule: "RRULE:freq=daily;interval=2", freq: FreqDaily, interval: 2},
+		{name: "último viernes del mes", rule: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=6", freq: FreqMonthly, interval: 1, monthWeek: -1, monthWeekday: "FR", count: 6},
+		{name: "hasta una fecha", rule: "FREQ=WEEKLY;UNTIL=20260430", freq: FreqWeekly, interval: 1, until: "2026-04-30"},
+		{name: "excepciones ordenadas", rule: "FREQ=WEEKLY", exceptions: "2026-03-17, 2026-03-10", freq: FreqWeekly, interval: 1, skip: []string{"2026-03-10", "2026-03-17"}},
+		{name: "regla vacía", rule: " ", wantErr: true},
+		{name: "frecuencia no soportada", rule: "FREQ=YEARLY", wantErr: true},
+		{name: "sin FREQ", rule: "INTERVAL=2", wantErr: true},
+		{name: "intervalo inválido", rule: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{name: "días en regla mensual", rule: "FREQ=MONTHLY;BYDAY=MO,TU", wantErr: true},
+		{name: "UNTIL y COUNT juntos", rule: "FREQ=DAILY;UNTIL=20260430;COUNT=3", wantErr: true},
+		{name: "UNTIL anterior al inicio", rule: "FREQ=DAILY;UNTIL=20260101", wantErr: true},
+		{name: "excepción inválida", rule: "FREQ=DAILY", exceptions: "10/03/2026", wantErr: true},
+		{name: "parámetro desconocido", rule: "FREQ=DAILY;BYHOUR=3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, err := Parse(tt.rule, tt.exceptions, start)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) no devolvió error", tt.rule)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.rule, err)
+			}
+
+			if rec.Freq != tt.freq || rec.Interval != tt.interval || rec.Count != tt.count {
+				t.Errorf("freq/interval/count = %s/%d/%d, se esperaba %s/%d/%d", rec.Freq, rec.Interval, rec.Count, tt.freq, tt.interval, tt.count)
+			}
+			if !reflect.DeepEqual(rec.Weekdays, tt.weekdays) {
+				t.Errorf("weekdays = %v, se esperaba %v", rec.Weekdays, tt.weekdays)
+			}
+			if rec.MonthWeek != tt.monthWeek || rec.MonthWeekday != tt.monthWeekday {
+				t.Errorf("día del mes = %d%s, se esperaba %d%s", rec.MonthWeek, rec.MonthWeekday, tt.monthWeek, tt.monthWeekday)
+			}
+			if !reflect.DeepEqual(rec.Exceptions, tt.skip) {
+				t.Errorf("exceptions = %v, se esperaba %v", rec.Exceptions, tt.skip)
+			}
+			switch {
+			case tt.until == "" && rec.Until != nil:
+				t.Errorf("until = %v, se esperaba vacío", rec.Until)
+			case tt.until != "" && (rec.Until == nil || rec.Until.In(loc).Format(dateLayout) != tt.until):
+				t.Errorf("until = %v, se esperaba %s", rec.Until, tt.until)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	loc := useTimezone(t, "Europe/Madrid")
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 0, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name  string
+		rule  string
+		skip  string
+		start time.Time
+		after time.Time
+		want  []time.Time // ocurrencias siguientes a after
+		ends  bool        // después de want la serie termina
+	}{
+		{
+			name:  "semanal cruzando el horario de verano",
+			rule:  "FREQ=WEEKLY",
+			start: at(time.March, 22, 21),
+			after: at(time.March, 22, 21),
+			want:  []time.Time{at(time.March, 29, 21), at(time.April, 5, 21)},
+		},
+		{
+			name:  "diaria cruzando el fin del horario de verano",
+			rule:  "FREQ=DAILY",
+			start: at(time.October, 24, 21),
+			after: at(time.October, 24, 21),
+			want:  []time.Time{at(time.October, 25, 21), at(time.October, 26, 21)},
+		},
+		{
+			name:  "martes y jueves",
+			rule:  "FREQ=WEEKLY;BYDAY=TU,TH",
+			start: at(time.March, 3, 21),
+			after: at(time.March, 3, 21),
+			want:  []time.Time{at(time.March, 5, 21), at(time.March, 10, 21), at(time.March, 12, 21)},
+		},
+		{
+			name:  "cada dos semanas",
+			rule:  "FREQ=WEEKLY;INTERVAL=2",
+			start: at(time.March, 3, 21),
+			after: at(time.March, 3, 21),
+			want:  []time.Time{at(time.March, 17, 21), at(time.March, 31, 21)},
+		},
+		{
+			name:  "último viernes del mes",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR",
+			start: at(time.January, 30, 20),
+			after: at(time.January, 30, 20),
+			want:  []time.Time{at(time.February, 27, 20), at(time.March, 27, 20)},
+		},
+		{
+			name:  "saltea excepciones",
+			rule:  "FREQ=WEEKLY",
+			skip:  "2026-03-10",
+			start: at(time.March, 3, 21),
+			after: at(time.March, 3, 21),
+			want:  []time.Time{at(time.March, 17, 21)},
+		},
+		{
+			name:  "COUNT cuenta las excepciones",
+			rule:  "FREQ=WEEKLY;COUNT=3",
+			skip:  "2026-03-10",
+			start: at(time.March, 3, 21),
+			after: at(time.March, 3, 21),
+			want:  []time.Time{at(time.March, 17, 21)},
+			ends:  true,
+		},
+		{
+			name:  "UNTIL incluye el último día",
+			rule:  "FREQ=WEEKLY;UNTIL=20260317",
+			start: at(time.March, 3, 21),
+			after: at(time.March, 3, 21),
+			want:  []time.Time{at(time.March, 10, 21), at(time.March, 17, 21)},
+			ends:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, err := Parse(tt.rule, tt.skip, tt.start)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.rule, err)
+			}
+
+			after := tt.after
+			for _, want := range tt.want {
+				got, ok := Next(rec, after)
+				if !ok {
+					t.Fatalf("Next(%v) terminó la serie, se esperaba %v", after, want)
+				}
+				if !got.Equal(want) || got.In(loc).Hour() != want.Hour() {
+					t.Fatalf("Next(%v) = %v, se esperaba %v", after, got, want)
+				}
+				after = got
+			}
+			if got, ok := Next(rec, after); ok == tt.ends {
+				t.Errorf("Next(%v) = %v, %v; fin de la serie esperado: %v", after, got, ok, tt.ends)
+			}
+		})
+	}
+}
+
+func TestRebase(t *testing.T) {
+	loc := useTimezone(t, "Europe/Madrid")
+	start := time.Date(2026, time.March, 3, 21, 0, 0, 0, loc)
+
+	tests := []struct {
+		name      string
+		rule      string
+		from      time.Time
+		wantCount int
+		wantNext  time.Time
+	}{
+		{
+			name:      "COUNT descuenta las ocurrencias anteriores",
+			rule:      "FREQ=WEEKLY;COUNT=4",
+			from:      time.Date(2026, time.March, 17, 21, 0, 0, 0, loc),
+			wantCount: 2,
+			wantNext:  time.Date(2026, time.March, 24, 21, 0, 0, 0, loc),
+		},
+		{
+			name:     "la nueva hora se mantiene en las siguientes",
+			rule:     "FREQ=WEEKLY",
+			from:     time.Date(2026, time.March, 3, 22, 30, 0, 0, loc),
+			wantNext: time.Date(2026, time.March, 10, 22, 30, 0, 0, loc),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, err := Parse(tt.rule, "", start)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.rule, err)
+			}
+
+			rebased := Rebase(rec, tt.from)
+			if rebased.Count != tt.wantCount {
+				t.Errorf("Count = %d, se esperaba %d", rebased.Count, tt.wantCount)
+			}
+			if !rebased.Start.Equal(tt.from) || !rec.Start.Equal(start) {
+				t.Errorf("Start = %v (original %v), se esperaba %v sin tocar la original", rebased.Start, rec.Start, tt.from)
+			}
+			if got, ok := Next(rebased, tt.from); !ok || !got.Equal(tt.wantNext) {
+				t.Errorf("Next = %v, %v; se esperaba %v", got, ok, tt.wantNext)
+			}
+		})
+	}
+}
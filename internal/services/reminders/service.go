@@ -1,17 +1,68 @@
 package reminders
 
 import (
-	"discord-event-bot/config"
+	"discord-event-bot/internal/services/guilds"
+	"discord-event-bot/internal/services/notifications"
+	"discord-event-bot/internal/services/recurrence"
+	signupsvc "discord-event-bot/internal/services/signups"
 	"discord-event-bot/internal/storage"
+	"fmt"
 	"log"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // ProcessResult representa el resultado del procesamiento de recordatorios.
 type ProcessResult struct {
-	EventsToRemind         []*storage.Event
+	Reminders              []Reminder
 	EventsToUpdate         []*storage.Event
 	EventsToDeleteMessages []*storage.Event
+	EventsToTakeAttendance []*storage.Event
+	DirectReminders        []DirectReminder
+	FillIns                []FillIn
+	ScheduledEvents        []ScheduledEventChange
+}
+
+// Acciones sobre el evento programado de Discord de un evento
+const (
+	ScheduledEventStart = "start" // empezó el evento: pasa a ACTIVE
+	ScheduledEventEnd   = "end"   // terminó el evento: pasa a COMPLETED
+	ScheduledEventRoll  = "roll"  // nueva ocurrencia de una serie: se crea su evento programado
+)
+
+// scheduledEventEndRetryWindow es cuánto tiempo después de completado un
+// evento se sigue reintentando cerrar su evento de Discord
+const scheduledEventEndRetryWindow = 24 * time.Hour
+
+// ScheduledEventChange es un cambio a aplicar en el evento programado de Discord
+type ScheduledEventChange struct {
+	Event  *storage.Event
+	Action string
+}
+
+// Reminder es una etapa de recordatorio que toca enviar para un evento
+type Reminder struct {
+	Event         *storage.Event
+	OffsetMinutes int
+	Stage         int  // 1 = la etapa con más anticipación
+	Stages        int  // cantidad total de etapas del evento
+	Final         bool // última etapa antes del evento
+}
+
+// FillIn es un llamado a completar los roles que le faltan a un evento
+type FillIn struct {
+	Event *storage.Event
+	Gaps  []signupsvc.RoleGap
+}
+
+// DirectReminder son los usuarios a recordar por mensaje privado para un evento
+type DirectReminder struct {
+	Event   *storage.Event
+	UserIDs []string
 }
 
 // ProcessReminders aplica la lógica de recordatorios sobre todos los eventos activos
@@ -19,16 +70,24 @@ type ProcessResult struct {
 func ProcessReminders(now time.Time) ProcessResult {
 	result := ProcessResult{}
 
+	retryScheduledEventEnds(now, &result)
+
 	events := storage.Store.GetActiveEvents()
 
 	for _, event := range events {
-		// Calcular offset de recordatorio (por evento o global)
+		// Anticipación de la última etapa, usada por defecto en los recordatorios privados
 		offsetMinutes := calculateReminderOffsetMinutes(event)
 
-		if event.RepeatEveryDays > 0 {
-			handleRecurringEvent(event, now, offsetMinutes, &result)
+		// La asistencia se pide antes de que una ocurrencia recurrente pase a la siguiente
+		handleAttendance(event, now, &result)
+		handleDirectReminders(event, now, offsetMinutes, &result)
+		handleFillIn(event, now, &result)
+		handleScheduledEventStart(event, now, &result)
+
+		if recurrence.IsRecurring(event) {
+			handleRecurringEvent(event, now, &result)
 		} else {
-			handleNonRecurringEvent(event, now, offsetMinutes, &result)
+			handleNonRecurringEvent(event, now, &result)
 		}
 
 		handleAutoDelete(event, now, &result)
@@ -38,38 +97,108 @@ func ProcessReminders(now time.Time) ProcessResult {
 }
 
 func calculateReminderOffsetMinutes(event *storage.Event) int {
+	offsets := ReminderOffsets(event)
+	return offsets[len(offsets)-1]
+}
+
+// ReminderOffsets devuelve las etapas de recordatorio del evento en minutos,
+// de mayor a menor anticipación. Sin etapas configuradas hay una sola: la
+// anticipación del evento o, si no tiene, la de su servidor.
+func ReminderOffsets(event *storage.Event) []int {
+	if offsets, err := NormalizeOffsets(event.ReminderOffsets); err == nil && len(offsets) > 0 {
+		return offsets
+	}
+
 	offsetMinutes := event.ReminderOffsetMinutes
 	if offsetMinutes <= 0 {
-		offsetMinutes = config.AppConfig.ReminderOffsetMinutes
+		offsetMinutes = guilds.ReminderOffsetMinutes(event.GuildID)
 	}
-	return offsetMinutes
+	return []int{offsetMinutes}
 }
 
-func handleRecurringEvent(event *storage.Event, now time.Time, offsetMinutes int, result *ProcessResult) {
-	changed, shouldRemind := processRecurringEvent(event, now, offsetMinutes)
-	if changed {
-		if err := storage.Store.SaveEvent(event); err != nil {
-			log.Printf("Error guardando evento recurrente %s: %v", event.ID, err)
-		} else {
-			result.EventsToUpdate = append(result.EventsToUpdate, event)
+// dueReminder marca y devuelve la etapa de recordatorio que corresponde
+// enviar ahora. Si el bot estuvo apagado y vencieron varias etapas, solo se
+// envía la más reciente y las anteriores quedan marcadas como enviadas.
+func dueReminder(event *storage.Event, now time.Time) (Reminder, bool) {
+	if event.Status != "active" || event.ReminderSent || !now.Before(event.DateTime) {
+		return Reminder{}, false
+	}
+
+	offsets := ReminderOffsets(event)
+	sent := make(map[int]bool, len(event.RemindersSent))
+	for _, offset := range event.RemindersSent {
+		sent[offset] = true
+	}
+
+	due := -1
+	for idx, offset := range offsets {
+		if !now.Before(event.DateTime.Add(-time.Duration(offset) * time.Minute)) {
+			due = idx
 		}
 	}
-	if shouldRemind {
-		result.EventsToRemind = append(result.EventsToRemind, event)
+	if due == -1 || sent[offsets[due]] {
+		return Reminder{}, false
+	}
+
+	for _, offset := range offsets[:due+1] {
+		if !sent[offset] {
+			event.RemindersSent = append(event.RemindersSent, offset)
+			sent[offset] = true
+		}
 	}
+	final := due == len(offsets)-1
+	if final {
+		event.ReminderSent = true
+	}
+
+	return Reminder{
+		Event:         event,
+		OffsetMinutes: offsets[due],
+		Stage:         due + 1,
+		Stages:        len(offsets),
+		Final:         final,
+	}, true
 }
 
-func handleNonRecurringEvent(event *storage.Event, now time.Time, offsetMinutes int, result *ProcessResult) {
-	// Eventos no recurrentes
-	reminderTime := event.DateTime.Add(-time.Duration(offsetMinutes) * time.Minute)
+func handleRecurringEvent(event *storage.Event, now time.Time, result *ProcessResult) {
+	current, changed, reminder, shouldRemind := processRecurringEvent(event, now)
+	if !changed {
+		return
+	}
 
-	if now.After(reminderTime) && !event.ReminderSent {
-		// Marcar recordatorio como enviado y guardar
-		event.ReminderSent = true
+	var err error
+	if current != event {
+		err = storage.Store.ReplaceOccurrence(event, current)
+	} else {
+		err = storage.Store.SaveEvent(current)
+	}
+	if err != nil {
+		log.Printf("Error guardando evento recurrente %s: %v", event.ID, err)
+		return
+	}
+
+	result.EventsToUpdate = append(result.EventsToUpdate, current)
+	if shouldRemind {
+		result.Reminders = append(result.Reminders, reminder)
+	}
+
+	// La ocurrencia que terminó cierra su evento de Discord y la siguiente
+	// tiene uno propio
+	if current != event && event.DiscordEventID != "" {
+		handleScheduledEventEnd(event, result)
+		result.ScheduledEvents = append(result.ScheduledEvents, ScheduledEventChange{Event: current, Action: ScheduledEventRoll})
+	} else if event.Status == "completed" {
+		handleScheduledEventEnd(event, result)
+	}
+}
+
+func handleNonRecurringEvent(event *storage.Event, now time.Time, result *ProcessResult) {
+	// Eventos no recurrentes: marcar la etapa como enviada y guardar
+	if reminder, ok := dueReminder(event, now); ok {
 		if err := storage.Store.SaveEvent(event); err != nil {
 			log.Printf("Error guardando evento %s al marcar recordatorio enviado: %v", event.ID, err)
 		} else {
-			result.EventsToRemind = append(result.EventsToRemind, event)
+			result.Reminders = append(result.Reminders, reminder)
 		}
 	}
 
@@ -79,7 +208,154 @@ func handleNonRecurringEvent(event *storage.Event, now time.Time, offsetMinutes
 		if err := storage.Store.SaveEvent(event); err != nil {
 			log.Printf("Error marcando evento %s como completado: %v", event.ID, err)
 		}
+		handleScheduledEventEnd(event, result)
+	}
+}
+
+// handleScheduledEventStart pide pasar a ACTIVE el evento de Discord cuando
+// empieza el evento. El estado se guarda recién cuando Discord acepta el
+// cambio, así un fallo se reintenta en la próxima pasada.
+func handleScheduledEventStart(event *storage.Event, now time.Time, result *ProcessResult) {
+	if event.DiscordEventID == "" || event.DiscordEventStatus != "" || now.Before(event.DateTime) {
+		return
+	}
+	result.ScheduledEvents = append(result.ScheduledEvents, ScheduledEventChange{Event: event, Action: ScheduledEventStart})
+}
+
+// handleScheduledEventEnd pide pasar a COMPLETED el evento de Discord de un
+// evento terminado; como al iniciarlo, el estado lo guarda quien aplica el cambio
+func handleScheduledEventEnd(event *storage.Event, result *ProcessResult) {
+	if event.DiscordEventID == "" || event.DiscordEventStatus == "completed" || event.DiscordEventStatus == "cancelled" {
+		return
+	}
+	result.ScheduledEvents = append(result.ScheduledEvents, ScheduledEventChange{Event: event, Action: ScheduledEventEnd})
+}
+
+// retryScheduledEventEnds vuelve a pedir el cierre de los eventos de Discord
+// de eventos terminados hace poco que Discord no llegó a cerrar. Pasada la
+// ventana de reintento se dejan como están.
+func retryScheduledEventEnds(now time.Time, result *ProcessResult) {
+	for _, event := range storage.Store.GetAllEvents() {
+		if event.Status == "completed" && now.Before(event.DateTime.Add(2*time.Hour+scheduledEventEndRetryWindow)) {
+			handleScheduledEventEnd(event, result)
+		}
+	}
+}
+
+func handleAttendance(event *storage.Event, now time.Time, result *ProcessResult) {
+	// Publicar el panel de asistencia cuando empieza el evento
+	if event.AttendancePanelSent || now.Before(event.DateTime) || !hasConfirmedSignups(event) {
+		return
+	}
+
+	event.AttendancePanelSent = true
+	if err := storage.Store.SaveEvent(event); err != nil {
+		log.Printf("Error guardando evento %s al marcar panel de asistencia: %v", event.ID, err)
+		return
+	}
+	result.EventsToTakeAttendance = append(result.EventsToTakeAttendance, event)
+}
+
+// handleDirectReminders junta a los confirmados con recordatorios privados
+// activados cuya anticipación ya se cumplió. Cada usuario se recuerda una sola
+// vez por ocurrencia.
+func handleDirectReminders(event *storage.Event, now time.Time, offsetMinutes int, result *ProcessResult) {
+	if event.Status != "active" || !now.Before(event.DateTime) {
+		return
+	}
+
+	sent := make(map[string]bool, len(event.DMRemindersSent))
+	for _, userID := range event.DMRemindersSent {
+		sent[userID] = true
+	}
+
+	var due []string
+	for _, signups := range event.Signups {
+		for _, signup := range signups {
+			if signup.Status != "confirmed" || sent[signup.UserID] {
+				continue
+			}
+			prefs, ok := storage.Notifications.GetPrefs(signup.UserID)
+			if !ok || !prefs.DMReminders {
+				continue
+			}
+			if now.Before(event.DateTime.Add(-notifications.LeadTime(prefs, offsetMinutes))) {
+				continue
+			}
+			sent[signup.UserID] = true
+			due = append(due, signup.UserID)
+		}
+	}
+	if len(due) == 0 {
+		return
+	}
+
+	event.DMRemindersSent = append(event.DMRemindersSent, due...)
+	if err := storage.Store.SaveEvent(event); err != nil {
+		log.Printf("Error guardando evento %s al marcar recordatorios privados: %v", event.ID, err)
+		return
 	}
+	result.DirectReminders = append(result.DirectReminders, DirectReminder{Event: event, UserIDs: due})
+}
+
+// handleFillIn pide jugadores para los roles incompletos cuando falta poco
+// para el evento. Si al llegar la hora no falta nadie se sigue revisando,
+// así una baja de último momento también dispara el llamado.
+func handleFillIn(event *storage.Event, now time.Time, result *ProcessResult) {
+	lead := FillInLeadMinutes(event)
+	if lead <= 0 || event.FillInSent || event.Status != "active" || !now.Before(event.DateTime) {
+		return
+	}
+	if now.Before(event.DateTime.Add(-time.Duration(lead) * time.Minute)) {
+		return
+	}
+
+	gaps := signupsvc.MissingSlots(event)
+	if len(gaps) == 0 {
+		return
+	}
+
+	event.FillInSent = true
+	event.FillInRoles = make([]string, 0, len(gaps))
+	for _, gap := range gaps {
+		event.FillInRoles = append(event.FillInRoles, gap.Role.Name)
+	}
+	if err := storage.Store.SaveEvent(event); err != nil {
+		log.Printf("Error guardando evento %s al marcar llamado a completar: %v", event.ID, err)
+		return
+	}
+	result.FillIns = append(result.FillIns, FillIn{Event: event, Gaps: gaps})
+}
+
+// FillInLeadMinutes devuelve con cuántos minutos de anticipación se hace el
+// llamado a completar roles; 0 = desactivado
+func FillInLeadMinutes(event *storage.Event) int {
+	if event.FillIn != nil && event.FillIn.LeadMinutes > 0 {
+		return event.FillIn.LeadMinutes
+	}
+	return guilds.FillInLeadMinutes(event.GuildID)
+}
+
+// FillInRolePing devuelve el ID del rol de Discord a mencionar para un rol
+// del juego: primero el del evento y después el de su servidor
+func FillInRolePing(event *storage.Event, role string) string {
+	if event.FillIn != nil {
+		if id := guilds.LookupRolePing(event.FillIn.RolePings, role); id != "" {
+			return id
+		}
+	}
+	return guilds.FillInRolePing(event.GuildID, role)
+}
+
+func hasConfirmedSignups(event *storage.Event) bool {
+	for _, signups := range event.Signups {
+		for _, signup := range signups {
+			if signup.Status == "confirmed" {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func handleAutoDelete(event *storage.Event, now time.Time, result *ProcessResult) {
@@ -95,31 +371,235 @@ func handleAutoDelete(event *storage.Event, now time.Time, result *ProcessResult
 }
 
 // processRecurringEvent aplica la lógica específica para eventos recurrentes.
-// Devuelve si hubo cambios persistentes en el evento y si corresponde enviar recordatorio ahora.
-func processRecurringEvent(event *storage.Event, now time.Time, offsetMinutes int) (changed bool, shouldRemind bool) {
-	// Avanzar la fecha del evento si ya pasó hace más de 2 horas
-	for now.After(event.DateTime.Add(2 * time.Hour)) {
-		event.DateTime = event.DateTime.Add(time.Duration(event.RepeatEveryDays) * 24 * time.Hour)
-		event.ReminderSent = false
-		// Recalcular AnnouncementTime para la nueva fecha si hay offset configurado
-		if event.AnnouncementOffsetHours > 0 {
-			event.AnnouncementTime = event.DateTime.Add(-time.Duration(event.AnnouncementOffsetHours) * time.Hour)
+// Devuelve la ocurrencia vigente (una nueva si la anterior ya pasó), si hubo
+// cambios persistentes y la etapa de recordatorio a enviar ahora, si hay una.
+func processRecurringEvent(event *storage.Event, now time.Time) (current *storage.Event, changed bool, reminder Reminder, shouldRemind bool) {
+	current = event
+
+	// Pasar a la próxima ocurrencia si la actual ya pasó hace más de 2 horas.
+	// Las fechas se calculan en la zona horaria configurada, así que la hora
+	// local se mantiene aunque cambie el horario de verano.
+	if now.After(event.DateTime.Add(2 * time.Hour)) {
+		rule := recurrence.RuleFor(event)
+		next, ok := recurrence.Next(rule, event.DateTime)
+		skipped := 0
+		// Si el bot estuvo apagado se saltean las ocurrencias que ya pasaron
+		for ok && now.After(next.Add(2*time.Hour)) {
+			next, ok = recurrence.Next(rule, next)
+			skipped++
+		}
+
+		event.SeriesID = seriesID(event)
+		event.Occurrence = occurrenceNumber(event)
+		event.Status = "completed"
+		changed = true
+		if !ok {
+			// La serie terminó (UNTIL/COUNT): la última ocurrencia queda completada
+			return
 		}
+
+		current = nextOccurrence(event, rule, next, skipped, now)
+	}
+
+	if reminder, shouldRemind = dueReminder(current, now); shouldRemind {
 		changed = true
 	}
 
-	if !event.ReminderSent {
-		reminderTime := event.DateTime.Add(-time.Duration(offsetMinutes) * time.Minute)
-		windowStart := reminderTime.Add(-1 * time.Minute)
-		windowEnd := reminderTime.Add(1 * time.Minute)
+	return
+}
+
+// nextOccurrence crea el registro de la próxima ocurrencia de una serie.
+// El mensaje y el hilo pasan a la nueva ocurrencia y la anterior queda como
+// historial con sus inscripciones. El evento de Discord queda en la anterior
+// para cerrarlo; la nueva ocurrencia tendrá uno propio.
+func nextOccurrence(previous *storage.Event, rule *storage.Recurrence, dateTime time.Time, skipped int, now time.Time) *storage.Event {
+	next := *previous
+	next.ID = uuid.New().String()
+	next.Occurrence = previous.Occurrence + skipped + 1
+	next.DateTime = dateTime
+	next.Status = "active"
+	next.ReminderSent = false
+	next.RemindersSent = nil
+	next.AttendancePanelSent = false
+	next.DMRemindersSent = nil
+	next.FillInSent = false
+	next.FillInRoles = nil
+	next.DiscordEventID = ""
+	next.DiscordEventStatus = ""
+	next.CreatedAt = now
+
+	// La copia no comparte listas, mapas ni punteros con la ocurrencia
+	// anterior: editar una no debe cambiar la otra
+	next.Roles = copyRoles(previous.Roles)
+	next.ReminderOffsets = append([]int(nil), previous.ReminderOffsets...)
+	next.Requirements = copyRequirements(previous.Requirements)
+	next.FillIn = copyFillIn(previous.FillIn)
+
+	// Las series con repetición en días pasan a guardar la regla completa
+	next.Recurrence = recurrence.Copy(rule)
+	next.RepeatEveryDays = 0
+
+	// Recalcular AnnouncementTime para la nueva fecha si hay offset configurado
+	if next.AnnouncementOffsetHours > 0 {
+		next.AnnouncementTime = next.DateTime.Add(-time.Duration(next.AnnouncementOffsetHours) * time.Hour)
+	}
 
-		if now.After(windowStart) && now.Before(windowEnd) {
-			// Toca enviar recordatorio
-			event.ReminderSent = true
-			changed = true
-			shouldRemind = true
+	next.Signups = make(map[string][]storage.Signup)
+	next.Waitlist = nil
+	next.Cancellations = nil
+	if previous.CarryOverSignups {
+		next.Signups = carriedOverSignups(previous.Signups)
+		if waitlist := carriedOverSignups(previous.Waitlist); len(waitlist) > 0 {
+			next.Waitlist = waitlist
 		}
 	}
 
-	return
+	previous.MessageID = ""
+	previous.ThreadID = ""
+
+	return &next
+}
+
+// carriedOverSignups copia las inscripciones para la próxima ocurrencia,
+// descartando las rechazadas
+func carriedOverSignups(signups map[string][]storage.Signup) map[string][]storage.Signup {
+	carried := make(map[string][]storage.Signup)
+	for role, list := range signups {
+		for _, signup := range list {
+			if signup.Status == "declined" {
+				continue
+			}
+			signup.Attendance = ""
+			signup.AttendanceBy = ""
+			signup.LateFill = false
+			carried[role] = append(carried[role], signup)
+		}
+	}
+	return carried
+}
+
+func copyRoles(roles []storage.RoleSignup) []storage.RoleSignup {
+	copied := make([]storage.RoleSignup, len(roles))
+	for i, role := range roles {
+		role.Classes = append([]storage.ClassInfo(nil), role.Classes...)
+		copied[i] = role
+	}
+	return copied
+}
+
+func copyRequirements(requirements *storage.SignupRequirements) *storage.SignupRequirements {
+	if requirements == nil {
+		return nil
+	}
+	copied := *requirements
+	copied.RequiredRoles = append([]string(nil), requirements.RequiredRoles...)
+	copied.ForbiddenRoles = append([]string(nil), requirements.ForbiddenRoles...)
+	return &copied
+}
+
+func copyFillIn(settings *storage.FillInSettings) *storage.FillInSettings {
+	if settings == nil {
+		return nil
+	}
+	copied := *settings
+	if settings.RolePings != nil {
+		copied.RolePings = make(map[string]string, len(settings.RolePings))
+		for role, id := range settings.RolePings {
+			copied.RolePings[role] = id
+		}
+	}
+	return &copied
+}
+
+// seriesID devuelve el ID de la serie; la primera ocurrencia usa su propio ID
+func seriesID(event *storage.Event) string {
+	if event.SeriesID != "" {
+		return event.SeriesID
+	}
+	return event.ID
+}
+
+// occurrenceNumber devuelve el número de ocurrencia, contando desde 1
+func occurrenceNumber(event *storage.Event) int {
+	if event.Occurrence > 0 {
+		return event.Occurrence
+	}
+	return 1
+}
+
+// MaxReminderStages es la cantidad máxima de etapas de recordatorio por evento
+const MaxReminderStages = 5
+
+// NormalizeOffsets valida las etapas de recordatorio, quita repetidas y las
+// ordena de mayor a menor anticipación
+func NormalizeOffsets(offsets []int) ([]int, error) {
+	seen := make(map[int]bool, len(offsets))
+	var normalized []int
+	for _, offset := range offsets {
+		if offset <= 0 {
+			return nil, fmt.Errorf("las etapas de recordatorio tienen que ser mayores a 0 minutos")
+		}
+		if !seen[offset] {
+			seen[offset] = true
+			normalized = append(normalized, offset)
+		}
+	}
+	if len(normalized) > MaxReminderStages {
+		return nil, fmt.Errorf("como máximo %d etapas de recordatorio", MaxReminderStages)
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(normalized)))
+	return normalized, nil
+}
+
+// ParseOffsets interpreta etapas de recordatorio separadas por coma, como
+// "24h, 1h, 10m". Los números sin unidad son minutos y "d" indica días.
+func ParseOffsets(text string) ([]int, error) {
+	var offsets []int
+	for _, part := range strings.Split(text, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+
+		unit := 1
+		switch {
+		case strings.HasSuffix(part, "d"):
+			unit = 24 * 60
+			part = strings.TrimSuffix(part, "d")
+		case strings.HasSuffix(part, "h"):
+			unit = 60
+			part = strings.TrimSuffix(part, "h")
+		case strings.HasSuffix(part, "m"):
+			part = strings.TrimSuffix(part, "m")
+		}
+
+		value, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("etapa de recordatorio inválida: %q (usa por ejemplo 24h, 1h, 10m)", part)
+		}
+		offsets = append(offsets, value*unit)
+	}
+	return NormalizeOffsets(offsets)
+}
+
+// FormatOffsets muestra las etapas en el mismo formato que acepta ParseOffsets
+func FormatOffsets(offsets []int) string {
+	parts := make([]string, 0, len(offsets))
+	for _, offset := range offsets {
+		parts = append(parts, FormatOffset(offset))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FormatOffset muestra una anticipación en la unidad más grande exacta
+func FormatOffset(minutes int) string {
+	switch {
+	case minutes%(24*60) == 0:
+		return fmt.Sprintf("%dd", minutes/(24*60))
+	case minutes%60 == 0:
+		return fmt.Sprintf("%dh", minutes/60)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
 }
@@ -0,0 +1,95 @@
+package reminders
+
+import (
+	"discord-event-bot/internal/storage"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseOffsets(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    []int
+		wantErr bool
+	}{
+		{text: "", want: nil},
+		{text: "10", want: []int{10}},
+		{text: "24h, 1h, 10m", want: []int{1440, 60, 10}},
+		{text: "10m,1d,2H", want: []int{1440, 120, 10}},
+		{text: "1h, 60m, 60", want: []int{60}},
+		{text: " 30m , , 2h ", want: []int{120, 30}},
+		{text: "1d,2d,3d,4d,5d", want: []int{7200, 5760, 4320, 2880, 1440}},
+		{text: "1d,2d,3d,4d,5d,6d", wantErr: true},
+		{text: "0m", wantErr: true},
+		{text: "-5m", wantErr: true},
+		{text: "1 hora", wantErr: true},
+		{text: "h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseOffsets(tt.text)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseOffsets(%q) = %v, se esperaba error", tt.text, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseOffsets(%q): %v", tt.text, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseOffsets(%q) = %v, se esperaba %v", tt.text, got, tt.want)
+			}
+			if back, err := ParseOffsets(FormatOffsets(got)); err != nil || !reflect.DeepEqual(back, got) {
+				t.Errorf("FormatOffsets(%v) = %q no se vuelve a leer igual: %v, %v", got, FormatOffsets(got), back, err)
+			}
+		})
+	}
+}
+
+func TestNextOccurrenceDoesNotShareData(t *testing.T) {
+	now := time.Now()
+	previous := &storage.Event{
+		ID:               "serie-1",
+		Name:             "Raid",
+		DateTime:         now,
+		Status:           "completed",
+		CarryOverSignups: true,
+		Roles:            []storage.RoleSignup{{Name: "Tank", Limit: 2, Classes: []storage.ClassInfo{{Name: "Guerrero"}}}},
+		Signups:          map[string][]storage.Signup{"Tank": {{UserID: "1", Role: "Tank", Status: "confirmed"}}},
+		ReminderOffsets:  []int{60, 10},
+		Requirements:     &storage.SignupRequirements{RequiredRoles: []string{"100"}},
+		FillIn:           &storage.FillInSettings{RolePings: map[string]string{"Tank": "200"}},
+		Recurrence: &storage.Recurrence{
+			Freq: "weekly", Interval: 1, Start: now,
+			Weekdays: []string{"MO"}, Exceptions: []string{"2026-01-05"},
+		},
+	}
+	next := nextOccurrence(previous, previous.Recurrence, now.Add(7*24*time.Hour), 0, now)
+
+	// Cada cambio en la nueva ocurrencia no debe verse en la anterior
+	mutations := []struct {
+		name   string
+		mutate func()
+		check  func() bool
+	}{
+		{"clases del rol", func() { next.Roles[0].Classes[0].Name = "Paladín" }, func() bool { return previous.Roles[0].Classes[0].Name == "Guerrero" }},
+		{"inscripciones", func() { next.Signups["Tank"][0].Status = "declined" }, func() bool { return previous.Signups["Tank"][0].Status == "confirmed" }},
+		{"etapas de recordatorio", func() { next.ReminderOffsets[0] = 5 }, func() bool { return previous.ReminderOffsets[0] == 60 }},
+		{"requisitos", func() { next.Requirements.RequiredRoles[0] = "999" }, func() bool { return previous.Requirements.RequiredRoles[0] == "100" }},
+		{"menciones del llamado", func() { next.FillIn.RolePings["Tank"] = "999" }, func() bool { return previous.FillIn.RolePings["Tank"] == "200" }},
+		{"días de la regla", func() { next.Recurrence.Weekdays[0] = "TU" }, func() bool { return previous.Recurrence.Weekdays[0] == "MO" }},
+		{"excepciones de la regla", func() { next.Recurrence.Exceptions[0] = "2026-02-02" }, func() bool { return previous.Recurrence.Exceptions[0] == "2026-01-05" }},
+	}
+
+	for _, tt := range mutations {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mutate()
+			if !tt.check() {
+				t.Errorf("cambiar %s en la nueva ocurrencia modificó la anterior", tt.name)
+			}
+		})
+	}
+}
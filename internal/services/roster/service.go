@@ -0,0 +1,229 @@
+package roster
+
+import (
+	"bytes"
+	"discord-event-bot/internal/services/recurrence"
+	"discord-event-bot/internal/storage"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Formatos de exportación soportados
+const (
+	FormatCSV      = "csv"
+	FormatJSON     = "json"
+	FormatMarkdown = "md"
+)
+
+// Formats lista los formatos en el orden en que se ofrecen
+var Formats = []string{FormatCSV, FormatJSON, FormatMarkdown}
+
+// Entry es una fila del roster: una inscripción o un lugar en la banca
+type Entry struct {
+	Role       string    `json:"role"`
+	Class      string    `json:"class,omitempty"`
+	Character  string    `json:"character,omitempty"`
+	Username   string    `json:"username"`
+	UserID     string    `json:"user_id"`
+	Status     string    `json:"status"` // pending, confirmed, declined, waitlisted
+	SignedUpAt time.Time `json:"signed_up_at"`
+}
+
+// Export es el roster listo para descargar
+type Export struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// ParseFormat normaliza el formato pedido; vacío = CSV
+func ParseFormat(format string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	switch format {
+	case "":
+		return FormatCSV, nil
+	case "markdown":
+		return FormatMarkdown, nil
+	case FormatCSV, FormatJSON, FormatMarkdown:
+		return format, nil
+	}
+	return "", fmt.Errorf("formato desconocido: %s (usa csv, json o md)", format)
+}
+
+// Entries devuelve el roster en el orden de los roles del evento. Dentro de
+// cada rol van primero los inscritos y después la banca, por orden de llegada.
+func Entries(event *storage.Event) []Entry {
+	var entries []Entry
+	for _, role := range event.Roles {
+		signups := append([]storage.Signup(nil), event.Signups[role.Name]...)
+		sort.SliceStable(signups, func(i, j int) bool {
+			return signups[i].SignedUpAt.Before(signups[j].SignedUpAt)
+		})
+		for _, signup := range signups {
+			entries = append(entries, newEntry(role.Name, signup, signup.Status))
+		}
+		// La banca ya está en orden de llegada
+		for _, waiting := range event.Waitlist[role.Name] {
+			entries = append(entries, newEntry(role.Name, waiting, "waitlisted"))
+		}
+	}
+	return entries
+}
+
+// Build genera el roster del evento en el formato indicado
+func Build(event *storage.Event, format string) (*Export, error) {
+	format, err := ParseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := Entries(event)
+	export := &Export{Filename: filename(event, format)}
+
+	switch format {
+	case FormatJSON:
+		export.ContentType = "application/json; charset=utf-8"
+		export.Data, err = json.MarshalIndent(document(event, entries), "", "  ")
+	case FormatMarkdown:
+		export.ContentType = "text/markdown; charset=utf-8"
+		export.Data = markdown(event, entries)
+	default:
+		export.ContentType = "text/csv; charset=utf-8"
+		export.Data, err = csvRoster(entries)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error generando el roster: %v", err)
+	}
+	return export, nil
+}
+
+func newEntry(role string, signup storage.Signup, status string) Entry {
+	return Entry{
+		Role:       role,
+		Class:      signup.Class,
+		Character:  signup.Character,
+		Username:   signup.Username,
+		UserID:     signup.UserID,
+		Status:     status,
+		SignedUpAt: signup.SignedUpAt,
+	}
+}
+
+// document arma el documento JSON con los datos básicos del evento
+func document(event *storage.Event, entries []Entry) map[string]any {
+	if entries == nil {
+		entries = []Entry{}
+	}
+	return map[string]any{
+		"event_id": event.ID,
+		"name":     event.Name,
+		"type":     event.Type,
+		"datetime": event.DateTime,
+		"status":   event.Status,
+		"roster":   entries,
+	}
+}
+
+func csvRoster(entries []Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"role", "class", "character", "username", "user_id", "status", "signed_up_at"}); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		err := w.Write([]string{
+			csvCell(entry.Role),
+			csvCell(entry.Class),
+			csvCell(entry.Character),
+			csvCell(entry.Username),
+			entry.UserID,
+			entry.Status,
+			entry.SignedUpAt.In(recurrence.Location()).Format(time.RFC3339),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// csvCell evita que una planilla interprete como fórmula un texto elegido por
+// los jugadores (nombres, personajes): se antepone un apóstrofo
+func csvCell(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// Nombres de los estados en el roster en Markdown
+var statusLabels = map[string]string{
+	"pending":    "🕒 Pendiente",
+	"confirmed":  "✅ Confirmado",
+	"declined":   "❌ Rechazado",
+	"waitlisted": "⏳ Banca",
+}
+
+func markdown(event *storage.Event, entries []Entry) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", event.Name)
+	fmt.Fprintf(&b, "%s · %s\n\n", event.Type, event.DateTime.In(recurrence.Location()).Format("02/01/2006 15:04"))
+
+	if len(entries) == 0 {
+		b.WriteString("Sin inscritos.\n")
+		return []byte(b.String())
+	}
+
+	b.WriteString("| Rol | Clase | Jugador | Estado | Inscripción |\n")
+	b.WriteString("|---|---|---|---|---|\n")
+	for _, entry := range entries {
+		class := entry.Class
+		if entry.Character != "" {
+			class = strings.TrimSpace(class + " (" + entry.Character + ")")
+		}
+		status := statusLabels[entry.Status]
+		if status == "" {
+			status = entry.Status
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+			markdownCell(entry.Role),
+			markdownCell(class),
+			markdownCell(entry.Username),
+			status,
+			entry.SignedUpAt.In(recurrence.Location()).Format("02/01/2006 15:04"))
+	}
+	return []byte(b.String())
+}
+
+func markdownCell(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, "|", `\|`), "\n", " ")
+}
+
+// Letras con tilde que se conservan sin tilde en el nombre del archivo
+var accents = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n")
+
+// filename arma un nombre de archivo seguro, ej: roster-raid-semanal-2024-12-25.csv
+func filename(event *storage.Event, format string) string {
+	var slug strings.Builder
+	dash := false
+	for _, r := range accents.Replace(strings.ToLower(event.Name)) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			slug.WriteRune(r)
+			dash = false
+		} else if !dash && slug.Len() > 0 {
+			slug.WriteByte('-')
+			dash = true
+		}
+	}
+	name := strings.TrimSuffix(slug.String(), "-")
+	if name == "" {
+		name = "evento"
+	}
+	return fmt.Sprintf("roster-%s-%s.%s", name, event.DateTime.In(recurrence.Location()).Format("2006-01-02"), format)
+}
@@ -0,0 +1,111 @@
+package roster
+
+import (
+	"bytes"
+	"discord-event-bot/config"
+	"discord-event-bot/internal/storage"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEvent() *storage.Event {
+	signedUp := time.Date(2026, time.March, 1, 20, 0, 0, 0, time.UTC)
+	return &storage.Event{
+		ID:       "e1",
+		Name:     "Raid Semanal: Última | Prueba",
+		Type:     "Raid",
+		DateTime: time.Date(2026, time.March, 10, 21, 0, 0, 0, time.UTC),
+		Roles:    []storage.RoleSignup{{Name: "Tank", Limit: 2}, {Name: "DPS"}},
+		Signups: map[string][]storage.Signup{
+			"Tank": {
+				{UserID: "2", Username: "=HYPERLINK(\"x\")", Role: "Tank", Class: "Guerrero", Status: "confirmed", SignedUpAt: signedUp.Add(time.Minute)},
+				{UserID: "1", Username: "ana", Role: "Tank", Class: "Paladín", Character: "@Ana|Main", Status: "pending", SignedUpAt: signedUp},
+			},
+		},
+		Waitlist: map[string][]storage.Signup{
+			"Tank": {{UserID: "3", Username: "-bo", Role: "Tank", Status: "waitlisted", SignedUpAt: signedUp.Add(time.Hour)}},
+		},
+	}
+}
+
+func TestCSV(t *testing.T) {
+	useUTC(t)
+
+	export, err := Build(testEvent(), FormatCSV)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(export.Data)).ReadAll()
+	if err != nil {
+		t.Fatalf("el CSV no se puede leer: %v", err)
+	}
+
+	want := [][]string{
+		{"role", "class", "character", "username", "user_id", "status", "signed_up_at"},
+		{"Tank", "Paladín", "'@Ana|Main", "ana", "1", "pending", "2026-03-01T20:00:00Z"},
+		{"Tank", "Guerrero", "", "'=HYPERLINK(\"x\")", "2", "confirmed", "2026-03-01T20:01:00Z"},
+		{"Tank", "", "", "'-bo", "3", "waitlisted", "2026-03-01T21:00:00Z"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("%d filas, se esperaban %d: %v", len(records), len(want), records)
+	}
+	for i := range want {
+		if strings.Join(records[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("fila %d = %q, se esperaba %q", i, records[i], want[i])
+		}
+	}
+}
+
+func TestCSVCell(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"", ""},
+		{"Ana", "Ana"},
+		{"=1+1", "'=1+1"},
+		{"+54 11", "'+54 11"},
+		{"-bo", "'-bo"},
+		{"@everyone", "'@everyone"},
+		{"\tTab", "'\tTab"},
+		{"a=b", "a=b"},
+	}
+
+	for _, tt := range tests {
+		if got := csvCell(tt.text); got != tt.want {
+			t.Errorf("csvCell(%q) = %q, se esperaba %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestMarkdownAndFilename(t *testing.T) {
+	useUTC(t)
+
+	export, err := Build(testEvent(), "markdown")
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if export.Filename != "roster-raid-semanal-ultima-prueba-2026-03-10.md" {
+		t.Errorf("Filename = %q", export.Filename)
+	}
+
+	md := string(export.Data)
+	for _, want := range []string{
+		"# Raid Semanal: Última | Prueba\n",
+		`| Tank | Paladín (@Ana\|Main) | ana | 🕒 Pendiente | 01/03/2026 20:00 |`,
+		"| Tank |  | -bo | ⏳ Banca | 01/03/2026 21:00 |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("falta %q en:\n%s", want, md)
+		}
+	}
+}
+
+func useUTC(t *testing.T) {
+	t.Helper()
+	previous := config.AppConfig
+	config.AppConfig = &config.Config{Timezone: "UTC"}
+	t.Cleanup(func() { config.AppConfig = previous })
+}
@@ -1,17 +1,25 @@
 package signups
 
 import (
+	"discord-event-bot/internal/services/profiles"
 	"discord-event-bot/internal/storage"
 	"fmt"
+	"strings"
+	"time"
 )
 
 // SignupInput representa los datos necesarios para inscribir a un usuario en un evento.
 type SignupInput struct {
-	EventID  string
-	UserID   string
-	Username string
-	Role     string
-	Class    string
+	EventID   string
+	UserID    string
+	Username  string
+	Role      string
+	Class     string
+	Character string // ID o nombre de un personaje del perfil del jugador
+
+	// MemberRoles son los IDs de roles de Discord del jugador, necesarios
+	// cuando el evento tiene requisitos de roles
+	MemberRoles []string
 }
 
 // CancelInput representa los datos necesarios para cancelar la inscripción de un usuario.
@@ -20,6 +28,14 @@ type CancelInput struct {
 	UserID  string
 }
 
+// ReviewInput representa la decisión de un oficial sobre una inscripción pendiente.
+type ReviewInput struct {
+	EventID    string
+	UserID     string
+	Role       string
+	ReviewerID string
+}
+
 // SignupToEvent aplica las reglas de negocio para inscribir a un usuario en un evento.
 func SignupToEvent(input SignupInput) (*storage.Event, error) {
 	event, err := storage.Store.GetEvent(input.EventID)
@@ -27,74 +43,613 @@ func SignupToEvent(input SignupInput) (*storage.Event, error) {
 		return nil, fmt.Errorf("Evento no encontrado")
 	}
 
-	// Verificar si ya está inscrito
+	if input.Class, err = checkSignupTarget(event, input.Role, input.Class); err != nil {
+		return nil, err
+	}
+
+	if err := checkNotSignedUp(event, input); err != nil {
+		return nil, err
+	}
+
+	// Con un personaje del perfil la clase sale del personaje
+	var character *storage.Character
+	if input.Character != "" {
+		found, ok := profiles.FindCharacter(input.UserID, input.Character)
+		if !ok {
+			return nil, fmt.Errorf("No tienes un personaje llamado %s", input.Character)
+		}
+		character = &found
+		input.Character = character.Name
+		if input.Class == "" {
+			class, err := classForCharacter(event, input.Role, *character)
+			if err != nil {
+				return nil, err
+			}
+			input.Class = class
+		}
+	}
+
+	if err := CheckRequirements(event, input, character); err != nil {
+		return nil, err
+	}
+
+	// Verificar límite de rol
+	if IsRoleFull(event, input.Role) {
+		return nil, fmt.Errorf("El rol %s ya está lleno. Podés unirte a la banca y te avisaremos si se libera un lugar.", input.Role)
+	}
+
+	// Verificar límite de clase dentro del rol
+	if input.Class != "" && IsClassFull(event, input.Role, input.Class) {
+		return nil, fmt.Errorf("La clase %s ya está llena en el rol %s. Elegí otra clase o unite a la banca.", input.Class, input.Role)
+	}
+
+	// Agregar inscripción (con personaje o clase si aplica)
+	err = storage.Store.AddSignup(input.EventID, storage.Signup{
+		UserID:    input.UserID,
+		Username:  input.Username,
+		Role:      input.Role,
+		Class:     input.Class,
+		Character: input.Character,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error procesando inscripción")
+	}
+
+	// Si estaba en la banca ya no tiene sentido que siga esperando
+	// (solo en este rol si el evento permite multi-inscripción)
+	waitlistRole := ""
+	if event.AllowMultiSignup {
+		waitlistRole = input.Role
+	}
+	if _, err := storage.Store.RemoveFromWaitlist(input.EventID, input.UserID, waitlistRole); err != nil {
+		return nil, fmt.Errorf("Error procesando inscripción")
+	}
+
+	// El puntero event apunta a la misma instancia que se actualiza en el store
+	return event, nil
+}
+
+// JoinWaitlist agrega al usuario a la banca de un rol que ya está lleno o,
+// si eligió clase, de una clase llena. La clase se conserva para que la
+// promoción espere un lugar en esa clase.
+func JoinWaitlist(input SignupInput) (*storage.Event, error) {
+	event, err := storage.Store.GetEvent(input.EventID)
+	if err != nil {
+		return nil, fmt.Errorf("Evento no encontrado")
+	}
+
+	if input.Class, err = checkSignupTarget(event, input.Role, input.Class); err != nil {
+		return nil, err
+	}
+
+	if err := checkNotSignedUp(event, input); err != nil {
+		return nil, err
+	}
+
+	for _, waiting := range event.Waitlist {
+		for _, signup := range waiting {
+			if signup.UserID == input.UserID {
+				return nil, fmt.Errorf("Ya estás en la banca de %s", signup.Role)
+			}
+		}
+	}
+
+	if !CanJoinWaitlist(event, input.Role, input.Class) {
+		if input.Class != "" {
+			return nil, fmt.Errorf("La clase %s todavía tiene lugares libres en el rol %s, inscribite directamente", input.Class, input.Role)
+		}
+		return nil, fmt.Errorf("El rol %s todavía tiene lugares libres, inscribite directamente", input.Role)
+	}
+
+	if err := CheckRequirements(event, input, nil); err != nil {
+		return nil, err
+	}
+
+	if err := storage.Store.AddToWaitlist(input.EventID, input.UserID, input.Username, input.Role, input.Class); err != nil {
+		return nil, fmt.Errorf("Error procesando inscripción en la banca")
+	}
+
+	return event, nil
+}
+
+// CancelSignup aplica las reglas de negocio para cancelar la inscripción de un usuario.
+// Si la cancelación libera un lugar, promueve automáticamente al primero de la banca
+// y devuelve las inscripciones promovidas para que el llamador pueda notificarlas.
+func CancelSignup(input CancelInput) (*storage.Event, []storage.Signup, error) {
+	event, err := storage.Store.GetEvent(input.EventID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Evento no encontrado")
+	}
+
+	userID := input.UserID
+
+	// Las inscripciones rechazadas se conservan como registro de la decisión
+	var freedRoles []string
+	for role, signups := range event.Signups {
+		for _, signup := range signups {
+			if signup.UserID == userID && signup.Status != "declined" {
+				freedRoles = append(freedRoles, role)
+				break
+			}
+		}
+	}
+
+	for _, role := range freedRoles {
+		// Se registra la cancelación para las estadísticas; es tardía si ya
+		// se había enviado alguna etapa del recordatorio. ReminderSent
+		// cubre los eventos guardados antes de los recordatorios por etapas.
+		if signup, ok := FindSignup(event, role, userID); ok {
+			cancellation := storage.Cancellation{
+				UserID:      userID,
+				Username:    signup.Username,
+				Role:        role,
+				Class:       signup.Class,
+				SignedUpAt:  signup.SignedUpAt,
+				CancelledAt: time.Now(),
+				Late:        len(event.RemindersSent) > 0 || event.ReminderSent,
+			}
+			if err := storage.Store.RecordCancellation(input.EventID, cancellation); err != nil {
+				return nil, nil, fmt.Errorf("Error cancelando inscripción")
+			}
+		}
+
+		if err := storage.Store.RemoveSignup(input.EventID, userID, role); err != nil {
+			return nil, nil, fmt.Errorf("Error cancelando inscripción")
+		}
+	}
+
+	removedFromWaitlist, err := storage.Store.RemoveFromWaitlist(input.EventID, userID, "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error cancelando inscripción")
+	}
+
+	if len(freedRoles) == 0 && !removedFromWaitlist {
+		return nil, nil, fmt.Errorf("No estás inscrito en este evento")
+	}
+
+	var promoted []storage.Signup
+	for _, role := range freedRoles {
+		promoted = append(promoted, fillFromWaitlist(event, role)...)
+	}
+
+	return event, promoted, nil
+}
+
+// AddInterested inscribe como pendiente a quien marcó "me interesa" en el
+// evento de Discord. El rol sale del personaje principal del perfil; si no
+// tiene o ese rol no tiene lugar, se usa el primer rol con lugar. Devuelve
+// nil si el usuario ya estaba inscrito o en la banca.
+func AddInterested(eventID, userID, username string) (*storage.Signup, error) {
+	event, err := storage.Store.GetEvent(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("Evento no encontrado")
+	}
+
+	for _, list := range []map[string][]storage.Signup{event.Signups, event.Waitlist} {
+		for _, signups := range list {
+			for _, signup := range signups {
+				if signup.UserID == userID {
+					return nil, nil
+				}
+			}
+		}
+	}
+
+	role := ""
+	if character, ok := mainCharacter(userID); ok && hasRole(event, character.Role) && !IsRoleFull(event, character.Role) {
+		role = character.Role
+	}
+	for _, r := range event.Roles {
+		if role == "" && !IsRoleFull(event, r.Name) {
+			role = r.Name
+		}
+	}
+	if role == "" {
+		return nil, fmt.Errorf("No quedan lugares libres en el evento")
+	}
+
+	signup, err := storage.Store.AddInterestedSignup(eventID, userID, username, role)
+	if err != nil {
+		return nil, fmt.Errorf("Error procesando inscripción")
+	}
+	return signup, nil
+}
+
+// RemoveInterested quita la inscripción pendiente que vino de "me interesa".
+// Las que un oficial ya confirmó se mantienen. Si se liberó un lugar
+// promueve a la banca y devuelve los promovidos; devuelve un evento nil si
+// no había nada que quitar.
+func RemoveInterested(eventID, userID string) (*storage.Event, []storage.Signup, error) {
+	event, err := storage.Store.GetEvent(eventID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Evento no encontrado")
+	}
+
+	var freedRoles []string
+	for role, signups := range event.Signups {
+		for _, signup := range signups {
+			if signup.UserID == userID && signup.Interested && signup.Status == "pending" {
+				freedRoles = append(freedRoles, role)
+				break
+			}
+		}
+	}
+	if len(freedRoles) == 0 {
+		return nil, nil, nil
+	}
+
+	var promoted []storage.Signup
+	for _, role := range freedRoles {
+		if err := storage.Store.RemoveSignup(eventID, userID, role); err != nil {
+			return nil, nil, fmt.Errorf("Error cancelando inscripción")
+		}
+		promoted = append(promoted, fillFromWaitlist(event, role)...)
+	}
+	return event, promoted, nil
+}
+
+// ApproveSignup confirma una inscripción pendiente respetando los límites de rol y clase.
+func ApproveSignup(input ReviewInput) (*storage.Event, error) {
+	event, err := storage.Store.GetEvent(input.EventID)
+	if err != nil {
+		return nil, fmt.Errorf("Evento no encontrado")
+	}
+
+	signup, err := findPendingSignup(event, input.Role, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// La inscripción pendiente ya ocupa su lugar: solo se frena si el rol o
+	// la clase quedaron con más ocupados que el límite
+	if limit := roleLimit(event, input.Role); limit > 0 && OccupiedSlots(event, input.Role) > limit {
+		return nil, fmt.Errorf("El rol %s ya está lleno", input.Role)
+	}
+	if limit := classLimit(event, input.Role, signup.Class); signup.Class != "" && limit > 0 && OccupiedClassSlots(event, input.Role, signup.Class) > limit {
+		return nil, fmt.Errorf("La clase %s ya está llena en el rol %s", signup.Class, input.Role)
+	}
+
+	if err := storage.Store.ConfirmSignup(input.EventID, input.UserID, input.Role, input.ReviewerID); err != nil {
+		return nil, fmt.Errorf("Error confirmando inscripción")
+	}
+
+	return event, nil
+}
+
+// DeclineSignup rechaza una inscripción pendiente.
+func DeclineSignup(input ReviewInput) (*storage.Event, error) {
+	event, err := storage.Store.GetEvent(input.EventID)
+	if err != nil {
+		return nil, fmt.Errorf("Evento no encontrado")
+	}
+
+	if _, err := findPendingSignup(event, input.Role, input.UserID); err != nil {
+		return nil, err
+	}
+
+	if err := storage.Store.DeclineSignup(input.EventID, input.UserID, input.Role, input.ReviewerID); err != nil {
+		return nil, fmt.Errorf("Error rechazando inscripción")
+	}
+
+	return event, nil
+}
+
+// FindSignup busca la inscripción de un usuario en un rol
+func FindSignup(event *storage.Event, role, userID string) (storage.Signup, bool) {
+	for _, signup := range event.Signups[role] {
+		if signup.UserID == userID {
+			return signup, true
+		}
+	}
+	return storage.Signup{}, false
+}
+
+func findPendingSignup(event *storage.Event, role, userID string) (storage.Signup, error) {
+	signup, ok := FindSignup(event, role, userID)
+	if !ok {
+		return storage.Signup{}, fmt.Errorf("Inscripción no encontrada")
+	}
+	if signup.Status != "pending" {
+		return storage.Signup{}, fmt.Errorf("La inscripción ya fue revisada (%s)", signup.Status)
+	}
+	return signup, nil
+}
+
+// CanJoinWaitlist indica si se puede esperar en la banca de un rol: el rol
+// está lleno o lo está la clase elegida.
+func CanJoinWaitlist(event *storage.Event, role, class string) bool {
+	return IsRoleFull(event, role) || (class != "" && IsClassFull(event, role, class))
+}
+
+// FullRoles devuelve los roles del evento que ya alcanzaron su límite.
+func FullRoles(event *storage.Event) []storage.RoleSignup {
+	var full []storage.RoleSignup
+	for _, role := range event.Roles {
+		if IsRoleFull(event, role.Name) {
+			full = append(full, role)
+		}
+	}
+	return full
+}
+
+// RoleGap indica cuántos lugares libres quedan en un rol con límite
+type RoleGap struct {
+	Role    storage.RoleSignup
+	Missing int
+}
+
+// MissingSlots devuelve los roles con límite que todavía tienen lugares
+// libres, en el orden del evento. Los roles sin límite no cuentan.
+func MissingSlots(event *storage.Event) []RoleGap {
+	var gaps []RoleGap
+	for _, role := range event.Roles {
+		if role.Limit <= 0 {
+			continue
+		}
+		if missing := role.Limit - OccupiedSlots(event, role.Name); missing > 0 {
+			gaps = append(gaps, RoleGap{Role: role, Missing: missing})
+		}
+	}
+	return gaps
+}
+
+// IsRoleFull indica si un rol alcanzó su límite. Cuentan los lugares
+// ocupados (OccupiedSlots): confirmados y pendientes de aprobación.
+func IsRoleFull(event *storage.Event, role string) bool {
+	limit := roleLimit(event, role)
+	return limit > 0 && OccupiedSlots(event, role) >= limit
+}
+
+// IsClassFull indica si una clase alcanzó su límite dentro de un rol,
+// contando confirmados y pendientes como IsRoleFull.
+func IsClassFull(event *storage.Event, role, class string) bool {
+	limit := classLimit(event, role, class)
+	return limit > 0 && OccupiedClassSlots(event, role, class) >= limit
+}
+
+// fillFromWaitlist promueve usuarios de la banca mientras el rol tenga lugares libres.
+// Se saltea a quienes esperan por una clase que sigue llena. En eventos con
+// aprobación los promovidos quedan pendientes y también ocupan su lugar.
+func fillFromWaitlist(event *storage.Event, role string) []storage.Signup {
+	var promoted []storage.Signup
+	for !IsRoleFull(event, role) {
+		next := ""
+		for _, waiting := range event.Waitlist[role] {
+			if waiting.Class == "" || !IsClassFull(event, role, waiting.Class) {
+				next = waiting.UserID
+				break
+			}
+		}
+		if next == "" {
+			break
+		}
+
+		signup, err := storage.Store.PromoteFromWaitlist(event.ID, role, next)
+		if err != nil || signup == nil {
+			break
+		}
+		promoted = append(promoted, *signup)
+	}
+	return promoted
+}
+
+// FillOpenSlots promueve de la banca a quienes entren en los lugares libres
+// de cada rol, por ejemplo después de subir el límite de un rol.
+func FillOpenSlots(event *storage.Event) []storage.Signup {
+	var promoted []storage.Signup
+	for _, role := range event.Roles {
+		promoted = append(promoted, fillFromWaitlist(event, role.Name)...)
+	}
+	return promoted
+}
+
+// OccupiedSlots cuenta los lugares ocupados de un rol: las inscripciones
+// confirmadas y las pendientes de aprobación. Es la definición que usan
+// todos los límites de rol y clase.
+func OccupiedSlots(event *storage.Event, role string) int {
+	occupied := 0
+	for _, signup := range event.Signups[role] {
+		if occupiesSlot(signup) {
+			occupied++
+		}
+	}
+	return occupied
+}
+
+// OccupiedClassSlots cuenta los lugares ocupados por una clase de un rol,
+// con el mismo criterio que OccupiedSlots
+func OccupiedClassSlots(event *storage.Event, role, class string) int {
+	occupied := 0
+	for _, signup := range event.Signups[role] {
+		if occupiesSlot(signup) && signup.Class == class {
+			occupied++
+		}
+	}
+	return occupied
+}
+
+func occupiesSlot(signup storage.Signup) bool {
+	return signup.Status == "confirmed" || signup.Status == "pending"
+}
+
+// checkSignupTarget verifica que el evento siga activo y que el rol y la
+// clase existan. Si el rol define clases, la clase tiene que ser una de
+// ellas; se devuelve con el nombre tal como está definida en el rol.
+func checkSignupTarget(event *storage.Event, role, class string) (string, error) {
+	if event.Status != "active" {
+		return "", fmt.Errorf("El evento ya no acepta inscripciones")
+	}
+
+	for _, r := range event.Roles {
+		if r.Name != role {
+			continue
+		}
+		if class == "" || len(r.Classes) == 0 {
+			return class, nil
+		}
+		for _, c := range r.Classes {
+			if strings.EqualFold(c.Name, class) {
+				return c.Name, nil
+			}
+		}
+		return "", fmt.Errorf("La clase %s no existe en el rol %s", class, role)
+	}
+	return "", fmt.Errorf("El rol %s no existe en este evento", role)
+}
+
+// checkNotSignedUp verifica que el usuario no esté ya inscrito. Una
+// inscripción rechazada también lo impide a propósito: la decisión del
+// oficial se conserva como registro y el jugador no puede volver a
+// inscribirse en el mismo evento, ni siquiera en otro rol.
+func checkNotSignedUp(event *storage.Event, input SignupInput) error {
 	for r, signups := range event.Signups {
 		for _, signup := range signups {
 			if signup.UserID == input.UserID {
+				if signup.Status == "declined" {
+					return fmt.Errorf("Tu inscripción a este evento fue rechazada por un oficial")
+				}
 				if r == input.Role {
-					return nil, fmt.Errorf("Ya estás inscrito en este rol")
+					return fmt.Errorf("Ya estás inscrito en este rol")
 				}
 				if !event.AllowMultiSignup {
-					return nil, fmt.Errorf("Ya estás inscrito en otro rol. Cancela primero tu inscripción actual.")
+					return fmt.Errorf("Ya estás inscrito en otro rol. Cancela primero tu inscripción actual.")
 				}
 			}
 		}
 	}
+	return nil
+}
 
-	// Verificar límite de rol
-	confirmedCount := 0
-	for _, signup := range event.Signups[input.Role] {
-		if signup.Status == "confirmed" {
-			confirmedCount++
+// CheckRequirements verifica que el jugador cumpla los requisitos del evento.
+// Si no se indica personaje, el item level se toma del main del perfil.
+func CheckRequirements(event *storage.Event, input SignupInput, character *storage.Character) error {
+	req := event.Requirements
+	if req.IsEmpty() {
+		return nil
+	}
+
+	if len(req.RequiredRoles) > 0 && !hasAnyRole(input.MemberRoles, req.RequiredRoles) {
+		return fmt.Errorf("Para inscribirte en este evento necesitas uno de estos roles: %s", roleMentions(req.RequiredRoles))
+	}
+	for _, roleID := range req.ForbiddenRoles {
+		if hasAnyRole(input.MemberRoles, []string{roleID}) {
+			return fmt.Errorf("No puedes inscribirte en este evento con el rol <@&%s>", roleID)
 		}
 	}
 
-	var roleLimit int
-	for _, r := range event.Roles {
-		if r.Name == input.Role {
-			roleLimit = r.Limit
-			break
+	if req.MinItemLevel > 0 {
+		if character == nil {
+			main, ok := mainCharacter(input.UserID)
+			if !ok {
+				return fmt.Errorf("Este evento requiere item level %d. Registra tu personaje con `/profile add` indicando su item_level", req.MinItemLevel)
+			}
+			character = &main
+		}
+		if character.ItemLevel < req.MinItemLevel {
+			return fmt.Errorf("Este evento requiere item level %d y %s tiene %d. Actualízalo con `/profile add` si subió", req.MinItemLevel, character.Name, character.ItemLevel)
 		}
 	}
 
-	if roleLimit > 0 && confirmedCount >= roleLimit {
-		return nil, fmt.Errorf("El rol %s ya está lleno", input.Role)
+	return nil
+}
+
+// DescribeRequirements devuelve los requisitos en una línea por condición
+func DescribeRequirements(req *storage.SignupRequirements) string {
+	if req.IsEmpty() {
+		return ""
 	}
 
-	// Agregar inscripción (con clase si aplica)
-	if input.Class != "" {
-		if err := storage.Store.AddSignupWithClass(input.EventID, input.UserID, input.Username, input.Role, input.Class); err != nil {
-			return nil, fmt.Errorf("Error procesando inscripción")
-		}
-	} else {
-		if err := storage.Store.AddSignup(input.EventID, input.UserID, input.Username, input.Role); err != nil {
-			return nil, fmt.Errorf("Error procesando inscripción")
+	var lines []string
+	if len(req.RequiredRoles) > 0 {
+		lines = append(lines, "Rol requerido: "+roleMentions(req.RequiredRoles))
+	}
+	if len(req.ForbiddenRoles) > 0 {
+		lines = append(lines, "Rol excluido: "+roleMentions(req.ForbiddenRoles))
+	}
+	if req.MinItemLevel > 0 {
+		lines = append(lines, fmt.Sprintf("Item level mínimo: %d", req.MinItemLevel))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func hasAnyRole(memberRoles, roles []string) bool {
+	for _, have := range memberRoles {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
 		}
 	}
+	return false
+}
 
-	// El puntero event apunta a la misma instancia que se actualiza en el store
-	return event, nil
+func roleMentions(roleIDs []string) string {
+	mentions := make([]string, 0, len(roleIDs))
+	for _, roleID := range roleIDs {
+		mentions = append(mentions, fmt.Sprintf("<@&%s>", roleID))
+	}
+	return strings.Join(mentions, ", ")
 }
 
-// CancelSignup aplica las reglas de negocio para cancelar la inscripción de un usuario.
-func CancelSignup(input CancelInput) (*storage.Event, error) {
-	event, err := storage.Store.GetEvent(input.EventID)
+func mainCharacter(userID string) (storage.Character, bool) {
+	profile, err := storage.Profiles.GetProfile(userID)
 	if err != nil {
-		return nil, fmt.Errorf("Evento no encontrado")
+		return storage.Character{}, false
+	}
+	for _, character := range profile.Characters {
+		if character.Main {
+			return character, true
+		}
 	}
+	return storage.Character{}, false
+}
 
-	userID := input.UserID
-	removed := false
+// classForCharacter devuelve la clase con la que se inscribe un personaje.
+// Si el rol tiene clases definidas, la del personaje tiene que ser una de ellas.
+func classForCharacter(event *storage.Event, role string, character storage.Character) (string, error) {
+	for _, r := range event.Roles {
+		if r.Name != role || len(r.Classes) == 0 {
+			continue
+		}
+		for _, class := range r.Classes {
+			if strings.EqualFold(class.Name, character.Class) {
+				return class.Name, nil
+			}
+		}
+		return "", fmt.Errorf("Tu personaje %s es %s, que no es una clase del rol %s", character.Name, character.Class, role)
+	}
+	return character.Class, nil
+}
 
-	for role := range event.Signups {
-		if err := storage.Store.RemoveSignup(input.EventID, userID, role); err == nil {
-			removed = true
+func hasRole(event *storage.Event, role string) bool {
+	for _, r := range event.Roles {
+		if r.Name == role {
+			return true
 		}
 	}
+	return false
+}
 
-	if !removed {
-		return nil, fmt.Errorf("No estás inscrito en este evento")
+func roleLimit(event *storage.Event, role string) int {
+	for _, r := range event.Roles {
+		if r.Name == role {
+			return r.Limit
+		}
 	}
+	return 0
+}
 
-	return event, nil
+func classLimit(event *storage.Event, role, class string) int {
+	for _, r := range event.Roles {
+		if r.Name != role {
+			continue
+		}
+		for _, c := range r.Classes {
+			if c.Name == class {
+				return c.Limit
+			}
+		}
+	}
+	return 0
 }
+
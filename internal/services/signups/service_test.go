@@ -0,0 +1,308 @@
+package signups
+
+import (
+	"discord-event-bot/internal/storage"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Los servicios usan el store global: las pruebas lo inicializan sobre un
+// directorio temporal para no tocar data/ del repositorio
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "signups-test")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		log.Fatal(err)
+	}
+	if err := storage.InitBackend("json", ""); err != nil {
+		log.Fatal(err)
+	}
+	if err := storage.InitEventStore(); err != nil {
+		log.Fatal(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// testEvent arma un evento activo con un rol Tank de 2 lugares (clases
+// Guerrero y Paladín de 1 lugar cada una) y un rol DPS sin límite
+func testEvent(t *testing.T, requireApproval bool, signups []storage.Signup, waitlist []storage.Signup) *storage.Event {
+	t.Helper()
+	event := &storage.Event{
+		ID:              strings.NewReplacer("/", "-", " ", "-").Replace(t.Name()),
+		Name:            "Raid",
+		DateTime:        time.Now().Add(24 * time.Hour),
+		Status:          "active",
+		RequireApproval: requireApproval,
+		Roles: []storage.RoleSignup{
+			{Name: "Tank", Limit: 2, Classes: []storage.ClassInfo{{Name: "Guerrero", Limit: 1}, {Name: "Paladín", Limit: 1}}},
+			{Name: "DPS"},
+		},
+		Signups:  make(map[string][]storage.Signup),
+		Waitlist: make(map[string][]storage.Signup),
+	}
+	for _, signup := range signups {
+		event.Signups[signup.Role] = append(event.Signups[signup.Role], signup)
+	}
+	for _, waiting := range waitlist {
+		event.Waitlist[waiting.Role] = append(event.Waitlist[waiting.Role], waiting)
+	}
+	if err := storage.Store.SaveEvent(event); err != nil {
+		t.Fatalf("guardando evento: %v", err)
+	}
+	return event
+}
+
+func tank(userID, class, status string) storage.Signup {
+	return storage.Signup{UserID: userID, Username: userID, Role: "Tank", Class: class, Status: status, SignedUpAt: time.Now()}
+}
+
+func TestCapacity(t *testing.T) {
+	tests := []struct {
+		name         string
+		signups      []storage.Signup
+		wantOccupied int
+		wantRoleFull bool
+		wantWarrior  bool // Guerrero lleno
+	}{
+		{name: "vacío"},
+		{
+			name:         "un confirmado",
+			signups:      []storage.Signup{tank("1", "Guerrero", "confirmed")},
+			wantOccupied: 1,
+			wantWarrior:  true,
+		},
+		{
+			name:         "los pendientes ocupan lugar",
+			signups:      []storage.Signup{tank("1", "Guerrero", "pending"), tank("2", "Paladín", "pending")},
+			wantOccupied: 2,
+			wantRoleFull: true,
+			wantWarrior:  true,
+		},
+		{
+			name:         "los rechazados no ocupan lugar",
+			signups:      []storage.Signup{tank("1", "Guerrero", "declined"), tank("2", "Paladín", "confirmed")},
+			wantOccupied: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := testEvent(t, false, tt.signups, nil)
+
+			if got := OccupiedSlots(event, "Tank"); got != tt.wantOccupied {
+				t.Errorf("OccupiedSlots = %d, se esperaba %d", got, tt.wantOccupied)
+			}
+			if got := IsRoleFull(event, "Tank"); got != tt.wantRoleFull {
+				t.Errorf("IsRoleFull = %v, se esperaba %v", got, tt.wantRoleFull)
+			}
+			if got := IsClassFull(event, "Tank", "Guerrero"); got != tt.wantWarrior {
+				t.Errorf("IsClassFull(Guerrero) = %v, se esperaba %v", got, tt.wantWarrior)
+			}
+			if IsRoleFull(event, "DPS") {
+				t.Error("un rol sin límite nunca está lleno")
+			}
+			if got, want := CanJoinWaitlist(event, "Tank", "Guerrero"), tt.wantRoleFull || tt.wantWarrior; got != want {
+				t.Errorf("CanJoinWaitlist(Guerrero) = %v, se esperaba %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSignupToEvent(t *testing.T) {
+	tests := []struct {
+		name       string
+		signups    []storage.Signup
+		approval   bool
+		input      SignupInput
+		wantErr    string
+		wantStatus string
+	}{
+		{
+			name:       "confirma directo sin aprobación",
+			input:      SignupInput{UserID: "9", Role: "Tank", Class: "guerrero"},
+			wantStatus: "confirmed",
+		},
+		{
+			name:       "queda pendiente con aprobación",
+			approval:   true,
+			input:      SignupInput{UserID: "9", Role: "Tank", Class: "Guerrero"},
+			wantStatus: "pending",
+		},
+		{
+			name:    "rol lleno con un pendiente",
+			signups: []storage.Signup{tank("1", "Guerrero", "confirmed"), tank("2", "Paladín", "pending")},
+			input:   SignupInput{UserID: "9", Role: "Tank"},
+			wantErr: "ya está lleno",
+		},
+		{
+			name:    "clase llena",
+			signups: []storage.Signup{tank("1", "Guerrero", "pending")},
+			input:   SignupInput{UserID: "9", Role: "Tank", Class: "Guerrero"},
+			wantErr: "La clase Guerrero ya está llena",
+		},
+		{
+			name:    "rol inexistente",
+			input:   SignupInput{UserID: "9", Role: "Healer"},
+			wantErr: "no existe",
+		},
+		{
+			name:    "clase que no es del rol",
+			input:   SignupInput{UserID: "9", Role: "Tank", Class: "Mago"},
+			wantErr: "no existe",
+		},
+		{
+			name:    "ya inscrito",
+			signups: []storage.Signup{tank("9", "Guerrero", "confirmed")},
+			input:   SignupInput{UserID: "9", Role: "DPS"},
+			wantErr: "inscrito",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := testEvent(t, tt.approval, tt.signups, nil)
+			tt.input.EventID = event.ID
+			tt.input.Username = tt.input.UserID
+
+			_, err := SignupToEvent(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, se esperaba %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SignupToEvent: %v", err)
+			}
+
+			signup, ok := FindSignup(event, tt.input.Role, tt.input.UserID)
+			if !ok || signup.Status != tt.wantStatus {
+				t.Fatalf("inscripción = %+v, se esperaba estado %s", signup, tt.wantStatus)
+			}
+			if tt.input.Class != "" && signup.Class != "Guerrero" {
+				t.Errorf("clase = %q, se esperaba el nombre canónico Guerrero", signup.Class)
+			}
+		})
+	}
+}
+
+func TestCancelSignupPromotes(t *testing.T) {
+	tests := []struct {
+		name         string
+		approval     bool
+		signups      []storage.Signup
+		waitlist     []storage.Signup
+		cancel       string
+		wantPromoted []string
+		wantStatus   string
+	}{
+		{
+			name:         "promueve al primero de la banca",
+			signups:      []storage.Signup{tank("1", "Guerrero", "confirmed"), tank("2", "Paladín", "confirmed")},
+			waitlist:     []storage.Signup{tank("3", "", "waitlisted"), tank("4", "", "waitlisted")},
+			cancel:       "1",
+			wantPromoted: []string{"3"},
+			wantStatus:   "confirmed",
+		},
+		{
+			name:         "saltea a quien espera una clase llena",
+			signups:      []storage.Signup{tank("1", "Guerrero", "confirmed"), tank("2", "Paladín", "confirmed")},
+			waitlist:     []storage.Signup{tank("3", "Paladín", "waitlisted"), tank("4", "Guerrero", "waitlisted")},
+			cancel:       "1",
+			wantPromoted: []string{"4"},
+			wantStatus:   "confirmed",
+		},
+		{
+			name:         "con aprobación el promovido queda pendiente",
+			approval:     true,
+			signups:      []storage.Signup{tank("1", "Guerrero", "confirmed"), tank("2", "Paladín", "pending")},
+			waitlist:     []storage.Signup{tank("3", "", "waitlisted")},
+			cancel:       "1",
+			wantPromoted: []string{"3"},
+			wantStatus:   "pending",
+		},
+		{
+			name:     "salir de la banca no promueve a nadie",
+			signups:  []storage.Signup{tank("1", "Guerrero", "confirmed"), tank("2", "Paladín", "confirmed"), tank("5", "", "declined")},
+			waitlist: []storage.Signup{tank("3", "", "waitlisted")},
+			cancel:   "3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := testEvent(t, tt.approval, tt.signups, tt.waitlist)
+
+			_, promoted, err := CancelSignup(CancelInput{EventID: event.ID, UserID: tt.cancel})
+			if err != nil {
+				t.Fatalf("CancelSignup: %v", err)
+			}
+
+			var ids []string
+			for _, signup := range promoted {
+				ids = append(ids, signup.UserID)
+				if signup.Status != tt.wantStatus {
+					t.Errorf("promovido %s con estado %s, se esperaba %s", signup.UserID, signup.Status, tt.wantStatus)
+				}
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.wantPromoted) {
+				t.Errorf("promovidos = %v, se esperaba %v", ids, tt.wantPromoted)
+			}
+			if OccupiedSlots(event, "Tank") > 2 {
+				t.Errorf("el rol quedó con %d lugares ocupados, el límite es 2", OccupiedSlots(event, "Tank"))
+			}
+		})
+	}
+}
+
+func TestApproveSignupAtLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		signups []storage.Signup
+		approve string
+		wantErr bool
+	}{
+		{
+			name:    "el pendiente ya ocupa su lugar",
+			signups: []storage.Signup{tank("1", "Guerrero", "confirmed"), tank("2", "Paladín", "pending")},
+			approve: "2",
+		},
+		{
+			name:    "rol sobrevendido",
+			signups: []storage.Signup{tank("1", "Guerrero", "confirmed"), tank("2", "Paladín", "confirmed"), tank("3", "", "pending")},
+			approve: "3",
+			wantErr: true,
+		},
+		{
+			name:    "clase sobrevendida",
+			signups: []storage.Signup{tank("1", "Guerrero", "confirmed"), tank("2", "Guerrero", "pending")},
+			approve: "2",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := testEvent(t, true, tt.signups, nil)
+
+			_, err := ApproveSignup(ReviewInput{EventID: event.ID, UserID: tt.approve, Role: "Tank", ReviewerID: "officer"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApproveSignup error = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				if signup, _ := FindSignup(event, "Tank", tt.approve); signup.Status != "confirmed" {
+					t.Errorf("estado = %s, se esperaba confirmed", signup.Status)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package stats
+
+import (
+	"discord-event-bot/internal/services/attendance"
+	"discord-event-bot/internal/services/guilds"
+	"discord-event-bot/internal/storage"
+	"sort"
+	"time"
+)
+
+// UserStats resume el historial de un jugador en todos los eventos guardados.
+// Los contadores son por evento: inscribirse en dos roles del mismo evento
+// cuenta como una sola inscripción.
+type UserStats struct {
+	UserID            string         `json:"user_id"`
+	Username          string         `json:"username"`
+	Signups           int            `json:"signups"`
+	Cancellations     int            `json:"cancellations"`
+	LateCancellations int            `json:"late_cancellations"`
+	LateFills         int            `json:"late_fills"` // inscripciones en respuesta al llamado a completar
+	Present           int            `json:"present"`
+	Late              int            `json:"late"`
+	NoShow            int            `json:"no_show"`
+	FavouriteRole     string         `json:"favourite_role,omitempty"`
+	FavouriteClass    string         `json:"favourite_class,omitempty"`
+	RoleCounts        map[string]int `json:"role_counts"`
+	ClassCounts       map[string]int `json:"class_counts,omitempty"`
+	LastSignupAt      time.Time      `json:"last_signup_at"`
+
+	// Commitments son los compromisos cerrados: asistencias marcadas más
+	// cancelaciones tardías. Reliability es el porcentaje cumplido de ellos.
+	Commitments int `json:"commitments"`
+	Reliability int `json:"reliability"`
+}
+
+// Compute agrega las estadísticas de todos los usuarios en los eventos del
+// servidor y las devuelve ordenadas como tabla de posiciones: más confiables
+// primero y, a igual confiabilidad, los que más se inscribieron
+func Compute(guildID string) []UserStats {
+	byUser := make(map[string]*UserStats)
+	get := func(userID, username string) *UserStats {
+		st, ok := byUser[userID]
+		if !ok {
+			st = &UserStats{
+				UserID:      userID,
+				RoleCounts:  make(map[string]int),
+				ClassCounts: make(map[string]int),
+			}
+			byUser[userID] = st
+		}
+		if username != "" {
+			st.Username = username
+		}
+		return st
+	}
+
+	events := guilds.FilterEvents(storage.Store.GetAllEvents(), guildID)
+	// Recorrer en orden cronológico para quedarse con el nombre más reciente
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].DateTime.Before(events[j].DateTime)
+	})
+
+	for _, event := range events {
+		// Los eventos cancelados por la organización no cuentan
+		if event.Status == "cancelled" {
+			continue
+		}
+
+		signedUp := make(map[string]bool)
+		lateFilled := make(map[string]bool)
+		for _, signups := range event.Signups {
+			for _, signup := range signups {
+				if signup.Status == "declined" {
+					continue
+				}
+				st := get(signup.UserID, signup.Username)
+				st.RoleCounts[signup.Role]++
+				if signup.Class != "" {
+					st.ClassCounts[signup.Class]++
+				}
+				if signup.SignedUpAt.After(st.LastSignupAt) {
+					st.LastSignupAt = signup.SignedUpAt
+				}
+				signedUp[signup.UserID] = true
+				if signup.LateFill {
+					lateFilled[signup.UserID] = true
+				}
+			}
+		}
+
+		cancelled := make(map[string]bool)
+		lateCancelled := make(map[string]bool)
+		for _, cancellation := range event.Cancellations {
+			st := get(cancellation.UserID, cancellation.Username)
+			if cancellation.SignedUpAt.After(st.LastSignupAt) {
+				st.LastSignupAt = cancellation.SignedUpAt
+			}
+			cancelled[cancellation.UserID] = true
+			if cancellation.Late {
+				lateCancelled[cancellation.UserID] = true
+			}
+		}
+
+		for userID := range signedUp {
+			byUser[userID].Signups++
+		}
+		for userID := range cancelled {
+			// Quien canceló y se volvió a inscribir ya está contado
+			if !signedUp[userID] {
+				byUser[userID].Signups++
+			}
+			byUser[userID].Cancellations++
+		}
+		for userID := range lateCancelled {
+			byUser[userID].LateCancellations++
+		}
+		for userID := range lateFilled {
+			byUser[userID].LateFills++
+		}
+
+		for _, entry := range attendance.Entries(event) {
+			st := byUser[entry.UserID]
+			switch entry.Attendance {
+			case attendance.Present:
+				st.Present++
+			case attendance.Late:
+				st.Late++
+			case attendance.NoShow:
+				st.NoShow++
+			}
+		}
+	}
+
+	result := make([]UserStats, 0, len(byUser))
+	for _, st := range byUser {
+		st.FavouriteRole = favourite(st.RoleCounts)
+		st.FavouriteClass = favourite(st.ClassCounts)
+		st.Commitments = st.Present + st.Late + st.NoShow + st.LateCancellations
+		if st.Commitments > 0 {
+			st.Reliability = (st.Present + st.Late) * 100 / st.Commitments
+		}
+		result = append(result, *st)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if (a.Commitments > 0) != (b.Commitments > 0) {
+			return a.Commitments > 0
+		}
+		if a.Reliability != b.Reliability {
+			return a.Reliability > b.Reliability
+		}
+		if a.Signups != b.Signups {
+			return a.Signups > b.Signups
+		}
+		return a.Username < b.Username
+	})
+
+	return result
+}
+
+// ForUser devuelve las estadísticas de un usuario en el servidor
+func ForUser(guildID, userID string) (UserStats, bool) {
+	for _, st := range Compute(guildID) {
+		if st.UserID == userID {
+			return st, true
+		}
+	}
+	return UserStats{}, false
+}
+
+// favourite devuelve la clave con más apariciones; a igualdad, la primera alfabéticamente
+func favourite(counts map[string]int) string {
+	best := ""
+	for key, count := range counts {
+		if count > counts[best] || (count == counts[best] && best != "" && key < best) {
+			best = key
+		}
+	}
+	return best
+}
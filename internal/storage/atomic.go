@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// writeFileAtomic escribe un archivo de forma segura ante cortes de luz:
+// escribe en un temporal del mismo directorio, hace fsync y lo renombra
+// sobre el destino. Un lector nunca ve un archivo a medio escribir.
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(filename)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("error creando archivo temporal: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	// Si algo falla antes del rename, no dejar basura en el directorio
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmpName)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		cleanup()
+		return fmt.Errorf("error escribiendo archivo temporal: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		cleanup()
+		return fmt.Errorf("error sincronizando archivo temporal: %w", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		cleanup()
+		return fmt.Errorf("error ajustando permisos: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("error cerrando archivo temporal: %w", err)
+	}
+
+	if err := os.Rename(tmpName, filename); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("error reemplazando archivo: %w", err)
+	}
+
+	return syncDir(dir)
+}
+
+// WriteFileAtomic expone writeFileAtomic a otros paquetes, por ejemplo para
+// guardar backups
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	return writeFileAtomic(filename, data, perm)
+}
+
+// syncDir persiste la entrada de directorio creada por el rename
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("error abriendo directorio: %w", err)
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("error sincronizando directorio: %w", err)
+	}
+	return nil
+}
+
+// isTempFile indica si un archivo es un temporal abandonado por writeFileAtomic
+func isTempFile(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.Contains(name, ".tmp-")
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"fmt"
+	"log"
+)
+
+// Formatos de archivo soportados para templates
+const (
+	TemplateFormatJSON = "json"
+	TemplateFormatYAML = "yaml"
+)
+
+// Backend abstrae dónde se persisten los datos del bot. Los stores
+// mantienen su caché en memoria y delegan la escritura en el backend.
+type Backend interface {
+	LoadEvents() ([]*Event, error)
+	SaveEvent(event *Event) error
+	DeleteEvent(id string) error
+
+	LoadTemplates() ([]*EventTemplate, error)
+	SaveTemplate(template *EventTemplate, format string) error
+	DeleteTemplate(template *EventTemplate) error
+
+	LoadTokens() ([]*APIToken, error)
+	SaveToken(token *APIToken) error
+
+	LoadProfiles() ([]*Profile, error)
+	SaveProfile(profile *Profile) error
+
+	LoadNotificationPrefs() ([]*NotificationPrefs, error)
+	SaveNotificationPrefs(prefs *NotificationPrefs) error
+
+	LoadGuildSettings() ([]*GuildSettings, error)
+	SaveGuildSettings(settings *GuildSettings) error
+
+	LoadCalendarFeeds() ([]*CalendarFeed, error)
+	SaveCalendarFeed(feed *CalendarFeed) error
+
+	// ReplaceAll reemplaza todos los datos por los de un snapshot. Si
+	// falla, los datos anteriores quedan intactos.
+	ReplaceAll(data *SnapshotData) error
+
+	Close() error
+}
+
+var backend Backend
+
+// InitBackend selecciona el backend de almacenamiento ("json" o "sqlite").
+// Debe llamarse antes de inicializar los stores.
+func InitBackend(kind, sqlitePath string) error {
+	switch kind {
+	case "", "json":
+		jsonBackend, err := NewJSONBackend(eventsDir, templatesDir)
+		if err != nil {
+			return err
+		}
+		backend = jsonBackend
+	case "sqlite":
+		sqliteBackend, err := NewSQLiteBackend(sqlitePath)
+		if err != nil {
+			return err
+		}
+		backend = sqliteBackend
+	default:
+		return fmt.Errorf("backend de almacenamiento desconocido: %s", kind)
+	}
+
+	log.Printf("✅ Backend de almacenamiento: %s", kindOrDefault(kind))
+	return nil
+}
+
+// CloseBackend libera los recursos del backend activo
+func CloseBackend() {
+	if backend == nil {
+		return
+	}
+	if err := backend.Close(); err != nil {
+		log.Printf("Error cerrando backend de almacenamiento: %v", err)
+	}
+}
+
+// RecoveredFiles devuelve los eventos que el backend restauró desde su copia
+// .bak al iniciar. Solo el backend JSON guarda copias.
+func RecoveredFiles() []string {
+	if jsonBackend, ok := backend.(*JSONBackend); ok {
+		return jsonBackend.RecoveredFiles()
+	}
+	return nil
+}
+
+// activeBackend devuelve el backend configurado, usando JSON si nadie lo inicializó
+func activeBackend() (Backend, error) {
+	if backend == nil {
+		jsonBackend, err := NewJSONBackend(eventsDir, templatesDir)
+		if err != nil {
+			return nil, err
+		}
+		backend = jsonBackend
+	}
+	return backend, nil
+}
+
+func kindOrDefault(kind string) string {
+	if kind == "" {
+		return "json"
+	}
+	return kind
+}
@@ -0,0 +1,501 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// backupSuffix es la extensión de la copia de la versión anterior de cada evento
+const backupSuffix = ".bak"
+
+// JSONBackend guarda un archivo por evento, template, token, perfil,
+// preferencia, servidor y calendario en disco. Las escrituras son atómicas
+// y cada evento conserva una copia .bak de su versión anterior para
+// recuperarse de archivos truncados.
+type JSONBackend struct {
+	eventsDir    string
+	templatesDir string
+	recovered    []string
+}
+
+// jsonDataDir es un directorio de datos y su carpeta en los snapshots
+type jsonDataDir struct {
+	snapshot string
+	dir      string
+	perm     os.FileMode
+}
+
+// NewJSONBackend crea el backend de archivos y sus directorios si no existen
+func NewJSONBackend(eventsDir, templatesDir string) (*JSONBackend, error) {
+	b := &JSONBackend{
+		eventsDir:    eventsDir,
+		templatesDir: templatesDir,
+	}
+
+	for _, data := range b.dataDirs() {
+		if err := os.MkdirAll(data.dir, data.perm); err != nil {
+			return nil, fmt.Errorf("error creando directorio %s: %w", data.dir, err)
+		}
+	}
+	return b, nil
+}
+
+// dataDirs lista los directorios de datos con sus permisos. Los tokens y los
+// calendarios dan acceso al bot, así que solo los lee el dueño.
+func (b *JSONBackend) dataDirs() []jsonDataDir {
+	return []jsonDataDir{
+		{snapshotEventsDir, b.eventsDir, 0755},
+		{snapshotTemplatesDir, b.templatesDir, 0755},
+		{snapshotTokensDir, tokensDir, 0700},
+		{snapshotProfilesDir, profilesDir, 0755},
+		{snapshotNotificationsDir, notificationsDir, 0755},
+		{snapshotGuildsDir, guildsDir, 0755},
+		{snapshotCalendarsDir, calendarsDir, 0700},
+	}
+}
+
+// LoadEvents lee todos los eventos desde disco. Si un archivo está dañado o
+// falta y quedó su copia .bak, se restaura desde ella y se registra en
+// RecoveredFiles.
+func (b *JSONBackend) LoadEvents() ([]*Event, error) {
+	files, err := ioutil.ReadDir(b.eventsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil // Directorio no existe aún, no es error
+		}
+		return nil, fmt.Errorf("error leyendo directorio: %w", err)
+	}
+
+	b.recovered = nil
+
+	var events []*Event
+	for _, file := range files {
+		filename := filepath.Join(b.eventsDir, file.Name())
+
+		// Temporales de una escritura interrumpida: el original sigue intacto
+		if isTempFile(file.Name()) {
+			os.Remove(filename)
+			continue
+		}
+
+		// Una copia .bak sin su archivo: se perdió el evento en un corte
+		if strings.HasSuffix(file.Name(), ".json"+backupSuffix) {
+			primary := strings.TrimSuffix(filename, backupSuffix)
+			if _, err := os.Stat(primary); !os.IsNotExist(err) {
+				continue
+			}
+			log.Printf("Falta el archivo %s, se restaura desde su backup", primary)
+			event, err := b.recoverEvent(primary)
+			if err != nil {
+				log.Printf("❌ No se pudo recuperar %s desde backup: %v", primary, err)
+				continue
+			}
+			events = append(events, event)
+			continue
+		}
+
+		if filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+
+		event, err := readEventFile(filename)
+		if err != nil {
+			log.Printf("Error parseando archivo %s: %v", filename, err)
+
+			event, err = b.recoverEvent(filename)
+			if err != nil {
+				log.Printf("❌ No se pudo recuperar %s desde backup: %v", filename, err)
+				continue
+			}
+		}
+
+		events = append(events, event)
+	}
+
+	if len(b.recovered) > 0 {
+		log.Printf("♻️ Recuperados %d eventos desde backup: %s", len(b.recovered), strings.Join(b.recovered, ", "))
+	}
+
+	return events, nil
+}
+
+// RecoveredFiles devuelve los archivos restaurados desde .bak en la última carga
+func (b *JSONBackend) RecoveredFiles() []string {
+	return append([]string(nil), b.recovered...)
+}
+
+// recoverEvent restaura un evento dañado a partir de su copia .bak
+func (b *JSONBackend) recoverEvent(filename string) (*Event, error) {
+	backupFile := filename + backupSuffix
+
+	event, err := readEventFile(backupFile)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(backupFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(filename, data, 0644); err != nil {
+		return nil, err
+	}
+
+	b.recovered = append(b.recovered, filepath.Base(filename))
+	return event, nil
+}
+
+// SaveEvent escribe el evento en su archivo de forma atómica, guardando
+// antes la versión anterior (si era válida) como .bak
+func (b *JSONBackend) SaveEvent(event *Event) error {
+	filename := filepath.Join(b.eventsDir, fmt.Sprintf("%s.json", event.ID))
+	data, err := json.MarshalIndent(event, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializando evento: %w", err)
+	}
+
+	if previous, err := ioutil.ReadFile(filename); err == nil && json.Valid(previous) {
+		if err := writeFileAtomic(filename+backupSuffix, previous, 0644); err != nil {
+			log.Printf("Error guardando backup de %s: %v", filename, err)
+		}
+	}
+
+	if err := writeFileAtomic(filename, data, 0644); err != nil {
+		return fmt.Errorf("error escribiendo archivo: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteEvent elimina el backup del evento y después su archivo: si se corta
+// a mitad de camino, el evento no vuelve a aparecer desde el backup
+func (b *JSONBackend) DeleteEvent(id string) error {
+	filename := filepath.Join(b.eventsDir, fmt.Sprintf("%s.json", id))
+	if err := os.Remove(filename + backupSuffix); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error eliminando backup: %w", err)
+	}
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error eliminando archivo: %w", err)
+	}
+	return nil
+}
+
+func readEventFile(filename string) (*Event, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var event Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// LoadTemplates lee todos los templates (JSON o YAML) desde disco
+func (b *JSONBackend) LoadTemplates() ([]*EventTemplate, error) {
+	files, err := os.ReadDir(b.templatesDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error leyendo directorio de templates: %w", err)
+	}
+
+	var templates []*EventTemplate
+	for _, file := range files {
+		ext := filepath.Ext(file.Name())
+		if ext != ".json" && ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+
+		filename := filepath.Join(b.templatesDir, file.Name())
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			log.Printf("Error leyendo archivo %s: %v", filename, err)
+			continue
+		}
+
+		var template EventTemplate
+		if ext == ".json" {
+			if err := json.Unmarshal(data, &template); err != nil {
+				log.Printf("Error parseando JSON %s: %v", filename, err)
+				continue
+			}
+		} else {
+			if err := yaml.Unmarshal(data, &template); err != nil {
+				log.Printf("Error parseando YAML %s: %v", filename, err)
+				continue
+			}
+		}
+
+		// Los templates de un servidor se guardaban solo con su nombre:
+		// se pasan al nombre con el servidor para no pisar al compartido
+		if expected := filepath.Join(b.templatesDir, templateFilename(&template)+ext); expected != filename {
+			if _, err := os.Stat(expected); os.IsNotExist(err) {
+				if err := os.Rename(filename, expected); err != nil {
+					log.Printf("Error renombrando template %s: %v", filename, err)
+				}
+			}
+		}
+
+		templates = append(templates, &template)
+	}
+
+	return templates, nil
+}
+
+// SaveTemplate escribe el template en JSON o YAML según el formato pedido
+func (b *JSONBackend) SaveTemplate(template *EventTemplate, format string) error {
+	if format == TemplateFormatYAML {
+		filename := filepath.Join(b.templatesDir, fmt.Sprintf("%s.yaml", templateFilename(template)))
+		data, err := yaml.Marshal(template)
+		if err != nil {
+			return fmt.Errorf("error serializando template a YAML: %w", err)
+		}
+
+		if err := writeFileAtomic(filename, data, 0644); err != nil {
+			return fmt.Errorf("error escribiendo archivo YAML: %w", err)
+		}
+		return nil
+	}
+
+	filename := filepath.Join(b.templatesDir, fmt.Sprintf("%s.json", templateFilename(template)))
+	data, err := json.MarshalIndent(template, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializando template: %w", err)
+	}
+
+	if err := writeFileAtomic(filename, data, 0644); err != nil {
+		return fmt.Errorf("error escribiendo archivo: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteTemplate elimina tanto la versión JSON como la YAML del template
+func (b *JSONBackend) DeleteTemplate(template *EventTemplate) error {
+	jsonFile := filepath.Join(b.templatesDir, fmt.Sprintf("%s.json", templateFilename(template)))
+	yamlFile := filepath.Join(b.templatesDir, fmt.Sprintf("%s.yaml", templateFilename(template)))
+
+	os.Remove(jsonFile)
+	os.Remove(yamlFile)
+
+	return nil
+}
+
+// LoadTokens lee todos los tokens de la API desde disco
+func (b *JSONBackend) LoadTokens() ([]*APIToken, error) {
+	var tokens []*APIToken
+	err := readRecordDir(tokensDir, "token", func(data []byte) error {
+		var token APIToken
+		if err := json.Unmarshal(data, &token); err != nil {
+			return err
+		}
+		tokens = append(tokens, &token)
+		return nil
+	})
+	return tokens, err
+}
+
+// SaveToken escribe el token; solo lo puede leer el dueño del proceso
+func (b *JSONBackend) SaveToken(token *APIToken) error {
+	return writeRecord(filepath.Join(tokensDir, token.ID+".json"), token, 0600)
+}
+
+// LoadProfiles lee todos los perfiles de jugadores desde disco
+func (b *JSONBackend) LoadProfiles() ([]*Profile, error) {
+	var profiles []*Profile
+	err := readRecordDir(profilesDir, "perfil", func(data []byte) error {
+		var profile Profile
+		if err := json.Unmarshal(data, &profile); err != nil {
+			return err
+		}
+		profiles = append(profiles, &profile)
+		return nil
+	})
+	return profiles, err
+}
+
+// SaveProfile escribe el perfil de un jugador
+func (b *JSONBackend) SaveProfile(profile *Profile) error {
+	return writeRecord(filepath.Join(profilesDir, profile.UserID+".json"), profile, 0644)
+}
+
+// LoadNotificationPrefs lee todas las preferencias de notificación desde disco
+func (b *JSONBackend) LoadNotificationPrefs() ([]*NotificationPrefs, error) {
+	var all []*NotificationPrefs
+	err := readRecordDir(notificationsDir, "preferencias", func(data []byte) error {
+		var prefs NotificationPrefs
+		if err := json.Unmarshal(data, &prefs); err != nil {
+			return err
+		}
+		all = append(all, &prefs)
+		return nil
+	})
+	return all, err
+}
+
+// SaveNotificationPrefs escribe las preferencias de un jugador
+func (b *JSONBackend) SaveNotificationPrefs(prefs *NotificationPrefs) error {
+	return writeRecord(filepath.Join(notificationsDir, prefs.UserID+".json"), prefs, 0644)
+}
+
+// LoadGuildSettings lee la configuración de todos los servidores desde disco
+func (b *JSONBackend) LoadGuildSettings() ([]*GuildSettings, error) {
+	var all []*GuildSettings
+	err := readRecordDir(guildsDir, "servidor", func(data []byte) error {
+		var settings GuildSettings
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return err
+		}
+		all = append(all, &settings)
+		return nil
+	})
+	return all, err
+}
+
+// SaveGuildSettings escribe la configuración de un servidor
+func (b *JSONBackend) SaveGuildSettings(settings *GuildSettings) error {
+	return writeRecord(filepath.Join(guildsDir, settings.GuildID+".json"), settings, 0644)
+}
+
+// LoadCalendarFeeds lee todos los accesos a calendarios desde disco
+func (b *JSONBackend) LoadCalendarFeeds() ([]*CalendarFeed, error) {
+	var feeds []*CalendarFeed
+	err := readRecordDir(calendarsDir, "calendario", func(data []byte) error {
+		var feed CalendarFeed
+		if err := json.Unmarshal(data, &feed); err != nil {
+			return err
+		}
+		feeds = append(feeds, &feed)
+		return nil
+	})
+	return feeds, err
+}
+
+// SaveCalendarFeed escribe el acceso; solo lo puede leer el dueño del proceso
+func (b *JSONBackend) SaveCalendarFeed(feed *CalendarFeed) error {
+	return writeRecord(filepath.Join(calendarsDir, calendarKey(feed.GuildID, feed.UserID)+".json"), feed, 0600)
+}
+
+// ReplaceAll reemplaza todos los datos. Primero escribe cada directorio
+// completo en uno nuevo junto al original y después los intercambia con
+// renames; si falla una escritura o un rename, se deshacen los cambios y
+// los datos anteriores quedan como estaban.
+func (b *JSONBackend) ReplaceAll(data *SnapshotData) error {
+	stamp := time.Now().Format("20060102-150405.000000000")
+	dirs := b.dataDirs()
+
+	staged := make(map[string]string, len(dirs))
+	defer func() {
+		for _, staging := range staged {
+			os.RemoveAll(staging)
+		}
+	}()
+
+	for _, d := range dirs {
+		staging := d.dir + ".restore-" + stamp
+		if err := os.MkdirAll(staging, d.perm); err != nil {
+			return fmt.Errorf("error preparando %s: %w", d.dir, err)
+		}
+		staged[d.snapshot] = staging
+	}
+
+	for _, entry := range data.entries() {
+		dir, file := filepath.Split(filepath.FromSlash(entry.name))
+		dir = filepath.Clean(dir)
+		perm := os.FileMode(0644)
+		for _, d := range dirs {
+			if d.snapshot == dir {
+				perm = d.perm &^ 0111
+			}
+		}
+		if err := writeRecord(filepath.Join(staged[dir], file), entry.record, perm); err != nil {
+			return fmt.Errorf("error escribiendo %s: %w", entry.name, err)
+		}
+	}
+
+	// Intercambio: el original pasa a .old y el nuevo ocupa su lugar
+	var swapped []jsonDataDir
+	rollback := func() {
+		for _, d := range swapped {
+			os.Rename(d.dir, staged[d.snapshot])
+			os.Rename(d.dir+".old-"+stamp, d.dir)
+		}
+	}
+	for _, d := range dirs {
+		old := d.dir + ".old-" + stamp
+		if err := os.Rename(d.dir, old); err != nil && !os.IsNotExist(err) {
+			rollback()
+			return fmt.Errorf("error reemplazando %s: %w", d.dir, err)
+		}
+		if err := os.Rename(staged[d.snapshot], d.dir); err != nil {
+			os.Rename(old, d.dir)
+			rollback()
+			return fmt.Errorf("error reemplazando %s: %w", d.dir, err)
+		}
+		swapped = append(swapped, d)
+	}
+
+	for _, d := range dirs {
+		if err := syncDir(filepath.Dir(d.dir)); err != nil {
+			log.Printf("Error sincronizando %s: %v", filepath.Dir(d.dir), err)
+		}
+		if err := os.RemoveAll(d.dir + ".old-" + stamp); err != nil {
+			log.Printf("Error eliminando datos anteriores de %s: %v", d.dir, err)
+		}
+	}
+	return nil
+}
+
+// readRecordDir pasa a decode el contenido de cada archivo .json del
+// directorio. Los archivos dañados se registran en el log y se saltean.
+func readRecordDir(dir, kind string, decode func(data []byte) error) error {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, file := range files {
+		if file.IsDir() || isTempFile(file.Name()) || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			log.Printf("Error leyendo %s %s: %v", kind, file.Name(), err)
+			continue
+		}
+		if err := decode(data); err != nil {
+			log.Printf("Error parseando %s %s: %v", kind, file.Name(), err)
+		}
+	}
+	return nil
+}
+
+func writeRecord(filename string, record any, perm os.FileMode) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, data, perm)
+}
+
+// Close no hace nada: cada escritura abre y cierra su propio archivo
+func (b *JSONBackend) Close() error {
+	return nil
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "evento.json")
+
+	for _, content := range []string{`{"v":1}`, `{"v":2}`} {
+		if err := writeFileAtomic(filename, []byte(content), 0600); err != nil {
+			t.Fatalf("writeFileAtomic: %v", err)
+		}
+		if data, _ := os.ReadFile(filename); string(data) != content {
+			t.Errorf("contenido = %q, se esperaba %q", data, content)
+		}
+	}
+
+	if info, err := os.Stat(filename); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("permisos = %v, %v; se esperaba 0600", info.Mode().Perm(), err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("quedaron %d archivos en el directorio, se esperaba solo el destino", len(entries))
+	}
+
+	if err := writeFileAtomic(filepath.Join(dir, "no-existe", "x.json"), []byte("{}"), 0644); err == nil {
+		t.Error("writeFileAtomic no falló en un directorio inexistente")
+	}
+}
+
+func TestLoadEventsRecovers(t *testing.T) {
+	const id = "evento-1"
+
+	tests := []struct {
+		name          string
+		saves         int // versiones guardadas antes del "corte"; la última es la vigente
+		damage        func(t *testing.T, filename string)
+		wantName      string // vacío = el evento no se carga
+		wantRecovered []string
+	}{
+		{
+			name:     "archivo intacto",
+			saves:    2,
+			damage:   func(t *testing.T, filename string) {},
+			wantName: "v2",
+		},
+		{
+			name:          "archivo truncado",
+			saves:         2,
+			damage:        func(t *testing.T, filename string) { writeTestFile(t, filename, `{"id":"evento-1","na`) },
+			wantName:      "v1",
+			wantRecovered: []string{id + ".json"},
+		},
+		{
+			name:          "archivo vacío",
+			saves:         2,
+			damage:        func(t *testing.T, filename string) { writeTestFile(t, filename, "") },
+			wantName:      "v1",
+			wantRecovered: []string{id + ".json"},
+		},
+		{
+			name:          "falta el archivo",
+			saves:         2,
+			damage:        func(t *testing.T, filename string) { os.Remove(filename) },
+			wantName:      "v1",
+			wantRecovered: []string{id + ".json"},
+		},
+		{
+			name:   "dañado sin backup",
+			saves:  1,
+			damage: func(t *testing.T, filename string) { writeTestFile(t, filename, "{") },
+		},
+		{
+			name:  "temporal abandonado",
+			saves: 2,
+			damage: func(t *testing.T, filename string) {
+				writeTestFile(t, filepath.Join(filepath.Dir(filename), "."+id+".json.tmp-123"), `{"id":"evento-1","na`)
+			},
+			wantName: "v2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			b, err := NewJSONBackend(filepath.Join(dir, "events"), filepath.Join(dir, "templates"))
+			if err != nil {
+				t.Fatalf("NewJSONBackend: %v", err)
+			}
+
+			for i := 1; i <= tt.saves; i++ {
+				if err := b.SaveEvent(&Event{ID: id, Name: fmt.Sprintf("v%d", i)}); err != nil {
+					t.Fatalf("SaveEvent: %v", err)
+				}
+			}
+			filename := filepath.Join(dir, "events", id+".json")
+			tt.damage(t, filename)
+
+			events, err := b.LoadEvents()
+			if err != nil {
+				t.Fatalf("LoadEvents: %v", err)
+			}
+
+			if tt.wantName == "" {
+				if len(events) != 0 {
+					t.Errorf("se cargaron %d eventos, se esperaba ninguno", len(events))
+				}
+			} else if len(events) != 1 || events[0].Name != tt.wantName {
+				t.Fatalf("eventos = %+v, se esperaba %s", events, tt.wantName)
+			}
+			if got := b.RecoveredFiles(); !reflect.DeepEqual(got, tt.wantRecovered) {
+				t.Errorf("RecoveredFiles = %v, se esperaba %v", got, tt.wantRecovered)
+			}
+
+			// Lo recuperado queda escrito y no se vuelve a reportar
+			if tt.wantRecovered != nil {
+				if event, err := readEventFile(filename); err != nil || event.Name != tt.wantName {
+					t.Errorf("el archivo no quedó restaurado: %+v, %v", event, err)
+				}
+				if _, err := b.LoadEvents(); err != nil || len(b.RecoveredFiles()) != 0 {
+					t.Errorf("la segunda carga reportó %v, %v", b.RecoveredFiles(), err)
+				}
+			}
+
+			entries, _ := os.ReadDir(filepath.Join(dir, "events"))
+			for _, entry := range entries {
+				if strings.Contains(entry.Name(), ".tmp-") {
+					t.Errorf("quedó el temporal %s", entry.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteEventDoesNotComeBack(t *testing.T) {
+	dir := t.TempDir()
+	b, err := NewJSONBackend(filepath.Join(dir, "events"), filepath.Join(dir, "templates"))
+	if err != nil {
+		t.Fatalf("NewJSONBackend: %v", err)
+	}
+
+	for _, name := range []string{"v1", "v2"} {
+		if err := b.SaveEvent(&Event{ID: "borrado", Name: name}); err != nil {
+			t.Fatalf("SaveEvent: %v", err)
+		}
+	}
+	if err := b.DeleteEvent("borrado"); err != nil {
+		t.Fatalf("DeleteEvent: %v", err)
+	}
+
+	events, err := b.LoadEvents()
+	if err != nil {
+		t.Fatalf("LoadEvents: %v", err)
+	}
+	if len(events) != 0 || len(b.RecoveredFiles()) != 0 {
+		t.Errorf("el evento borrado volvió desde el backup: %+v", events)
+	}
+}
+
+func writeTestFile(t *testing.T, filename, content string) {
+	t.Helper()
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,403 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS events (
+	id       TEXT PRIMARY KEY,
+	status   TEXT NOT NULL,
+	datetime INTEGER NOT NULL,
+	data     TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_events_status ON events(status);
+CREATE TABLE IF NOT EXISTS templates (
+	name TEXT PRIMARY KEY,
+	data TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS tokens (
+	id   TEXT PRIMARY KEY,
+	data TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS profiles (
+	user_id TEXT PRIMARY KEY,
+	data    TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS notification_prefs (
+	user_id TEXT PRIMARY KEY,
+	data    TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS guilds (
+	guild_id TEXT PRIMARY KEY,
+	data     TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+	guild_id TEXT NOT NULL,
+	user_id  TEXT NOT NULL,
+	data     TEXT NOT NULL,
+	PRIMARY KEY (guild_id, user_id)
+);
+`
+
+// SQLiteBackend guarda los datos del bot en una base SQLite embebida.
+// Cada registro se guarda como JSON en una fila y, como con el backend JSON,
+// los stores lo cargan todo en memoria al iniciar: una inscripción reescribe
+// la fila entera del evento. Lo que se gana es un solo archivo y que cada
+// escritura sea una transacción.
+type SQLiteBackend struct {
+	db *sql.DB
+}
+
+// NewSQLiteBackend abre (o crea) la base de datos en la ruta indicada
+func NewSQLiteBackend(path string) (*SQLiteBackend, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("error creando directorio de la base de datos: %w", err)
+	}
+
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo base de datos SQLite: %w", err)
+	}
+	// SQLite admite un solo escritor; serializamos el acceso
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creando esquema SQLite: %w", err)
+	}
+
+	return &SQLiteBackend{db: db}, nil
+}
+
+// LoadEvents lee todos los eventos de la base
+func (b *SQLiteBackend) LoadEvents() ([]*Event, error) {
+	rows, err := b.db.Query(`SELECT id, data FROM events`)
+	if err != nil {
+		return nil, fmt.Errorf("error consultando eventos: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*Event
+	for rows.Next() {
+		var id, data string
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, fmt.Errorf("error leyendo evento: %w", err)
+		}
+
+		var event Event
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			log.Printf("Error parseando evento %s de SQLite: %v", id, err)
+			continue
+		}
+
+		events = append(events, &event)
+	}
+
+	return events, rows.Err()
+}
+
+// SaveEvent inserta o actualiza el evento
+func (b *SQLiteBackend) SaveEvent(event *Event) error {
+	return saveSQLiteEvent(b.db, event)
+}
+
+// sqlExecer es *sql.DB o *sql.Tx
+type sqlExecer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func saveSQLiteEvent(db sqlExecer, event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error serializando evento: %w", err)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO events (id, status, datetime, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET status = excluded.status, datetime = excluded.datetime, data = excluded.data`,
+		event.ID, event.Status, event.DateTime.Unix(), string(data))
+	if err != nil {
+		return fmt.Errorf("error guardando evento en SQLite: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteEvent elimina el evento de la base
+func (b *SQLiteBackend) DeleteEvent(id string) error {
+	if _, err := b.db.Exec(`DELETE FROM events WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("error eliminando evento de SQLite: %w", err)
+	}
+	return nil
+}
+
+// LoadTemplates lee todos los templates de la base
+func (b *SQLiteBackend) LoadTemplates() ([]*EventTemplate, error) {
+	rows, err := b.db.Query(`SELECT name, data FROM templates`)
+	if err != nil {
+		return nil, fmt.Errorf("error consultando templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []*EventTemplate
+	renamed := make(map[string]string)
+	for rows.Next() {
+		var name, data string
+		if err := rows.Scan(&name, &data); err != nil {
+			return nil, fmt.Errorf("error leyendo template: %w", err)
+		}
+
+		var template EventTemplate
+		if err := json.Unmarshal([]byte(data), &template); err != nil {
+			log.Printf("Error parseando template %s de SQLite: %v", name, err)
+			continue
+		}
+
+		if name != template.Key() {
+			renamed[name] = template.Key()
+		}
+		templates = append(templates, &template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// Los templates de un servidor se guardaban solo con su nombre:
+	// se pasan a la clave con el servidor para no pisar al compartido
+	for name, key := range renamed {
+		if _, err := b.db.Exec(`UPDATE OR IGNORE templates SET name = ? WHERE name = ?`, key, name); err != nil {
+			log.Printf("Error actualizando clave del template %s: %v", name, err)
+		}
+	}
+
+	return templates, nil
+}
+
+// SaveTemplate inserta o actualiza el template; la columna name guarda su
+// TemplateKey. El formato no aplica en SQLite.
+func (b *SQLiteBackend) SaveTemplate(template *EventTemplate, format string) error {
+	return saveRecord(b.db, upsertTemplateSQL, template, template.Key())
+}
+
+// DeleteTemplate elimina el template de la base
+func (b *SQLiteBackend) DeleteTemplate(template *EventTemplate) error {
+	if _, err := b.db.Exec(`DELETE FROM templates WHERE name = ?`, template.Key()); err != nil {
+		return fmt.Errorf("error eliminando template de SQLite: %w", err)
+	}
+	return nil
+}
+
+// LoadTokens lee todos los tokens de la API de la base
+func (b *SQLiteBackend) LoadTokens() ([]*APIToken, error) {
+	var tokens []*APIToken
+	err := b.loadRecords("tokens", "id", func(data []byte) error {
+		var token APIToken
+		if err := json.Unmarshal(data, &token); err != nil {
+			return err
+		}
+		tokens = append(tokens, &token)
+		return nil
+	})
+	return tokens, err
+}
+
+// SaveToken inserta o actualiza el token
+func (b *SQLiteBackend) SaveToken(token *APIToken) error {
+	return saveRecord(b.db, upsertTokenSQL, token, token.ID)
+}
+
+// LoadProfiles lee todos los perfiles de jugadores de la base
+func (b *SQLiteBackend) LoadProfiles() ([]*Profile, error) {
+	var profiles []*Profile
+	err := b.loadRecords("profiles", "user_id", func(data []byte) error {
+		var profile Profile
+		if err := json.Unmarshal(data, &profile); err != nil {
+			return err
+		}
+		profiles = append(profiles, &profile)
+		return nil
+	})
+	return profiles, err
+}
+
+// SaveProfile inserta o actualiza el perfil de un jugador
+func (b *SQLiteBackend) SaveProfile(profile *Profile) error {
+	return saveRecord(b.db, upsertProfileSQL, profile, profile.UserID)
+}
+
+// LoadNotificationPrefs lee todas las preferencias de notificación de la base
+func (b *SQLiteBackend) LoadNotificationPrefs() ([]*NotificationPrefs, error) {
+	var all []*NotificationPrefs
+	err := b.loadRecords("notification_prefs", "user_id", func(data []byte) error {
+		var prefs NotificationPrefs
+		if err := json.Unmarshal(data, &prefs); err != nil {
+			return err
+		}
+		all = append(all, &prefs)
+		return nil
+	})
+	return all, err
+}
+
+// SaveNotificationPrefs inserta o actualiza las preferencias de un jugador
+func (b *SQLiteBackend) SaveNotificationPrefs(prefs *NotificationPrefs) error {
+	return saveRecord(b.db, upsertNotificationPrefsSQL, prefs, prefs.UserID)
+}
+
+// LoadGuildSettings lee la configuración de todos los servidores de la base
+func (b *SQLiteBackend) LoadGuildSettings() ([]*GuildSettings, error) {
+	var all []*GuildSettings
+	err := b.loadRecords("guilds", "guild_id", func(data []byte) error {
+		var settings GuildSettings
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return err
+		}
+		all = append(all, &settings)
+		return nil
+	})
+	return all, err
+}
+
+// SaveGuildSettings inserta o actualiza la configuración de un servidor
+func (b *SQLiteBackend) SaveGuildSettings(settings *GuildSettings) error {
+	return saveRecord(b.db, upsertGuildSQL, settings, settings.GuildID)
+}
+
+// LoadCalendarFeeds lee todos los accesos a calendarios de la base
+func (b *SQLiteBackend) LoadCalendarFeeds() ([]*CalendarFeed, error) {
+	var feeds []*CalendarFeed
+	err := b.loadRecords("calendar_feeds", "guild_id || '_' || user_id", func(data []byte) error {
+		var feed CalendarFeed
+		if err := json.Unmarshal(data, &feed); err != nil {
+			return err
+		}
+		feeds = append(feeds, &feed)
+		return nil
+	})
+	return feeds, err
+}
+
+// SaveCalendarFeed inserta o actualiza el acceso de un jugador
+func (b *SQLiteBackend) SaveCalendarFeed(feed *CalendarFeed) error {
+	return saveRecord(b.db, upsertCalendarFeedSQL, feed, feed.GuildID, feed.UserID)
+}
+
+// loadRecords pasa a decode el JSON de cada fila de la tabla. Las filas
+// dañadas se registran en el log y se saltean.
+func (b *SQLiteBackend) loadRecords(table, key string, decode func(data []byte) error) error {
+	rows, err := b.db.Query(fmt.Sprintf(`SELECT %s, data FROM %s`, key, table))
+	if err != nil {
+		return fmt.Errorf("error consultando %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, data string
+		if err := rows.Scan(&id, &data); err != nil {
+			return fmt.Errorf("error leyendo %s: %w", table, err)
+		}
+		if err := decode([]byte(data)); err != nil {
+			log.Printf("Error parseando %s %s de SQLite: %v", table, id, err)
+		}
+	}
+	return rows.Err()
+}
+
+// ReplaceAll reemplaza todos los datos dentro de una transacción
+func (b *SQLiteBackend) ReplaceAll(data *SnapshotData) error {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"events", "templates", "tokens", "profiles", "notification_prefs", "guilds", "calendar_feeds"} {
+		if _, err := tx.Exec(`DELETE FROM ` + table); err != nil {
+			return fmt.Errorf("error vaciando %s: %w", table, err)
+		}
+	}
+
+	for _, event := range data.Events {
+		if err := saveSQLiteEvent(tx, event); err != nil {
+			return err
+		}
+	}
+	for _, template := range data.Templates {
+		if err := saveRecord(tx, upsertTemplateSQL, template, template.Key()); err != nil {
+			return err
+		}
+	}
+	for _, token := range data.Tokens {
+		if err := saveRecord(tx, upsertTokenSQL, token, token.ID); err != nil {
+			return err
+		}
+	}
+	for _, profile := range data.Profiles {
+		if err := saveRecord(tx, upsertProfileSQL, profile, profile.UserID); err != nil {
+			return err
+		}
+	}
+	for _, prefs := range data.Notifications {
+		if err := saveRecord(tx, upsertNotificationPrefsSQL, prefs, prefs.UserID); err != nil {
+			return err
+		}
+	}
+	for _, settings := range data.Guilds {
+		if err := saveRecord(tx, upsertGuildSQL, settings, settings.GuildID); err != nil {
+			return err
+		}
+	}
+	for _, feed := range data.Calendars {
+		if err := saveRecord(tx, upsertCalendarFeedSQL, feed, feed.GuildID, feed.UserID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error confirmando transacción: %w", err)
+	}
+	return nil
+}
+
+// Upserts de los registros guardados como JSON; el último valor es el registro
+const (
+	upsertTemplateSQL = `INSERT INTO templates (name, data) VALUES (?, ?)
+		ON CONFLICT(name) DO UPDATE SET data = excluded.data`
+	upsertTokenSQL = `INSERT INTO tokens (id, data) VALUES (?, ?)
+		ON CONFLICT(id) DO UPDATE SET data = excluded.data`
+	upsertProfileSQL = `INSERT INTO profiles (user_id, data) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET data = excluded.data`
+	upsertNotificationPrefsSQL = `INSERT INTO notification_prefs (user_id, data) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET data = excluded.data`
+	upsertGuildSQL = `INSERT INTO guilds (guild_id, data) VALUES (?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET data = excluded.data`
+	upsertCalendarFeedSQL = `INSERT INTO calendar_feeds (guild_id, user_id, data) VALUES (?, ?, ?)
+		ON CONFLICT(guild_id, user_id) DO UPDATE SET data = excluded.data`
+)
+
+// saveRecord ejecuta el upsert con las claves y el registro serializado como último valor
+func saveRecord(db sqlExecer, query string, record any, keys ...any) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("error serializando registro: %w", err)
+	}
+	if _, err := db.Exec(query, append(keys, string(data))...); err != nil {
+		return fmt.Errorf("error guardando en SQLite: %w", err)
+	}
+	return nil
+}
+
+// Close cierra la conexión a la base
+func (b *SQLiteBackend) Close() error {
+	return b.db.Close()
+}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const calendarsDir = "data/calendars"
+
+// Cada cuánto se persiste LastUsedAt: los calendarios consultan el feed seguido
+const calendarUsageFlushInterval = time.Hour
+
+// CalendarFeed es el acceso de un jugador a los calendarios iCal de un
+// servidor. El token va en la URL del feed, que no pasa por el login del
+// panel: quien tenga la URL puede leer los eventos del servidor.
+type CalendarFeed struct {
+	Token      string     `json:"token"`
+	UserID     string     `json:"user_id"`
+	Username   string     `json:"username"`
+	GuildID    string     `json:"guild_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// CalendarStore maneja el almacenamiento de los accesos a los calendarios
+type CalendarStore struct {
+	mu      sync.RWMutex
+	feeds   map[string]*CalendarFeed // por servidor y usuario
+	backend Backend
+	flushed map[string]time.Time
+}
+
+var Calendars *CalendarStore
+
+// InitCalendarStore inicializa el almacenamiento de calendarios
+func InitCalendarStore() error {
+	b, err := activeBackend()
+	if err != nil {
+		return err
+	}
+
+	Calendars = &CalendarStore{
+		feeds:   make(map[string]*CalendarFeed),
+		backend: b,
+		flushed: make(map[string]time.Time),
+	}
+
+	if err := Calendars.LoadFeeds(); err != nil {
+		log.Printf("Advertencia al cargar calendarios: %v", err)
+	}
+
+	log.Printf("✅ Sistema de calendarios inicializado con %d accesos", len(Calendars.feeds))
+	return nil
+}
+
+// LoadFeeds carga todos los accesos desde el backend
+func (cs *CalendarStore) LoadFeeds() error {
+	feeds, err := cs.backend.LoadCalendarFeeds()
+	if err != nil {
+		return err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	for _, feed := range feeds {
+		cs.feeds[calendarKey(feed.GuildID, feed.UserID)] = feed
+	}
+
+	return nil
+}
+
+// GetFeed devuelve el acceso de un usuario a los calendarios de un servidor
+func (cs *CalendarStore) GetFeed(guildID, userID string) (CalendarFeed, bool) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	feed, exists := cs.feeds[calendarKey(guildID, userID)]
+	if !exists {
+		return CalendarFeed{GuildID: guildID, UserID: userID}, false
+	}
+	return *feed, true
+}
+
+// ResetFeed genera un token nuevo para el usuario. El anterior deja de
+// funcionar, así que las suscripciones existentes hay que volver a hacerlas.
+func (cs *CalendarStore) ResetFeed(guildID, userID, username string) (CalendarFeed, error) {
+	// Los IDs forman el nombre del archivo: solo se aceptan IDs de Discord
+	if !validUserID(guildID) || !validUserID(userID) {
+		return CalendarFeed{}, fmt.Errorf("ID de usuario o servidor inválido: %s/%s", guildID, userID)
+	}
+
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return CalendarFeed{}, fmt.Errorf("error generando token de calendario: %w", err)
+	}
+
+	feed := &CalendarFeed{
+		Token:     hex.EncodeToString(raw),
+		UserID:    userID,
+		Username:  username,
+		GuildID:   guildID,
+		CreatedAt: time.Now(),
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if err := cs.saveFeedNoLock(feed); err != nil {
+		return CalendarFeed{}, err
+	}
+	cs.feeds[calendarKey(guildID, userID)] = feed
+	return *feed, nil
+}
+
+// Authenticate busca el acceso que corresponde a un token y registra su último uso
+func (cs *CalendarStore) Authenticate(token string) (CalendarFeed, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	var match *CalendarFeed
+	for _, feed := range cs.feeds {
+		if subtle.ConstantTimeCompare([]byte(feed.Token), []byte(token)) == 1 {
+			match = feed
+		}
+	}
+	if match == nil || token == "" {
+		return CalendarFeed{}, fmt.Errorf("calendario no encontrado")
+	}
+
+	now := time.Now()
+	match.LastUsedAt = &now
+	key := calendarKey(match.GuildID, match.UserID)
+	if now.Sub(cs.flushed[key]) >= calendarUsageFlushInterval {
+		cs.flushed[key] = now
+		if err := cs.saveFeedNoLock(match); err != nil {
+			log.Printf("Error guardando último uso del calendario de %s: %v", match.UserID, err)
+		}
+	}
+
+	return *match, nil
+}
+
+func (cs *CalendarStore) saveFeedNoLock(feed *CalendarFeed) error {
+	return cs.backend.SaveCalendarFeed(feed)
+}
+
+func calendarKey(guildID, userID string) string {
+	return guildID + "_" + userID
+}
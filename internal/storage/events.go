@@ -1,12 +1,9 @@
 package storage
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"os"
-	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 )
@@ -16,6 +13,7 @@ const eventsDir = "data/events"
 // Event representa un evento del MMO
 type Event struct {
 	ID                      string              `json:"id"`
+	GuildID                 string              `json:"guild_id,omitempty"` // vacío = el servidor principal (GUILD_ID)
 	Name                    string              `json:"name"`
 	Type                    string              `json:"type"`
 	Description             string              `json:"description"`
@@ -26,19 +24,83 @@ type Event struct {
 	MessageID               string              `json:"message_id"`
 	ThreadID                string              `json:"thread_id,omitempty"`
 	DiscordEventID          string              `json:"discord_event_id,omitempty"`
+	DiscordEventStatus      string              `json:"discord_event_status,omitempty"` // vacío = programado, active, completed, cancelled
+	Imported                bool                `json:"imported,omitempty"`             // creado desde Discord e importado por el bot
 	TemplateName            string              `json:"template_name,omitempty"`
 	Roles                   []RoleSignup        `json:"roles"`
 	Signups                 map[string][]Signup `json:"signups"`
-	ReminderSent            bool                `json:"reminder_sent"`
+	Waitlist                map[string][]Signup `json:"waitlist,omitempty"`
+	Cancellations           []Cancellation      `json:"cancellations,omitempty"`
+	ReminderSent            bool                `json:"reminder_sent"`              // se envió la última etapa
+	ReminderOffsets         []int               `json:"reminder_offsets,omitempty"` // minutos antes, de mayor a menor
+	RemindersSent           []int               `json:"reminders_sent,omitempty"`   // etapas ya enviadas
 	CreatedAt               time.Time           `json:"created_at"`
 	CreatedBy               string              `json:"created_by"`
 	AllowMultiSignup        bool                `json:"allow_multi_signup"`
+	RequireApproval         bool                `json:"require_approval,omitempty"`
 	Status                  string              `json:"status"` // active, completed, cancelled
 	MaxParticipants         int                 `json:"max_participants,omitempty"`
 	RepeatEveryDays         int                 `json:"repeat_every_days,omitempty"`
+	Recurrence              *Recurrence         `json:"recurrence,omitempty"`
+	SeriesID                string              `json:"series_id,omitempty"`  // ID de la primera ocurrencia de la serie
+	Occurrence              int                 `json:"occurrence,omitempty"` // número de ocurrencia dentro de la serie
+	CarryOverSignups        bool                `json:"carry_over_signups,omitempty"`
+	AttendancePanelSent     bool                `json:"attendance_panel_sent,omitempty"`
+	DMRemindersSent         []string            `json:"dm_reminders_sent,omitempty"` // usuarios ya recordados por privado
+	AnnouncementDMsSent     bool                `json:"announcement_dms_sent,omitempty"`
 	CreateDiscordEvent      bool                `json:"create_discord_event,omitempty"`
 	ReminderOffsetMinutes   int                 `json:"reminder_offset_minutes,omitempty"`
 	DeleteAfterHours        int                 `json:"delete_after_hours,omitempty"`
+	Requirements            *SignupRequirements `json:"requirements,omitempty"`
+	FillIn                  *FillInSettings     `json:"fill_in,omitempty"`
+	FillInSent              bool                `json:"fill_in_sent,omitempty"`
+	FillInRoles             []string            `json:"fill_in_roles,omitempty"` // roles que se pidieron en el llamado
+}
+
+// FillInSettings configura el llamado a completar roles antes del evento.
+// Los valores vacíos usan FILL_IN_LEAD_MINUTES y FILL_IN_ROLE_PINGS.
+type FillInSettings struct {
+	LeadMinutes int               `json:"lead_minutes,omitempty" yaml:"lead_minutes,omitempty"`
+	RolePings   map[string]string `json:"role_pings,omitempty" yaml:"role_pings,omitempty"` // rol del juego -> ID de rol de Discord
+}
+
+// IsEmpty indica si no hay nada configurado
+func (f *FillInSettings) IsEmpty() bool {
+	return f == nil || (f.LeadMinutes <= 0 && len(f.RolePings) == 0)
+}
+
+// SignupRequirements son las condiciones para poder inscribirse. Los roles
+// son IDs de roles de Discord: basta con tener uno de los requeridos y no
+// se puede tener ninguno de los prohibidos. El item level se toma del
+// personaje elegido o, si no se eligió, del main del perfil.
+type SignupRequirements struct {
+	RequiredRoles  []string `json:"required_roles,omitempty" yaml:"required_roles,omitempty"`
+	ForbiddenRoles []string `json:"forbidden_roles,omitempty" yaml:"forbidden_roles,omitempty"`
+	MinItemLevel   int      `json:"min_item_level,omitempty" yaml:"min_item_level,omitempty"`
+}
+
+// IsEmpty indica si no hay ningún requisito definido
+func (r *SignupRequirements) IsEmpty() bool {
+	return r == nil || (len(r.RequiredRoles) == 0 && len(r.ForbiddenRoles) == 0 && r.MinItemLevel <= 0)
+}
+
+// HasRoleRules indica si hay requisitos de roles de Discord
+func (r *SignupRequirements) HasRoleRules() bool {
+	return r != nil && (len(r.RequiredRoles) > 0 || len(r.ForbiddenRoles) > 0)
+}
+
+// Recurrence describe una regla de repetición estilo RRULE. Las fechas se
+// calculan en la zona horaria configurada a partir de Start (primera ocurrencia).
+type Recurrence struct {
+	Freq         string     `json:"freq"` // daily, weekly, monthly
+	Interval     int        `json:"interval,omitempty"`
+	Weekdays     []string   `json:"weekdays,omitempty"`      // MO..SU (weekly)
+	MonthWeek    int        `json:"month_week,omitempty"`    // 1-4, -1 = último (monthly)
+	MonthWeekday string     `json:"month_weekday,omitempty"` // MO..SU (monthly)
+	Until        *time.Time `json:"until,omitempty"`
+	Count        int        `json:"count,omitempty"`
+	Exceptions   []string   `json:"exceptions,omitempty"` // fechas YYYY-MM-DD que se saltean
+	Start        time.Time  `json:"start"`
 }
 
 // RoleSignup representa un rol disponible para el evento
@@ -54,36 +116,57 @@ type ClassInfo struct {
 	Name        string `json:"name"`
 	Emoji       string `json:"emoji"`
 	Description string `json:"description,omitempty"`
+	Limit       int    `json:"limit,omitempty"`
 }
 
 // Signup representa una inscripción de usuario
 type Signup struct {
+	UserID       string    `json:"user_id"`
+	Username     string    `json:"username"`
+	Role         string    `json:"role"`
+	Class        string    `json:"class,omitempty"`
+	Character    string    `json:"character,omitempty"`
+	Status       string    `json:"status"` // pending, confirmed, declined, waitlisted
+	SignedUpAt   time.Time `json:"signed_up_at"`
+	ConfirmedBy  string    `json:"confirmed_by,omitempty"`
+	DeclinedBy   string    `json:"declined_by,omitempty"`
+	Attendance   string    `json:"attendance,omitempty"` // present, late, no_show
+	AttendanceBy string    `json:"attendance_by,omitempty"`
+	LateFill     bool      `json:"late_fill,omitempty"`  // se inscribió en un rol pedido por el llamado a completar
+	Interested   bool      `json:"interested,omitempty"` // viene de "me interesa" en el evento de Discord
+}
+
+// Cancellation registra una inscripción que el usuario canceló. Late indica
+// que canceló después de que se envió el recordatorio del evento.
+type Cancellation struct {
 	UserID      string    `json:"user_id"`
 	Username    string    `json:"username"`
 	Role        string    `json:"role"`
 	Class       string    `json:"class,omitempty"`
-	Status      string    `json:"status"` // pending, confirmed, declined
 	SignedUpAt  time.Time `json:"signed_up_at"`
-	ConfirmedBy string    `json:"confirmed_by,omitempty"`
+	CancelledAt time.Time `json:"cancelled_at"`
+	Late        bool      `json:"late,omitempty"`
 }
 
 // EventStore maneja el almacenamiento de eventos
 type EventStore struct {
-	mu     sync.RWMutex
-	events map[string]*Event
+	mu      sync.RWMutex
+	events  map[string]*Event
+	backend Backend
 }
 
 var Store *EventStore
 
 // InitStore inicializa el almacenamiento de eventos
 func InitEventStore() error {
-	Store = &EventStore{
-		events: make(map[string]*Event),
+	b, err := activeBackend()
+	if err != nil {
+		return err
 	}
 
-	// Crear directorio de datos si no existe
-	if err := os.MkdirAll(eventsDir, 0755); err != nil {
-		return fmt.Errorf("error creando directorio de datos: %w", err)
+	Store = &EventStore{
+		events:  make(map[string]*Event),
+		backend: b,
 	}
 
 	// Cargar eventos existentes
@@ -95,16 +178,17 @@ func InitEventStore() error {
 	return nil
 }
 
-func (s *EventStore) DeleteCancelledEvents() (int, error) {
+// DeleteCancelledEvents elimina los eventos cancelados para los que match
+// devuelve true (por ejemplo, los de un servidor)
+func (s *EventStore) DeleteCancelledEvents(match func(event *Event) bool) (int, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	deleted := 0
 	for id, event := range s.events {
-		if event.Status == "cancelled" {
-			filename := filepath.Join(eventsDir, fmt.Sprintf("%s.json", id))
-			if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
-				return deleted, fmt.Errorf("error eliminando archivo: %w", err)
+		if event.Status == "cancelled" && match(event) {
+			if err := s.backend.DeleteEvent(id); err != nil {
+				return deleted, err
 			}
 			delete(s.events, id)
 			deleted++
@@ -125,17 +209,7 @@ func (s *EventStore) SaveEvent(event *Event) error {
 func (s *EventStore) saveEventNoLock(event *Event) error {
 	s.events[event.ID] = event
 
-	filename := filepath.Join(eventsDir, fmt.Sprintf("%s.json", event.ID))
-	data, err := json.MarshalIndent(event, "", "  ")
-	if err != nil {
-		return fmt.Errorf("error serializando evento: %w", err)
-	}
-
-	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
-		return fmt.Errorf("error escribiendo archivo: %w", err)
-	}
-
-	return nil
+	return s.backend.SaveEvent(event)
 }
 
 // GetEvent obtiene un evento por ID
@@ -179,6 +253,37 @@ func (s *EventStore) GetActiveEvents() []*Event {
 	return events
 }
 
+// GetSeriesEvents retorna todas las ocurrencias de una serie ordenadas por fecha
+func (s *EventStore) GetSeriesEvents(seriesID string) []*Event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make([]*Event, 0)
+	for _, event := range s.events {
+		if event.ID == seriesID || event.SeriesID == seriesID {
+			events = append(events, event)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].DateTime.Before(events[j].DateTime)
+	})
+
+	return events
+}
+
+// ReplaceOccurrence guarda la nueva ocurrencia de una serie y la anterior ya
+// cerrada bajo el mismo lock, para que nunca haya dos ocurrencias activas
+func (s *EventStore) ReplaceOccurrence(previous, next *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.saveEventNoLock(next); err != nil {
+		return err
+	}
+	return s.saveEventNoLock(previous)
+}
+
 // DeleteEvent elimina un evento
 func (s *EventStore) DeleteEvent(id string) error {
 	s.mu.Lock()
@@ -186,74 +291,75 @@ func (s *EventStore) DeleteEvent(id string) error {
 
 	delete(s.events, id)
 
-	filename := filepath.Join(eventsDir, fmt.Sprintf("%s.json", id))
-	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("error eliminando archivo: %w", err)
-	}
-
-	return nil
+	return s.backend.DeleteEvent(id)
 }
 
-// LoadEvents carga todos los eventos desde disco
+// LoadEvents carga todos los eventos desde el backend
 func (s *EventStore) LoadEvents() error {
-	files, err := ioutil.ReadDir(eventsDir)
+	events, err := s.backend.LoadEvents()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil // Directorio no existe aún, no es error
-		}
-		return fmt.Errorf("error leyendo directorio: %w", err)
+		return err
 	}
 
-	for _, file := range files {
-		if filepath.Ext(file.Name()) != ".json" {
-			continue
-		}
-
-		filename := filepath.Join(eventsDir, file.Name())
-		data, err := ioutil.ReadFile(filename)
-		if err != nil {
-			log.Printf("Error leyendo archivo %s: %v", filename, err)
-			continue
-		}
-
-		var event Event
-		if err := json.Unmarshal(data, &event); err != nil {
-			log.Printf("Error parseando archivo %s: %v", filename, err)
-			continue
-		}
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		s.events[event.ID] = &event
+	for _, event := range events {
+		s.events[event.ID] = event
 	}
 
 	log.Printf("📦 Cargados %d eventos desde disco", len(s.events))
 	return nil
 }
 
-// AddSignup agrega una inscripción a un evento
-func (s *EventStore) AddSignup(eventID, userID, username, role string) error {
+// AddSignup agrega una inscripción a un evento. Se indican el usuario, el
+// rol y, si corresponde, la clase y el personaje; el estado y la fecha los
+// completa el store.
+func (s *EventStore) AddSignup(eventID string, signup Signup) error {
+	signup.Status = ""
+	_, err := s.addSignup(eventID, signup)
+	return err
+}
+
+// AddInterestedSignup agrega la inscripción de alguien que marcó "me
+// interesa" en el evento de Discord. Queda pendiente, aunque el evento no
+// pida aprobación, hasta que un oficial la confirme.
+func (s *EventStore) AddInterestedSignup(eventID, userID, username, role string) (*Signup, error) {
+	return s.addSignup(eventID, Signup{
+		UserID:     userID,
+		Username:   username,
+		Role:       role,
+		Status:     "pending",
+		Interested: true,
+	})
+}
+
+// addSignup agrega la inscripción al rol que indica. Sin estado, entra con
+// el que pide el evento y se marca si completa un rol del llamado a suplentes.
+func (s *EventStore) addSignup(eventID string, signup Signup) (*Signup, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	event, exists := s.events[eventID]
 	if !exists {
-		return fmt.Errorf("evento no encontrado")
+		return nil, fmt.Errorf("evento no encontrado")
 	}
 
 	if event.Signups == nil {
 		event.Signups = make(map[string][]Signup)
 	}
 
-	signup := Signup{
-		UserID:     userID,
-		Username:   username,
-		Role:       role,
-		Status:     "confirmed",
-		SignedUpAt: time.Now(),
+	if signup.Status == "" {
+		signup.Status = initialSignupStatus(event)
+		signup.LateFill = isLateFill(event, signup.Role)
 	}
+	signup.SignedUpAt = time.Now()
+	event.Signups[signup.Role] = append(event.Signups[signup.Role], signup)
 
-	event.Signups[role] = append(event.Signups[role], signup)
-
-	return s.saveEventNoLock(event)
+	if err := s.saveEventNoLock(event); err != nil {
+		return nil, err
+	}
+	return &signup, nil
 }
 
 // RemoveSignup elimina una inscripción
@@ -292,24 +398,123 @@ func (s *EventStore) ConfirmSignup(eventID, userID, role, confirmedBy string) er
 		if signup.UserID == userID {
 			event.Signups[role][i].Status = "confirmed"
 			event.Signups[role][i].ConfirmedBy = confirmedBy
-			break
+			return s.saveEventNoLock(event)
+		}
+	}
+
+	return fmt.Errorf("inscripción no encontrada")
+}
+
+// DeclineSignup rechaza una inscripción pendiente. La inscripción se conserva
+// con estado "declined" para que quede registro de la decisión.
+func (s *EventStore) DeclineSignup(eventID, userID, role, declinedBy string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event, exists := s.events[eventID]
+	if !exists {
+		return fmt.Errorf("evento no encontrado")
+	}
+
+	signups := event.Signups[role]
+	for i, signup := range signups {
+		if signup.UserID == userID {
+			event.Signups[role][i].Status = "declined"
+			event.Signups[role][i].DeclinedBy = declinedBy
+			return s.saveEventNoLock(event)
 		}
 	}
 
+	return fmt.Errorf("inscripción no encontrada")
+}
+
+// RecordCancellation guarda el registro de una inscripción cancelada
+func (s *EventStore) RecordCancellation(eventID string, cancellation Cancellation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event, exists := s.events[eventID]
+	if !exists {
+		return fmt.Errorf("evento no encontrado")
+	}
+
+	event.Cancellations = append(event.Cancellations, cancellation)
 	return s.saveEventNoLock(event)
 }
 
-// CreateEventFromTemplate crea un evento basado en un template
-func (s *EventStore) CreateEventFromTemplate(templateName string, eventData *Event) (*Event, error) {
-	template, err := Templates.GetTemplate(templateName)
-	if err != nil {
-		return nil, fmt.Errorf("template no encontrado: %w", err)
+// SetAttendance registra la asistencia de un usuario en todas sus
+// inscripciones confirmadas del evento
+func (s *EventStore) SetAttendance(eventID, userID, attendance, markedBy string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event, exists := s.events[eventID]
+	if !exists {
+		return fmt.Errorf("evento no encontrado")
+	}
+
+	found := false
+	for role, signups := range event.Signups {
+		for i, signup := range signups {
+			if signup.UserID == userID && signup.Status == "confirmed" {
+				event.Signups[role][i].Attendance = attendance
+				event.Signups[role][i].AttendanceBy = markedBy
+				found = true
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("inscripción confirmada no encontrada")
+	}
+
+	return s.saveEventNoLock(event)
+}
+
+// initialSignupStatus devuelve el estado con el que entra una nueva inscripción
+func initialSignupStatus(event *Event) string {
+	if event.RequireApproval {
+		return "pending"
+	}
+	return "confirmed"
+}
+
+// isLateFill indica si una inscripción en el rol responde al llamado a completar
+func isLateFill(event *Event, role string) bool {
+	if !event.FillInSent {
+		return false
+	}
+	for _, r := range event.FillInRoles {
+		if r == role {
+			return true
+		}
 	}
+	return false
+}
 
+// CreateEventFromTemplate crea un evento basado en un template
+func (s *EventStore) CreateEventFromTemplate(template *EventTemplate, eventData *Event) (*Event, error) {
 	// Copiar configuración del template al evento
-	eventData.TemplateName = templateName
+	eventData.TemplateName = template.Name
 	eventData.MaxParticipants = template.MaxParticipants
 	eventData.AllowMultiSignup = template.AllowMultiSignup
+	eventData.RequireApproval = eventData.RequireApproval || template.RequireApproval
+	if len(eventData.ReminderOffsets) == 0 {
+		eventData.ReminderOffsets = append([]int(nil), template.ReminderOffsets...)
+	}
+	if eventData.Requirements == nil && !template.Requirements.IsEmpty() {
+		requirements := *template.Requirements
+		requirements.RequiredRoles = append([]string(nil), template.Requirements.RequiredRoles...)
+		requirements.ForbiddenRoles = append([]string(nil), template.Requirements.ForbiddenRoles...)
+		eventData.Requirements = &requirements
+	}
+	if eventData.FillIn == nil && !template.FillIn.IsEmpty() {
+		fillIn := *template.FillIn
+		fillIn.RolePings = make(map[string]string, len(template.FillIn.RolePings))
+		for role, discordRole := range template.FillIn.RolePings {
+			fillIn.RolePings[role] = discordRole
+		}
+		eventData.FillIn = &fillIn
+	}
 
 	// Convertir roles del template a roles del evento
 	eventData.Roles = make([]RoleSignup, 0, len(template.Roles))
@@ -328,6 +533,7 @@ func (s *EventStore) CreateEventFromTemplate(templateName string, eventData *Eve
 					Name:        tClass.Name,
 					Emoji:       tClass.Emoji,
 					Description: tClass.Description,
+					Limit:       tClass.Limit,
 				})
 			}
 		}
@@ -348,8 +554,8 @@ func (s *EventStore) CreateEventFromTemplate(templateName string, eventData *Eve
 	return eventData, nil
 }
 
-// AddSignupWithClass agrega una inscripción con clase específica
-func (s *EventStore) AddSignupWithClass(eventID, userID, username, role, class string) error {
+// AddToWaitlist agrega un usuario a la banca (lista de espera) de un rol
+func (s *EventStore) AddToWaitlist(eventID, userID, username, role, class string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -358,8 +564,8 @@ func (s *EventStore) AddSignupWithClass(eventID, userID, username, role, class s
 		return fmt.Errorf("evento no encontrado")
 	}
 
-	if event.Signups == nil {
-		event.Signups = make(map[string][]Signup)
+	if event.Waitlist == nil {
+		event.Waitlist = make(map[string][]Signup)
 	}
 
 	signup := Signup{
@@ -367,11 +573,90 @@ func (s *EventStore) AddSignupWithClass(eventID, userID, username, role, class s
 		Username:   username,
 		Role:       role,
 		Class:      class,
-		Status:     "confirmed",
+		Status:     "waitlisted",
 		SignedUpAt: time.Now(),
 	}
 
-	event.Signups[role] = append(event.Signups[role], signup)
+	event.Waitlist[role] = append(event.Waitlist[role], signup)
 
 	return s.saveEventNoLock(event)
 }
+
+// RemoveFromWaitlist quita a un usuario de la banca de un rol, o de todos
+// los roles si role está vacío. Devuelve true si el usuario estaba en alguna banca.
+func (s *EventStore) RemoveFromWaitlist(eventID, userID, role string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event, exists := s.events[eventID]
+	if !exists {
+		return false, fmt.Errorf("evento no encontrado")
+	}
+
+	removed := false
+	for r, waiting := range event.Waitlist {
+		if role != "" && r != role {
+			continue
+		}
+		for i, signup := range waiting {
+			if signup.UserID == userID {
+				event.Waitlist[r] = append(waiting[:i], waiting[i+1:]...)
+				removed = true
+				break
+			}
+		}
+		if len(event.Waitlist[r]) == 0 {
+			delete(event.Waitlist, r)
+		}
+	}
+
+	if !removed {
+		return false, nil
+	}
+
+	return true, s.saveEventNoLock(event)
+}
+
+// PromoteFromWaitlist mueve a un usuario de la banca de un rol a la
+// lista de inscriptos confirmados. Devuelve nil si no estaba en la banca.
+func (s *EventStore) PromoteFromWaitlist(eventID, role, userID string) (*Signup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event, exists := s.events[eventID]
+	if !exists {
+		return nil, fmt.Errorf("evento no encontrado")
+	}
+
+	waiting := event.Waitlist[role]
+	idx := -1
+	for i, signup := range waiting {
+		if signup.UserID == userID {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return nil, nil
+	}
+
+	promoted := waiting[idx]
+	event.Waitlist[role] = append(waiting[:idx:idx], waiting[idx+1:]...)
+	if len(event.Waitlist[role]) == 0 {
+		delete(event.Waitlist, role)
+	}
+
+	if event.Signups == nil {
+		event.Signups = make(map[string][]Signup)
+	}
+
+	promoted.Status = initialSignupStatus(event)
+	promoted.SignedUpAt = time.Now()
+	event.Signups[role] = append(event.Signups[role], promoted)
+
+	if err := s.saveEventNoLock(event); err != nil {
+		return nil, err
+	}
+
+	return &promoted, nil
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+const guildsDir = "data/guilds"
+
+// GuildSettings es la configuración propia de un servidor de Discord. Los
+// valores vacíos usan la configuración global del .env.
+type GuildSettings struct {
+	GuildID string `json:"guild_id"`
+	Name    string `json:"name"`
+
+	DefaultRoles             []RoleSignup      `json:"default_roles,omitempty"`
+	ReminderOffsetMinutes    int               `json:"reminder_offset_minutes,omitempty"`
+	EnableDiscordEvents      *bool             `json:"enable_discord_events,omitempty"`
+	AttendanceVoiceChannelID string            `json:"attendance_voice_channel_id,omitempty"`
+	FillInLeadMinutes        int               `json:"fill_in_lead_minutes,omitempty"`
+	FillInRolePings          map[string]string `json:"fill_in_role_pings,omitempty"`
+	ImportDiscordEvents      *bool             `json:"import_discord_events,omitempty"`
+	ImportEventsChannelID    string            `json:"import_events_channel_id,omitempty"`
+	ImportInterestedSignups  *bool             `json:"import_interested_signups,omitempty"`
+
+	JoinedAt  time.Time `json:"joined_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GuildStore maneja el almacenamiento de la configuración por servidor
+type GuildStore struct {
+	mu      sync.RWMutex
+	guilds  map[string]*GuildSettings
+	backend Backend
+}
+
+var Guilds *GuildStore
+
+// InitGuildStore inicializa el almacenamiento de servidores
+func InitGuildStore() error {
+	b, err := activeBackend()
+	if err != nil {
+		return err
+	}
+
+	Guilds = &GuildStore{
+		guilds:  make(map[string]*GuildSettings),
+		backend: b,
+	}
+
+	if err := Guilds.LoadGuilds(); err != nil {
+		log.Printf("Advertencia al cargar servidores: %v", err)
+	}
+
+	log.Printf("✅ Sistema de servidores inicializado con %d servidores", len(Guilds.guilds))
+	return nil
+}
+
+// LoadGuilds carga la configuración de todos los servidores desde el backend
+func (gs *GuildStore) LoadGuilds() error {
+	all, err := gs.backend.LoadGuildSettings()
+	if err != nil {
+		return err
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	for _, settings := range all {
+		gs.guilds[settings.GuildID] = settings
+	}
+
+	return nil
+}
+
+// GetSettings devuelve una copia de la configuración de un servidor
+func (gs *GuildStore) GetSettings(guildID string) (GuildSettings, bool) {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	settings, exists := gs.guilds[guildID]
+	if !exists {
+		return GuildSettings{GuildID: guildID}, false
+	}
+	return copyGuildSettings(settings), true
+}
+
+// GetAllSettings devuelve la configuración de todos los servidores conocidos,
+// ordenada por nombre
+func (gs *GuildStore) GetAllSettings() []GuildSettings {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	all := make([]GuildSettings, 0, len(gs.guilds))
+	for _, settings := range gs.guilds {
+		all = append(all, copyGuildSettings(settings))
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Name != all[j].Name {
+			return all[i].Name < all[j].Name
+		}
+		return all[i].GuildID < all[j].GuildID
+	})
+	return all
+}
+
+// RememberGuild registra un servidor al que se unió el bot, actualizando su
+// nombre sin tocar su configuración
+func (gs *GuildStore) RememberGuild(guildID, name string) error {
+	if !validUserID(guildID) {
+		return fmt.Errorf("ID de servidor inválido: %s", guildID)
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	settings, exists := gs.guilds[guildID]
+	if exists && settings.Name == name {
+		return nil
+	}
+	if !exists {
+		settings = &GuildSettings{GuildID: guildID, JoinedAt: time.Now()}
+		gs.guilds[guildID] = settings
+	}
+	settings.Name = name
+	return gs.saveSettingsNoLock(settings)
+}
+
+// SaveSettings guarda la configuración de un servidor conservando su nombre
+// y fecha de alta
+func (gs *GuildStore) SaveSettings(settings GuildSettings) (GuildSettings, error) {
+	// El ID se usa como nombre de archivo: solo se aceptan IDs de Discord
+	if !validUserID(settings.GuildID) {
+		return GuildSettings{}, fmt.Errorf("ID de servidor inválido: %s", settings.GuildID)
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if existing, ok := gs.guilds[settings.GuildID]; ok {
+		settings.Name = existing.Name
+		settings.JoinedAt = existing.JoinedAt
+	} else {
+		settings.JoinedAt = time.Now()
+	}
+	settings.UpdatedAt = time.Now()
+
+	saved := copyGuildSettings(&settings)
+	if err := gs.saveSettingsNoLock(&saved); err != nil {
+		return GuildSettings{}, err
+	}
+	gs.guilds[settings.GuildID] = &saved
+	return copyGuildSettings(&saved), nil
+}
+
+func (gs *GuildStore) saveSettingsNoLock(settings *GuildSettings) error {
+	return gs.backend.SaveGuildSettings(settings)
+}
+
+func copyGuildSettings(settings *GuildSettings) GuildSettings {
+	copied := *settings
+	copied.DefaultRoles = append([]RoleSignup(nil), settings.DefaultRoles...)
+	copied.EnableDiscordEvents = copyBool(settings.EnableDiscordEvents)
+	copied.ImportDiscordEvents = copyBool(settings.ImportDiscordEvents)
+	copied.ImportInterestedSignups = copyBool(settings.ImportInterestedSignups)
+	if settings.FillInRolePings != nil {
+		copied.FillInRolePings = make(map[string]string, len(settings.FillInRolePings))
+		for role, id := range settings.FillInRolePings {
+			copied.FillInRolePings[role] = id
+		}
+	}
+	return copied
+}
+
+func copyBool(value *bool) *bool {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const notificationsDir = "data/notifications"
+
+// NotificationPrefs son las preferencias de mensajes privados de un jugador
+type NotificationPrefs struct {
+	UserID      string `json:"user_id"`
+	Username    string `json:"username"`
+	DMReminders bool   `json:"dm_reminders"`
+	// LeadMinutes es la anticipación del recordatorio; 0 = la del evento
+	LeadMinutes int `json:"lead_minutes,omitempty"`
+	// AnnounceTypes son los tipos de evento de los que se avisan las
+	// publicaciones nuevas; "*" = todos
+	AnnounceTypes []string  `json:"announce_types,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	// Fallos de entrega de mensajes privados (por ejemplo, DMs cerrados)
+	DMFailures    int        `json:"dm_failures,omitempty"`
+	LastDMError   string     `json:"last_dm_error,omitempty"`
+	LastDMErrorAt *time.Time `json:"last_dm_error_at,omitempty"`
+}
+
+// NotificationStore maneja el almacenamiento de preferencias de notificación
+type NotificationStore struct {
+	mu      sync.RWMutex
+	prefs   map[string]*NotificationPrefs
+	backend Backend
+}
+
+var Notifications *NotificationStore
+
+// InitNotificationStore inicializa el almacenamiento de preferencias
+func InitNotificationStore() error {
+	b, err := activeBackend()
+	if err != nil {
+		return err
+	}
+
+	Notifications = &NotificationStore{
+		prefs:   make(map[string]*NotificationPrefs),
+		backend: b,
+	}
+
+	if err := Notifications.LoadPrefs(); err != nil {
+		log.Printf("Advertencia al cargar preferencias de notificación: %v", err)
+	}
+
+	log.Printf("✅ Sistema de notificaciones inicializado con %d preferencias", len(Notifications.prefs))
+	return nil
+}
+
+// LoadPrefs carga todas las preferencias desde el backend
+func (ns *NotificationStore) LoadPrefs() error {
+	all, err := ns.backend.LoadNotificationPrefs()
+	if err != nil {
+		return err
+	}
+
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	for _, prefs := range all {
+		ns.prefs[prefs.UserID] = prefs
+	}
+
+	return nil
+}
+
+// GetPrefs devuelve una copia de las preferencias de un usuario
+func (ns *NotificationStore) GetPrefs(userID string) (NotificationPrefs, bool) {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+
+	prefs, exists := ns.prefs[userID]
+	if !exists {
+		return NotificationPrefs{UserID: userID}, false
+	}
+	copied := *prefs
+	copied.AnnounceTypes = append([]string(nil), prefs.AnnounceTypes...)
+	return copied, true
+}
+
+// GetAllPrefs devuelve una copia de las preferencias de todos los usuarios
+func (ns *NotificationStore) GetAllPrefs() []NotificationPrefs {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+
+	all := make([]NotificationPrefs, 0, len(ns.prefs))
+	for _, prefs := range ns.prefs {
+		copied := *prefs
+		copied.AnnounceTypes = append([]string(nil), prefs.AnnounceTypes...)
+		all = append(all, copied)
+	}
+	return all
+}
+
+// SavePrefs guarda las preferencias de un usuario conservando el registro de fallos
+func (ns *NotificationStore) SavePrefs(prefs NotificationPrefs) (NotificationPrefs, error) {
+	// El ID se usa como nombre de archivo: solo se aceptan IDs de Discord
+	if !validUserID(prefs.UserID) {
+		return NotificationPrefs{}, fmt.Errorf("ID de usuario inválido: %s", prefs.UserID)
+	}
+
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	if existing, ok := ns.prefs[prefs.UserID]; ok {
+		prefs.DMFailures = existing.DMFailures
+		prefs.LastDMError = existing.LastDMError
+		prefs.LastDMErrorAt = existing.LastDMErrorAt
+	}
+	prefs.UpdatedAt = time.Now()
+
+	if err := ns.savePrefsNoLock(&prefs); err != nil {
+		return NotificationPrefs{}, err
+	}
+	ns.prefs[prefs.UserID] = &prefs
+	return prefs, nil
+}
+
+// RecordDMFailure registra que no se pudo entregar un mensaje privado
+func (ns *NotificationStore) RecordDMFailure(userID, reason string) error {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	prefs, exists := ns.prefs[userID]
+	if !exists {
+		return fmt.Errorf("preferencias no encontradas: %s", userID)
+	}
+
+	now := time.Now()
+	prefs.DMFailures++
+	prefs.LastDMError = reason
+	prefs.LastDMErrorAt = &now
+	return ns.savePrefsNoLock(prefs)
+}
+
+// ClearDMFailures reinicia el contador de fallos tras una entrega exitosa
+func (ns *NotificationStore) ClearDMFailures(userID string) error {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	prefs, exists := ns.prefs[userID]
+	if !exists || prefs.DMFailures == 0 {
+		return nil
+	}
+
+	prefs.DMFailures = 0
+	return ns.savePrefsNoLock(prefs)
+}
+
+func (ns *NotificationStore) savePrefsNoLock(prefs *NotificationPrefs) error {
+	return ns.backend.SaveNotificationPrefs(prefs)
+}
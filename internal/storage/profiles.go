@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const profilesDir = "data/profiles"
+
+// Profile es el perfil persistente de un jugador con sus personajes
+type Profile struct {
+	UserID     string      `json:"user_id"`
+	Username   string      `json:"username"`
+	Characters []Character `json:"characters"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// Character es un personaje (main o alt) de un jugador
+type Character struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Class     string `json:"class"`
+	Role      string `json:"role,omitempty"`
+	ItemLevel int    `json:"item_level,omitempty"`
+	Notes     string `json:"notes,omitempty"`
+	Main      bool   `json:"main,omitempty"`
+}
+
+// FindCharacter busca un personaje por ID o por nombre (sin distinguir mayúsculas)
+func (p *Profile) FindCharacter(idOrName string) (Character, bool) {
+	for _, character := range p.Characters {
+		if character.ID == idOrName || strings.EqualFold(character.Name, idOrName) {
+			return character, true
+		}
+	}
+	return Character{}, false
+}
+
+// ProfileStore maneja el almacenamiento de perfiles de jugadores
+type ProfileStore struct {
+	mu       sync.RWMutex
+	profiles map[string]*Profile
+	backend  Backend
+}
+
+var Profiles *ProfileStore
+
+// InitProfileStore inicializa el almacenamiento de perfiles
+func InitProfileStore() error {
+	b, err := activeBackend()
+	if err != nil {
+		return err
+	}
+
+	Profiles = &ProfileStore{
+		profiles: make(map[string]*Profile),
+		backend:  b,
+	}
+
+	if err := Profiles.LoadProfiles(); err != nil {
+		log.Printf("Advertencia al cargar perfiles: %v", err)
+	}
+
+	log.Printf("✅ Sistema de perfiles inicializado con %d perfiles", len(Profiles.profiles))
+	return nil
+}
+
+// LoadProfiles carga todos los perfiles desde el backend
+func (ps *ProfileStore) LoadProfiles() error {
+	profiles, err := ps.backend.LoadProfiles()
+	if err != nil {
+		return err
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for _, profile := range profiles {
+		ps.profiles[profile.UserID] = profile
+	}
+
+	return nil
+}
+
+// GetProfile obtiene el perfil de un usuario
+func (ps *ProfileStore) GetProfile(userID string) (*Profile, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	profile, exists := ps.profiles[userID]
+	if !exists {
+		return nil, fmt.Errorf("perfil no encontrado: %s", userID)
+	}
+	return profile, nil
+}
+
+// GetAllProfiles retorna todos los perfiles
+func (ps *ProfileStore) GetAllProfiles() []*Profile {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	profiles := make([]*Profile, 0, len(ps.profiles))
+	for _, profile := range ps.profiles {
+		profiles = append(profiles, profile)
+	}
+	return profiles
+}
+
+// SaveCharacter agrega un personaje al perfil o reemplaza el que tenga el
+// mismo nombre. Crea el perfil si no existe. El primer personaje es el main.
+func (ps *ProfileStore) SaveCharacter(userID, username string, character Character) (*Profile, error) {
+	// El ID se usa como nombre de archivo: solo se aceptan IDs de Discord
+	if !validUserID(userID) {
+		return nil, fmt.Errorf("ID de usuario inválido: %s", userID)
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	profile, exists := ps.profiles[userID]
+	if !exists {
+		profile = &Profile{UserID: userID}
+	}
+	if username != "" {
+		profile.Username = username
+	}
+
+	replaced := false
+	for i, existing := range profile.Characters {
+		if strings.EqualFold(existing.Name, character.Name) {
+			character.ID = existing.ID
+			character.Main = character.Main || existing.Main
+			profile.Characters[i] = character
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		character.ID = uuid.New().String()[:8]
+		character.Main = character.Main || len(profile.Characters) == 0
+		profile.Characters = append(profile.Characters, character)
+	}
+
+	if character.Main {
+		setMainNoLock(profile, character.ID)
+	}
+
+	if err := ps.saveProfileNoLock(profile); err != nil {
+		return nil, err
+	}
+	ps.profiles[userID] = profile
+	return profile, nil
+}
+
+// RemoveCharacter quita un personaje del perfil. Si era el main, el
+// siguiente personaje pasa a serlo.
+func (ps *ProfileStore) RemoveCharacter(userID, name string) (*Profile, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	profile, exists := ps.profiles[userID]
+	if !exists {
+		return nil, fmt.Errorf("perfil no encontrado: %s", userID)
+	}
+
+	for i, character := range profile.Characters {
+		if !strings.EqualFold(character.Name, name) {
+			continue
+		}
+		profile.Characters = append(profile.Characters[:i], profile.Characters[i+1:]...)
+		if character.Main && len(profile.Characters) > 0 {
+			profile.Characters[0].Main = true
+		}
+		return profile, ps.saveProfileNoLock(profile)
+	}
+
+	return nil, fmt.Errorf("personaje no encontrado: %s", name)
+}
+
+// SetMainCharacter marca un personaje como main
+func (ps *ProfileStore) SetMainCharacter(userID, name string) (*Profile, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	profile, exists := ps.profiles[userID]
+	if !exists {
+		return nil, fmt.Errorf("perfil no encontrado: %s", userID)
+	}
+
+	character, ok := profile.FindCharacter(name)
+	if !ok {
+		return nil, fmt.Errorf("personaje no encontrado: %s", name)
+	}
+
+	setMainNoLock(profile, character.ID)
+	return profile, ps.saveProfileNoLock(profile)
+}
+
+func validUserID(userID string) bool {
+	if userID == "" || len(userID) > 32 {
+		return false
+	}
+	for _, r := range userID {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func setMainNoLock(profile *Profile, characterID string) {
+	for i := range profile.Characters {
+		profile.Characters[i].Main = profile.Characters[i].ID == characterID
+	}
+}
+
+func (ps *ProfileStore) saveProfileNoLock(profile *Profile) error {
+	profile.UpdatedAt = time.Now()
+	return ps.backend.SaveProfile(profile)
+}
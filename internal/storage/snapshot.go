@@ -0,0 +1,434 @@
+package storage
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Carpetas dentro del snapshot; replican la estructura de data/
+const (
+	snapshotEventsDir        = "events"
+	snapshotTemplatesDir     = "templates"
+	snapshotTokensDir        = "tokens"
+	snapshotProfilesDir      = "profiles"
+	snapshotNotificationsDir = "notifications"
+	snapshotGuildsDir        = "guilds"
+	snapshotCalendarsDir     = "calendars"
+	snapshotManifest         = "manifest.json"
+)
+
+// Versión del formato de snapshot. La 1 solo tenía eventos y templates.
+const snapshotVersion = 2
+
+// SnapshotManifest describe el contenido de un snapshot
+type SnapshotManifest struct {
+	Version       int       `json:"version,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	Events        int       `json:"events"`
+	Templates     int       `json:"templates"`
+	Tokens        int       `json:"tokens"`
+	Profiles      int       `json:"profiles"`
+	Notifications int       `json:"notifications"`
+	Guilds        int       `json:"guilds"`
+	Calendars     int       `json:"calendars"`
+}
+
+// SnapshotData son todos los datos persistidos del bot
+type SnapshotData struct {
+	Events        []*Event
+	Templates     []*EventTemplate
+	Tokens        []*APIToken
+	Profiles      []*Profile
+	Notifications []*NotificationPrefs
+	Guilds        []*GuildSettings
+	Calendars     []*CalendarFeed
+}
+
+// count completa en el manifest la cantidad de registros de cada tipo
+func (d *SnapshotData) count(manifest *SnapshotManifest) {
+	manifest.Events = len(d.Events)
+	manifest.Templates = len(d.Templates)
+	manifest.Tokens = len(d.Tokens)
+	manifest.Profiles = len(d.Profiles)
+	manifest.Notifications = len(d.Notifications)
+	manifest.Guilds = len(d.Guilds)
+	manifest.Calendars = len(d.Calendars)
+}
+
+// lockAllStores toma el lock de escritura de todos los stores y devuelve la
+// función que los libera. El orden es fijo para no cruzarse con otro snapshot.
+func lockAllStores() func() {
+	Store.mu.Lock()
+	Templates.mu.Lock()
+	Tokens.mu.Lock()
+	Profiles.mu.Lock()
+	Notifications.mu.Lock()
+	Guilds.mu.Lock()
+	Calendars.mu.Lock()
+
+	return func() {
+		Calendars.mu.Unlock()
+		Guilds.mu.Unlock()
+		Notifications.mu.Unlock()
+		Profiles.mu.Unlock()
+		Tokens.mu.Unlock()
+		Templates.mu.Unlock()
+		Store.mu.Unlock()
+	}
+}
+
+// rLockAllStores es lockAllStores con locks de lectura
+func rLockAllStores() func() {
+	Store.mu.RLock()
+	Templates.mu.RLock()
+	Tokens.mu.RLock()
+	Profiles.mu.RLock()
+	Notifications.mu.RLock()
+	Guilds.mu.RLock()
+	Calendars.mu.RLock()
+
+	return func() {
+		Calendars.mu.RUnlock()
+		Guilds.mu.RUnlock()
+		Notifications.mu.RUnlock()
+		Profiles.mu.RUnlock()
+		Tokens.mu.RUnlock()
+		Templates.mu.RUnlock()
+		Store.mu.RUnlock()
+	}
+}
+
+func storesReady() bool {
+	return Store != nil && Templates != nil && Tokens != nil && Profiles != nil &&
+		Notifications != nil && Guilds != nil && Calendars != nil
+}
+
+// currentDataNoLock arma los datos vigentes a partir de los stores en memoria
+func currentDataNoLock() *SnapshotData {
+	data := &SnapshotData{}
+	for _, event := range Store.events {
+		data.Events = append(data.Events, event)
+	}
+	for _, template := range Templates.templates {
+		data.Templates = append(data.Templates, template)
+	}
+	for _, token := range Tokens.tokens {
+		data.Tokens = append(data.Tokens, token)
+	}
+	for _, profile := range Profiles.profiles {
+		data.Profiles = append(data.Profiles, profile)
+	}
+	for _, prefs := range Notifications.prefs {
+		data.Notifications = append(data.Notifications, prefs)
+	}
+	for _, settings := range Guilds.guilds {
+		data.Guilds = append(data.Guilds, settings)
+	}
+	for _, feed := range Calendars.feeds {
+		data.Calendars = append(data.Calendars, feed)
+	}
+	return data
+}
+
+// WriteSnapshot escribe un tar.gz con todos los datos del bot: eventos,
+// templates, tokens, perfiles, preferencias, servidores y calendarios.
+// Mantiene los locks de lectura de todos los stores mientras serializa para
+// que el snapshot sea una vista consistente aunque haya inscripciones en curso.
+func WriteSnapshot(w io.Writer) (*SnapshotManifest, error) {
+	if !storesReady() {
+		return nil, fmt.Errorf("almacenamiento no inicializado")
+	}
+
+	unlock := rLockAllStores()
+	defer unlock()
+
+	data := currentDataNoLock()
+	manifest := &SnapshotManifest{Version: snapshotVersion, CreatedAt: time.Now()}
+	data.count(manifest)
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	for _, entry := range data.entries() {
+		if err := writeSnapshotEntry(tw, entry.name, entry.record, manifest.CreatedAt); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeSnapshotEntry(tw, snapshotManifest, manifest, manifest.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("error cerrando snapshot: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("error comprimiendo snapshot: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// snapshotEntry es un archivo del snapshot con el registro que contiene
+type snapshotEntry struct {
+	name   string
+	record any
+}
+
+// entries devuelve los archivos del snapshot, uno por registro
+func (d *SnapshotData) entries() []snapshotEntry {
+	var entries []snapshotEntry
+	add := func(dir, file string, record any) {
+		entries = append(entries, snapshotEntry{path.Join(dir, file+".json"), record})
+	}
+
+	for _, event := range d.Events {
+		add(snapshotEventsDir, event.ID, event)
+	}
+	for _, template := range d.Templates {
+		add(snapshotTemplatesDir, templateFilename(template), template)
+	}
+	for _, token := range d.Tokens {
+		add(snapshotTokensDir, token.ID, token)
+	}
+	for _, profile := range d.Profiles {
+		add(snapshotProfilesDir, profile.UserID, profile)
+	}
+	for _, prefs := range d.Notifications {
+		add(snapshotNotificationsDir, prefs.UserID, prefs)
+	}
+	for _, settings := range d.Guilds {
+		add(snapshotGuildsDir, settings.GuildID, settings)
+	}
+	for _, feed := range d.Calendars {
+		add(snapshotCalendarsDir, calendarKey(feed.GuildID, feed.UserID), feed)
+	}
+	return entries
+}
+
+func writeSnapshotEntry(tw *tar.Writer, name string, v any, modTime time.Time) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializando %s: %w", name, err)
+	}
+
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("error escribiendo %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("error escribiendo %s: %w", name, err)
+	}
+	return nil
+}
+
+// RestoreSnapshot reemplaza todos los datos del bot por los del snapshot.
+// El snapshot se valida completo antes de tocar nada y el backend lo aplica
+// de una vez (ReplaceAll): si algo falla, los datos anteriores quedan
+// intactos. Solo entonces se reemplazan los stores en memoria. Los
+// snapshots de versión 1 no traen tokens, perfiles, preferencias,
+// servidores ni calendarios: esos datos se conservan como están.
+func RestoreSnapshot(r io.Reader) (*SnapshotManifest, error) {
+	if !storesReady() {
+		return nil, fmt.Errorf("almacenamiento no inicializado")
+	}
+
+	data, manifest, err := readSnapshot(r)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := lockAllStores()
+	defer unlock()
+
+	if manifest.Version < 2 {
+		current := currentDataNoLock()
+		data.Tokens = current.Tokens
+		data.Profiles = current.Profiles
+		data.Notifications = current.Notifications
+		data.Guilds = current.Guilds
+		data.Calendars = current.Calendars
+	}
+
+	if err := Store.backend.ReplaceAll(data); err != nil {
+		return nil, fmt.Errorf("error restaurando snapshot: %w", err)
+	}
+
+	Store.events = make(map[string]*Event, len(data.Events))
+	for _, event := range data.Events {
+		Store.events[event.ID] = event
+	}
+	Templates.templates = make(map[string]*EventTemplate, len(data.Templates))
+	for _, template := range data.Templates {
+		Templates.templates[template.Key()] = template
+	}
+	Tokens.tokens = make(map[string]*APIToken, len(data.Tokens))
+	for _, token := range data.Tokens {
+		Tokens.tokens[token.ID] = token
+	}
+	Profiles.profiles = make(map[string]*Profile, len(data.Profiles))
+	for _, profile := range data.Profiles {
+		Profiles.profiles[profile.UserID] = profile
+	}
+	Notifications.prefs = make(map[string]*NotificationPrefs, len(data.Notifications))
+	for _, prefs := range data.Notifications {
+		Notifications.prefs[prefs.UserID] = prefs
+	}
+	Guilds.guilds = make(map[string]*GuildSettings, len(data.Guilds))
+	for _, settings := range data.Guilds {
+		Guilds.guilds[settings.GuildID] = settings
+	}
+	Calendars.feeds = make(map[string]*CalendarFeed, len(data.Calendars))
+	for _, feed := range data.Calendars {
+		Calendars.feeds[calendarKey(feed.GuildID, feed.UserID)] = feed
+	}
+
+	data.count(manifest)
+	return manifest, nil
+}
+
+func readSnapshot(r io.Reader) (*SnapshotData, *SnapshotManifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("snapshot inválido: %w", err)
+	}
+	defer gz.Close()
+
+	data := &SnapshotData{}
+	manifest := &SnapshotManifest{}
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("snapshot inválido: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error leyendo %s: %w", header.Name, err)
+		}
+
+		dir, file := path.Split(path.Clean(header.Name))
+		dir = strings.TrimSuffix(dir, "/")
+		if header.Name == snapshotManifest {
+			if err := json.Unmarshal(content, manifest); err != nil {
+				return nil, nil, fmt.Errorf("manifest inválido: %w", err)
+			}
+			continue
+		}
+		if !strings.HasSuffix(file, ".json") {
+			continue
+		}
+
+		if err := data.decodeEntry(dir, content); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", header.Name, err)
+		}
+	}
+
+	return data, manifest, nil
+}
+
+// decodeEntry valida un archivo del snapshot y lo agrega a los datos
+func (d *SnapshotData) decodeEntry(dir string, content []byte) error {
+	switch dir {
+	case snapshotEventsDir:
+		var event Event
+		if err := json.Unmarshal(content, &event); err != nil {
+			return fmt.Errorf("evento inválido: %w", err)
+		}
+		if !validRecordID(event.ID) {
+			return fmt.Errorf("evento con ID inválido: %q", event.ID)
+		}
+		d.Events = append(d.Events, &event)
+	case snapshotTemplatesDir:
+		var template EventTemplate
+		if err := json.Unmarshal(content, &template); err != nil {
+			return fmt.Errorf("template inválido: %w", err)
+		}
+		if template.Name == "" {
+			return fmt.Errorf("template sin nombre")
+		}
+		if template.GuildID != "" && !validUserID(template.GuildID) {
+			return fmt.Errorf("template con servidor inválido: %q", template.GuildID)
+		}
+		d.Templates = append(d.Templates, &template)
+	case snapshotTokensDir:
+		var token APIToken
+		if err := json.Unmarshal(content, &token); err != nil {
+			return fmt.Errorf("token inválido: %w", err)
+		}
+		if !validRecordID(token.ID) || token.Hash == "" {
+			return fmt.Errorf("token con ID inválido o sin hash: %q", token.ID)
+		}
+		d.Tokens = append(d.Tokens, &token)
+	case snapshotProfilesDir:
+		var profile Profile
+		if err := json.Unmarshal(content, &profile); err != nil {
+			return fmt.Errorf("perfil inválido: %w", err)
+		}
+		if !validUserID(profile.UserID) {
+			return fmt.Errorf("perfil con ID de usuario inválido: %q", profile.UserID)
+		}
+		d.Profiles = append(d.Profiles, &profile)
+	case snapshotNotificationsDir:
+		var prefs NotificationPrefs
+		if err := json.Unmarshal(content, &prefs); err != nil {
+			return fmt.Errorf("preferencias inválidas: %w", err)
+		}
+		if !validUserID(prefs.UserID) {
+			return fmt.Errorf("preferencias con ID de usuario inválido: %q", prefs.UserID)
+		}
+		d.Notifications = append(d.Notifications, &prefs)
+	case snapshotGuildsDir:
+		var settings GuildSettings
+		if err := json.Unmarshal(content, &settings); err != nil {
+			return fmt.Errorf("servidor inválido: %w", err)
+		}
+		if !validUserID(settings.GuildID) {
+			return fmt.Errorf("servidor con ID inválido: %q", settings.GuildID)
+		}
+		d.Guilds = append(d.Guilds, &settings)
+	case snapshotCalendarsDir:
+		var feed CalendarFeed
+		if err := json.Unmarshal(content, &feed); err != nil {
+			return fmt.Errorf("calendario inválido: %w", err)
+		}
+		if feed.Token == "" || !validUserID(feed.GuildID) || !validUserID(feed.UserID) {
+			return fmt.Errorf("calendario sin token o con IDs inválidos")
+		}
+		d.Calendars = append(d.Calendars, &feed)
+	}
+	return nil
+}
+
+// validRecordID indica si el ID de un evento o token se puede usar como
+// nombre de archivo: los UUID que genera el bot solo usan letras, números y
+// guiones. Así un snapshot manipulado no puede escribir fuera de data/.
+func validRecordID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Los stores son globales y el backend JSON usa rutas relativas a data/:
+// las pruebas corren sobre un directorio temporal para no tocar el repositorio
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "storage-test")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		log.Fatal(err)
+	}
+	if err := InitBackend("json", ""); err != nil {
+		log.Fatal(err)
+	}
+	for _, init := range []func() error{
+		InitEventStore, InitTemplateStore, InitTokenStore, InitProfileStore,
+		InitNotificationStore, InitGuildStore, InitCalendarStore,
+	} {
+		if err := init(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	kept := &Event{ID: "snapshot-kept", Name: "Raid", Status: "active", DateTime: time.Now()}
+	if err := Store.SaveEvent(kept); err != nil {
+		t.Fatalf("SaveEvent: %v", err)
+	}
+	token, _, err := Tokens.CreateToken("snapshot", []string{ScopeReadEvents}, "test")
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+
+	var snapshot bytes.Buffer
+	if _, err := WriteSnapshot(&snapshot); err != nil {
+		t.Fatalf("WriteSnapshot: %v", err)
+	}
+
+	// Cambios posteriores al snapshot que la restauración tiene que deshacer
+	if err := Store.DeleteEvent(kept.ID); err != nil {
+		t.Fatalf("DeleteEvent: %v", err)
+	}
+	later := &Event{ID: "snapshot-later", Name: "Después", Status: "active", DateTime: time.Now()}
+	if err := Store.SaveEvent(later); err != nil {
+		t.Fatalf("SaveEvent: %v", err)
+	}
+
+	manifest, err := RestoreSnapshot(&snapshot)
+	if err != nil {
+		t.Fatalf("RestoreSnapshot: %v", err)
+	}
+	if manifest.Version != snapshotVersion || manifest.Tokens == 0 {
+		t.Errorf("manifest = %+v", manifest)
+	}
+
+	if event, err := Store.GetEvent(kept.ID); err != nil || event.Name != "Raid" {
+		t.Errorf("el evento del snapshot no se restauró: %v", err)
+	}
+	if _, err := Store.GetEvent(later.ID); err == nil {
+		t.Error("el evento creado después del snapshot sigue en memoria")
+	}
+	if _, err := os.Stat(filepath.Join(eventsDir, later.ID+".json")); !os.IsNotExist(err) {
+		t.Errorf("el evento creado después del snapshot sigue en disco: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tokensDir, token.ID+".json")); err != nil {
+		t.Errorf("el token no se restauró en disco: %v", err)
+	}
+}
+
+func TestRestoreRejectsBadSnapshots(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		raw   []byte // contenido que no es un tar.gz
+	}{
+		{name: "no es un tar.gz", raw: []byte("hola")},
+		{name: "JSON inválido", files: map[string]string{"events/a.json": "{"}},
+		{name: "evento sin ID", files: map[string]string{"events/a.json": `{"name":"Raid"}`}},
+		{name: "evento que sale del directorio", files: map[string]string{"events/a.json": `{"id":"../templates/x"}`}},
+		{name: "evento con ruta absoluta", files: map[string]string{"events/a.json": `{"id":"/tmp/x"}`}},
+		{name: "token que sale de data", files: map[string]string{"tokens/a.json": `{"id":"../../foo","hash":"h"}`}},
+		{name: "token sin hash", files: map[string]string{"tokens/a.json": `{"id":"abc"}`}},
+		{name: "perfil con ID inválido", files: map[string]string{"profiles/a.json": `{"user_id":"../x"}`}},
+		{name: "template con servidor inválido", files: map[string]string{"templates/a.json": `{"name":"Raid","guild_id":"../x"}`}},
+		{name: "calendario sin token", files: map[string]string{"calendars/a.json": `{"guild_id":"1","user_id":"2"}`}},
+	}
+
+	existing := &Event{ID: "bad-snapshot-existing", Name: "Raid", Status: "active", DateTime: time.Now()}
+	if err := Store.SaveEvent(existing); err != nil {
+		t.Fatalf("SaveEvent: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := tt.raw
+			if archive == nil {
+				archive = buildSnapshot(t, tt.files)
+			}
+
+			if _, err := RestoreSnapshot(bytes.NewReader(archive)); err == nil {
+				t.Fatal("RestoreSnapshot aceptó un snapshot inválido")
+			}
+			if _, err := Store.GetEvent(existing.ID); err != nil {
+				t.Errorf("un snapshot rechazado borró los datos actuales: %v", err)
+			}
+			for _, outside := range []string{"data/templates/x.json", "foo.json", "/tmp/x.json"} {
+				if _, err := os.Stat(outside); err == nil {
+					t.Errorf("se escribió %s fuera del directorio de datos", outside)
+				}
+			}
+		})
+	}
+}
+
+// buildSnapshot arma un tar.gz con los archivos indicados y un manifest de la versión actual
+func buildSnapshot(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	files[snapshotManifest] = `{"version":2}`
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestValidRecordID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"0f8fad5b-d9cb-469f-a165-70867728950e", true},
+		{"serie_1", true},
+		{"", false},
+		{"..", false},
+		{"../x", false},
+		{`a\b`, false},
+		{"a.json", false},
+		{strings.Repeat("a", 65), false},
+	}
+
+	for _, tt := range tests {
+		if got := validRecordID(tt.id); got != tt.want {
+			t.Errorf("validRecordID(%q) = %v, se esperaba %v", tt.id, got, tt.want)
+		}
+	}
+}
@@ -3,27 +3,27 @@ package storage
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"os"
-	"path/filepath"
 	"sync"
-
-	"gopkg.in/yaml.v3"
 )
 
 const templatesDir = "data/templates"
 
 // EventTemplate representa un template reutilizable para eventos
 type EventTemplate struct {
-	Name             string         `json:"name" yaml:"name"`
-	Icon             string         `json:"icon" yaml:"icon"`
-	MaxParticipants  int            `json:"max_participants" yaml:"max_participants"`
-	Description      string         `json:"description" yaml:"description"`
-	Roles            []TemplateRole `json:"roles" yaml:"roles"`
-	AllowMultiSignup bool           `json:"allow_multi_signup" yaml:"allow_multi_signup"`
-	CreatedAt        string         `json:"created_at" yaml:"created_at"`
-	UpdatedAt        string         `json:"updated_at" yaml:"updated_at"`
+	Name             string              `json:"name" yaml:"name"`
+	GuildID          string              `json:"guild_id,omitempty" yaml:"guild_id,omitempty"` // vacío = compartido por todos los servidores
+	Icon             string              `json:"icon" yaml:"icon"`
+	MaxParticipants  int                 `json:"max_participants" yaml:"max_participants"`
+	Description      string              `json:"description" yaml:"description"`
+	Roles            []TemplateRole      `json:"roles" yaml:"roles"`
+	AllowMultiSignup bool                `json:"allow_multi_signup" yaml:"allow_multi_signup"`
+	RequireApproval  bool                `json:"require_approval" yaml:"require_approval"`
+	Requirements     *SignupRequirements `json:"requirements,omitempty" yaml:"requirements,omitempty"`
+	ReminderOffsets  []int               `json:"reminder_offsets,omitempty" yaml:"reminder_offsets,omitempty"` // minutos antes del evento
+	FillIn           *FillInSettings     `json:"fill_in,omitempty" yaml:"fill_in,omitempty"`
+	CreatedAt        string              `json:"created_at" yaml:"created_at"`
+	UpdatedAt        string              `json:"updated_at" yaml:"updated_at"`
 }
 
 // TemplateRole representa un rol dentro de un template
@@ -39,25 +39,51 @@ type TemplateClass struct {
 	Name        string `json:"name" yaml:"name"`
 	Emoji       string `json:"emoji" yaml:"emoji"`
 	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Limit       int    `json:"limit,omitempty" yaml:"limit,omitempty"`
+}
+
+// TemplateKey identifica un template: el mismo nombre puede existir una vez
+// como compartido y una vez en cada servidor
+func TemplateKey(guildID, name string) string {
+	if guildID == "" {
+		return name
+	}
+	return guildID + "/" + name
+}
+
+// Key devuelve la clave del template en el store
+func (t *EventTemplate) Key() string {
+	return TemplateKey(t.GuildID, t.Name)
+}
+
+// templateFilename devuelve el nombre de archivo (sin extensión) del
+// template; los de un servidor llevan su ID adelante
+func templateFilename(template *EventTemplate) string {
+	if template.GuildID == "" {
+		return sanitizeFilename(template.Name)
+	}
+	return template.GuildID + "_" + sanitizeFilename(template.Name)
 }
 
 // TemplateStore maneja el almacenamiento de templates
 type TemplateStore struct {
 	mu        sync.RWMutex
-	templates map[string]*EventTemplate
+	templates map[string]*EventTemplate // por TemplateKey
+	backend   Backend
 }
 
 var Templates *TemplateStore
 
 // InitTemplateStore inicializa el almacenamiento de templates
 func InitTemplateStore() error {
-	Templates = &TemplateStore{
-		templates: make(map[string]*EventTemplate),
+	b, err := activeBackend()
+	if err != nil {
+		return err
 	}
 
-	// Crear directorio de templates si no existe
-	if err := os.MkdirAll(templatesDir, 0755); err != nil {
-		return fmt.Errorf("error creando directorio de templates: %w", err)
+	Templates = &TemplateStore{
+		templates: make(map[string]*EventTemplate),
+		backend:   b,
 	}
 
 	// Cargar templates existentes
@@ -86,20 +112,10 @@ func (ts *TemplateStore) SaveTemplate(template *EventTemplate) error {
 		return err
 	}
 
-	ts.templates[template.Name] = template
+	ts.templates[template.Key()] = template
 
 	// Guardar como JSON
-	filename := filepath.Join(templatesDir, fmt.Sprintf("%s.json", sanitizeFilename(template.Name)))
-	data, err := json.MarshalIndent(template, "", "  ")
-	if err != nil {
-		return fmt.Errorf("error serializando template: %w", err)
-	}
-
-	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
-		return fmt.Errorf("error escribiendo archivo: %w", err)
-	}
-
-	return nil
+	return ts.backend.SaveTemplate(template, TemplateFormatJSON)
 }
 
 // SaveTemplateYAML guarda un template en formato YAML
@@ -112,27 +128,17 @@ func (ts *TemplateStore) SaveTemplateYAML(template *EventTemplate) error {
 		return err
 	}
 
-	ts.templates[template.Name] = template
+	ts.templates[template.Key()] = template
 
-	filename := filepath.Join(templatesDir, fmt.Sprintf("%s.yaml", sanitizeFilename(template.Name)))
-	data, err := yaml.Marshal(template)
-	if err != nil {
-		return fmt.Errorf("error serializando template a YAML: %w", err)
-	}
-
-	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
-		return fmt.Errorf("error escribiendo archivo YAML: %w", err)
-	}
-
-	return nil
+	return ts.backend.SaveTemplate(template, TemplateFormatYAML)
 }
 
-// GetTemplate obtiene un template por nombre
-func (ts *TemplateStore) GetTemplate(name string) (*EventTemplate, error) {
+// GetTemplate obtiene un template por servidor y nombre; "" = compartido
+func (ts *TemplateStore) GetTemplate(guildID, name string) (*EventTemplate, error) {
 	ts.mu.RLock()
 	defer ts.mu.RUnlock()
 
-	template, exists := ts.templates[name]
+	template, exists := ts.templates[TemplateKey(guildID, name)]
 	if !exists {
 		return nil, fmt.Errorf("template no encontrado: %s", name)
 	}
@@ -154,59 +160,31 @@ func (ts *TemplateStore) GetAllTemplates() []*EventTemplate {
 }
 
 // DeleteTemplate elimina un template
-func (ts *TemplateStore) DeleteTemplate(name string) error {
+func (ts *TemplateStore) DeleteTemplate(guildID, name string) error {
 	ts.mu.Lock()
 	defer ts.mu.Unlock()
 
-	delete(ts.templates, name)
-
-	// Intentar eliminar tanto JSON como YAML
-	jsonFile := filepath.Join(templatesDir, fmt.Sprintf("%s.json", sanitizeFilename(name)))
-	yamlFile := filepath.Join(templatesDir, fmt.Sprintf("%s.yaml", sanitizeFilename(name)))
-
-	os.Remove(jsonFile)
-	os.Remove(yamlFile)
+	template, exists := ts.templates[TemplateKey(guildID, name)]
+	if !exists {
+		return nil
+	}
+	delete(ts.templates, template.Key())
 
-	return nil
+	return ts.backend.DeleteTemplate(template)
 }
 
-// LoadTemplates carga todos los templates desde disco
+// LoadTemplates carga todos los templates desde el backend
 func (ts *TemplateStore) LoadTemplates() error {
-	files, err := os.ReadDir(templatesDir)
+	templates, err := ts.backend.LoadTemplates()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return fmt.Errorf("error leyendo directorio de templates: %w", err)
+		return err
 	}
 
-	for _, file := range files {
-		ext := filepath.Ext(file.Name())
-		if ext != ".json" && ext != ".yaml" && ext != ".yml" {
-			continue
-		}
-
-		filename := filepath.Join(templatesDir, file.Name())
-		data, err := os.ReadFile(filename)
-		if err != nil {
-			log.Printf("Error leyendo archivo %s: %v", filename, err)
-			continue
-		}
-
-		var template EventTemplate
-		if ext == ".json" {
-			if err := json.Unmarshal(data, &template); err != nil {
-				log.Printf("Error parseando JSON %s: %v", filename, err)
-				continue
-			}
-		} else {
-			if err := yaml.Unmarshal(data, &template); err != nil {
-				log.Printf("Error parseando YAML %s: %v", filename, err)
-				continue
-			}
-		}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
 
-		ts.templates[template.Name] = &template
+	for _, template := range templates {
+		ts.templates[template.Key()] = template
 	}
 
 	log.Printf("📦 Cargados %d templates desde disco", len(ts.templates))
@@ -219,6 +197,10 @@ func (ts *TemplateStore) validateTemplate(template *EventTemplate) error {
 		return fmt.Errorf("el nombre del template es requerido")
 	}
 
+	if template.GuildID != "" && !validUserID(template.GuildID) {
+		return fmt.Errorf("servidor inválido: %q", template.GuildID)
+	}
+
 	if len(template.Roles) == 0 {
 		return fmt.Errorf("el template debe tener al menos un rol")
 	}
@@ -234,6 +216,14 @@ func (ts *TemplateStore) validateTemplate(template *EventTemplate) error {
 		if role.Limit > 0 {
 			totalLimit += role.Limit
 		}
+		for _, class := range role.Classes {
+			if class.Limit < 0 {
+				return fmt.Errorf("el límite de la clase %s no puede ser negativo", class.Name)
+			}
+			if role.Limit > 0 && class.Limit > role.Limit {
+				return fmt.Errorf("el límite de la clase %s (%d) excede el límite del rol %s (%d)", class.Name, class.Limit, role.Name, role.Limit)
+			}
+		}
 	}
 
 	if template.MaxParticipants > 0 && totalLimit > template.MaxParticipants {
@@ -385,16 +375,12 @@ func sanitizeFilename(name string) string {
 	return result
 }
 
-// CloneTemplate crea una copia de un template con un nuevo nombre
-func (ts *TemplateStore) CloneTemplate(sourceName, newName string) error {
+// CloneTemplate crea una copia de un template con un nuevo nombre en el
+// servidor indicado ("" = compartido)
+func (ts *TemplateStore) CloneTemplate(source *EventTemplate, newName, guildID string) error {
 	ts.mu.Lock()
 	defer ts.mu.Unlock()
 
-	source, exists := ts.templates[sourceName]
-	if !exists {
-		return fmt.Errorf("template fuente no encontrado: %s", sourceName)
-	}
-
 	// Crear copia profunda
 	data, err := json.Marshal(source)
 	if err != nil {
@@ -407,37 +393,22 @@ func (ts *TemplateStore) CloneTemplate(sourceName, newName string) error {
 	}
 
 	clone.Name = newName
-	ts.templates[newName] = &clone
+	clone.GuildID = guildID
+	ts.templates[clone.Key()] = &clone
 
 	// Guardar el clon
-	filename := filepath.Join(templatesDir, fmt.Sprintf("%s.json", sanitizeFilename(newName)))
-	cloneData, err := json.MarshalIndent(&clone, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	return ioutil.WriteFile(filename, cloneData, 0644)
+	return ts.backend.SaveTemplate(&clone, TemplateFormatJSON)
 }
 
 // ExportTemplate exporta un template a JSON
-func (ts *TemplateStore) ExportTemplate(name string) ([]byte, error) {
+func (ts *TemplateStore) ExportTemplate(guildID, name string) ([]byte, error) {
 	ts.mu.RLock()
 	defer ts.mu.RUnlock()
 
-	template, exists := ts.templates[name]
+	template, exists := ts.templates[TemplateKey(guildID, name)]
 	if !exists {
 		return nil, fmt.Errorf("template no encontrado: %s", name)
 	}
 
 	return json.MarshalIndent(template, "", "  ")
 }
-
-// ImportTemplate importa un template desde JSON
-func (ts *TemplateStore) ImportTemplate(data []byte) error {
-	var template EventTemplate
-	if err := json.Unmarshal(data, &template); err != nil {
-		return fmt.Errorf("error parseando JSON: %w", err)
-	}
-
-	return ts.SaveTemplate(&template)
-}
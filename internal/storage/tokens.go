@@ -0,0 +1,243 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const tokensDir = "data/tokens"
+
+// Prefijo de los tokens generados; facilita reconocerlos en logs y secretos filtrados
+const tokenPrefix = "meb_"
+
+// Cada cuánto se persiste LastUsedAt para no escribir en disco en cada request
+const tokenUsageFlushInterval = time.Minute
+
+// Scopes disponibles para los tokens de la API
+const (
+	ScopeReadEvents     = "read:events"
+	ScopeWriteEvents    = "write:events"
+	ScopeWriteTemplates = "write:templates"
+	ScopeAdmin          = "admin"
+)
+
+// AllScopes lista los scopes válidos en el orden en que se muestran en el panel
+var AllScopes = []string{ScopeReadEvents, ScopeWriteEvents, ScopeWriteTemplates, ScopeAdmin}
+
+// APIToken representa un token de acceso a la API. Solo se guarda el hash
+// del secreto; el valor en claro se muestra una única vez al crearlo.
+type APIToken struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Hash       string     `json:"hash"`
+	Hint       string     `json:"hint"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	CreatedBy  string     `json:"created_by"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasScope indica si el token tiene un scope; admin los incluye a todos
+func (t *APIToken) HasScope(scope string) bool {
+	return ScopesAllow(t.Scopes, scope)
+}
+
+// ScopesAllow indica si un conjunto de scopes habilita otro; admin los incluye a todos
+func ScopesAllow(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Revoked indica si el token fue revocado
+func (t *APIToken) Revoked() bool {
+	return t.RevokedAt != nil
+}
+
+// TokenStore maneja el almacenamiento de tokens de la API
+type TokenStore struct {
+	mu      sync.RWMutex
+	tokens  map[string]*APIToken
+	backend Backend
+	flushed map[string]time.Time
+}
+
+var Tokens *TokenStore
+
+// InitTokenStore inicializa el almacenamiento de tokens
+func InitTokenStore() error {
+	b, err := activeBackend()
+	if err != nil {
+		return err
+	}
+
+	Tokens = &TokenStore{
+		tokens:  make(map[string]*APIToken),
+		backend: b,
+		flushed: make(map[string]time.Time),
+	}
+
+	if err := Tokens.LoadTokens(); err != nil {
+		log.Printf("Advertencia al cargar tokens: %v", err)
+	}
+
+	log.Printf("✅ Sistema de tokens inicializado con %d tokens", len(Tokens.tokens))
+	return nil
+}
+
+// LoadTokens carga todos los tokens desde el backend
+func (ts *TokenStore) LoadTokens() error {
+	tokens, err := ts.backend.LoadTokens()
+	if err != nil {
+		return err
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	for _, token := range tokens {
+		ts.tokens[token.ID] = token
+	}
+
+	return nil
+}
+
+// CreateToken genera un token nuevo y devuelve el secreto en claro.
+// El secreto no se puede recuperar después.
+func (ts *TokenStore) CreateToken(name string, scopes []string, createdBy string) (*APIToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", fmt.Errorf("el nombre del token es obligatorio")
+	}
+	if len(scopes) == 0 {
+		return nil, "", fmt.Errorf("el token debe tener al menos un scope")
+	}
+	for _, scope := range scopes {
+		if !validScope(scope) {
+			return nil, "", fmt.Errorf("scope desconocido: %s", scope)
+		}
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", fmt.Errorf("error generando token: %w", err)
+	}
+	secret := tokenPrefix + hex.EncodeToString(raw)
+
+	token := &APIToken{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Hash:      hashToken(secret),
+		Hint:      secret[:len(tokenPrefix)+6],
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+		CreatedBy: createdBy,
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	for _, existing := range ts.tokens {
+		if existing.Name == name && !existing.Revoked() {
+			return nil, "", fmt.Errorf("ya existe un token activo llamado %s", name)
+		}
+	}
+
+	if err := ts.saveTokenNoLock(token); err != nil {
+		return nil, "", err
+	}
+	ts.tokens[token.ID] = token
+
+	return token, secret, nil
+}
+
+// RevokeToken revoca un token. Se conserva en disco como registro.
+func (ts *TokenStore) RevokeToken(id string) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	token, exists := ts.tokens[id]
+	if !exists {
+		return fmt.Errorf("token no encontrado: %s", id)
+	}
+	if token.Revoked() {
+		return nil
+	}
+
+	now := time.Now()
+	token.RevokedAt = &now
+	return ts.saveTokenNoLock(token)
+}
+
+// GetAllTokens retorna todos los tokens, incluidos los revocados
+func (ts *TokenStore) GetAllTokens() []*APIToken {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	tokens := make([]*APIToken, 0, len(ts.tokens))
+	for _, token := range ts.tokens {
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// Authenticate busca el token activo que corresponde a un secreto y
+// registra su último uso
+func (ts *TokenStore) Authenticate(secret string) (*APIToken, error) {
+	hash := hashToken(secret)
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	var match *APIToken
+	for _, token := range ts.tokens {
+		if subtle.ConstantTimeCompare([]byte(token.Hash), []byte(hash)) == 1 {
+			match = token
+		}
+	}
+	if match == nil || match.Revoked() {
+		return nil, fmt.Errorf("token inválido")
+	}
+
+	now := time.Now()
+	match.LastUsedAt = &now
+	if now.Sub(ts.flushed[match.ID]) >= tokenUsageFlushInterval {
+		ts.flushed[match.ID] = now
+		if err := ts.saveTokenNoLock(match); err != nil {
+			log.Printf("Error guardando último uso del token %s: %v", match.Name, err)
+		}
+	}
+
+	return match, nil
+}
+
+func (ts *TokenStore) saveTokenNoLock(token *APIToken) error {
+	return ts.backend.SaveToken(token)
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func validScope(scope string) bool {
+	for _, s := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
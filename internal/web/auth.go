@@ -0,0 +1,156 @@
+package web
+
+import (
+	"crypto/subtle"
+	"discord-event-bot/config"
+	"discord-event-bot/internal/storage"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Claves del contexto de gin donde se guarda quién hizo la request
+const (
+	actorContextKey   = "actor"
+	tokenContextKey   = "api_token"
+	sessionContextKey = "session"
+	scopesContextKey  = "scopes"
+)
+
+// Actor usado cuando se entra con el usuario y contraseña del .env
+const basicAuthActor = "admin_web"
+
+// Rutas que solo puede usar un administrador
+var adminPaths = []string{"/api/backups", "/backups", "/api/tokens", "/tokens", "/api/guilds", "/guilds", "/config"}
+
+// authMiddleware acepta tokens `Authorization: Bearer` con scopes, sesiones
+// del login con Discord y el usuario/contraseña del .env como acceso inicial
+// con todos los permisos. Cada request queda asociada a un actor que los
+// handlers usan como autor. Las sesiones de Discord tienen los permisos de
+// sus roles en el servidor elegido, así que guildMiddleware va antes.
+func authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var scopes []string
+
+		if secret, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+			token, err := storage.Tokens.Authenticate(strings.TrimSpace(secret))
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token inválido o revocado"})
+				return
+			}
+			scopes = token.Scopes
+			c.Set(tokenContextKey, token)
+			c.Set(actorContextKey, "token:"+token.Name)
+		} else if session, err := readSession(c); err == nil {
+			scopes = scopesForPanelRole(session.roleIn(currentGuild(c)))
+			c.Set(sessionContextKey, session)
+			c.Set(actorContextKey, session.UserID)
+		} else if user, pass, ok := c.Request.BasicAuth(); ok && validAdminCredentials(user, pass) {
+			scopes = []string{storage.ScopeAdmin}
+			c.Set(actorContextKey, basicAuthActor)
+		} else {
+			rejectUnauthenticated(c)
+			return
+		}
+
+		c.Set(scopesContextKey, scopes)
+
+		scope := requiredScope(c)
+		if !storage.ScopesAllow(scopes, scope) {
+			log.Printf("🔒 %s sin permiso %s para %s %s", requestActor(c), scope, c.Request.Method, c.Request.URL.Path)
+			if wantsHTML(c) {
+				c.HTML(http.StatusForbidden, "error.html", gin.H{
+					"title": "Acceso denegado",
+					"error": "No tienes permiso para acceder a esta sección",
+				})
+				c.Abort()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Falta el permiso " + scope})
+			return
+		}
+
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			log.Printf("🔑 %s %s %s", requestActor(c), c.Request.Method, c.Request.URL.Path)
+		}
+
+		c.Next()
+	}
+}
+
+// rejectUnauthenticated manda al login con Discord a los navegadores cuando
+// está habilitado; el resto recibe el desafío de autenticación básica
+func rejectUnauthenticated(c *gin.Context) {
+	if config.AppConfig.OAuth.Enabled() && wantsHTML(c) {
+		c.Redirect(http.StatusSeeOther, "/login")
+		c.Abort()
+		return
+	}
+
+	c.Header("WWW-Authenticate", `Basic realm="Authorization Required"`)
+	c.AbortWithStatus(http.StatusUnauthorized)
+}
+
+// requiredScope determina el scope necesario según la ruta y el método
+func requiredScope(c *gin.Context) string {
+	path := c.FullPath()
+	if path == "" {
+		path = c.Request.URL.Path
+	}
+
+	for _, prefix := range adminPaths {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return storage.ScopeAdmin
+		}
+	}
+
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		return storage.ScopeReadEvents
+	}
+
+	if strings.HasPrefix(path, "/api/templates") || strings.HasPrefix(path, "/templates") {
+		return storage.ScopeWriteTemplates
+	}
+	return storage.ScopeWriteEvents
+}
+
+// allowedInGuild indica si quien hizo la request tiene el scope en un
+// servidor distinto del elegido. Los tokens y el usuario del .env valen para
+// todos los servidores; las sesiones de Discord dependen de sus roles en cada uno.
+func allowedInGuild(c *gin.Context, guildID, scope string) bool {
+	value, ok := c.Get(sessionContextKey)
+	if !ok {
+		return true
+	}
+	session := value.(*panelSession)
+	return storage.ScopesAllow(scopesForPanelRole(session.roleIn(guildID)), scope)
+}
+
+// isAdmin indica si quien hizo la request es administrador en el servidor elegido
+func isAdmin(c *gin.Context) bool {
+	return storage.ScopesAllow(c.GetStringSlice(scopesContextKey), storage.ScopeAdmin)
+}
+
+// requestActor devuelve quién hizo la request: el ID de Discord del usuario
+// logueado, el token usado o el administrador del .env
+func requestActor(c *gin.Context) string {
+	if actor := c.GetString(actorContextKey); actor != "" {
+		return actor
+	}
+	return basicAuthActor
+}
+
+// wantsHTML indica si la request viene de un navegador pidiendo una página
+func wantsHTML(c *gin.Context) bool {
+	return c.Request.Method == http.MethodGet &&
+		!strings.HasPrefix(c.Request.URL.Path, "/api/") &&
+		strings.Contains(c.GetHeader("Accept"), "text/html")
+}
+
+func validAdminCredentials(user, pass string) bool {
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(config.AppConfig.AdminUser)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(config.AppConfig.AdminPass)) == 1
+	return userOK && passOK
+}
@@ -0,0 +1,94 @@
+package web
+
+import (
+	backupsvc "discord-event-bot/internal/services/backups"
+	"discord-event-bot/internal/storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterBackupRoutes registra la página y la API de backups
+func RegisterBackupRoutes(router *gin.RouterGroup) {
+	router.GET("/api/backups", handleListBackups)
+	router.POST("/api/backups", handleCreateBackup)
+	router.GET("/api/backups/:name/download", handleDownloadBackup)
+	router.POST("/api/backups/:name/restore", handleRestoreBackup)
+
+	router.GET("/backups", handleBackupsPage)
+}
+
+// handleBackupsPage muestra la página de administración de backups
+func handleBackupsPage(c *gin.Context) {
+	backups, err := backupsvc.ListBackups()
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"title": "Error",
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "backups.html", gin.H{
+		"title":     "Backups",
+		"backups":   backups,
+		"recovered": storage.RecoveredFiles(),
+	})
+}
+
+// handleListBackups retorna los backups disponibles
+func handleListBackups(c *gin.Context) {
+	backups, err := backupsvc.ListBackups()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"backups":   backups,
+		"count":     len(backups),
+		"recovered": storage.RecoveredFiles(),
+	})
+}
+
+// handleCreateBackup genera un backup manual
+func handleCreateBackup(c *gin.Context) {
+	backup, err := backupsvc.CreateBackup()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Backup generado exitosamente",
+		"backup":  backup,
+	})
+}
+
+// handleDownloadBackup descarga un backup
+func handleDownloadBackup(c *gin.Context) {
+	name := c.Param("name")
+	path, err := backupsvc.BackupPath(name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.FileAttachment(path, name)
+}
+
+// handleRestoreBackup restaura todos los datos del bot desde un backup
+func handleRestoreBackup(c *gin.Context) {
+	manifest, err := backupsvc.RestoreBackup(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Backup restaurado exitosamente",
+		"events":        manifest.Events,
+		"templates":     manifest.Templates,
+		"tokens":        manifest.Tokens,
+		"profiles":      manifest.Profiles,
+		"notifications": manifest.Notifications,
+		"guilds":        manifest.Guilds,
+		"calendars":     manifest.Calendars,
+	})
+}
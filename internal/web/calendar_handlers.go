@@ -0,0 +1,65 @@
+package web
+
+import (
+	"discord-event-bot/internal/services/calendar"
+	"discord-event-bot/internal/services/guilds"
+	"discord-event-bot/internal/storage"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterCalendarRoutes registra los feeds iCal. Son rutas públicas: las
+// aplicaciones de calendario no pueden iniciar sesión, así que el acceso lo
+// da el token de la URL.
+func RegisterCalendarRoutes(router *gin.Engine) {
+	router.GET("/ical/:token/events.ics", handleCalendarEvents)
+	router.GET("/ical/:token/mine.ics", handleCalendarMine)
+}
+
+// handleCalendarEvents sirve los eventos próximos del servidor; ?type=
+// filtra por tipo de evento
+func handleCalendarEvents(c *gin.Context) {
+	feed, ok := calendarFeed(c)
+	if !ok {
+		return
+	}
+
+	eventType := c.Query("type")
+	name := "Eventos de " + guilds.Name(feed.GuildID)
+	if eventType != "" {
+		name += " - " + eventType
+	}
+
+	now := time.Now()
+	events := calendar.GuildEvents(feed.GuildID, eventType, now)
+	writeCalendar(c, calendar.Render(name, events, "", now))
+}
+
+// handleCalendarMine sirve los eventos en los que está inscrito el dueño del token
+func handleCalendarMine(c *gin.Context) {
+	feed, ok := calendarFeed(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	events := calendar.UserEvents(feed.GuildID, feed.UserID, c.Query("type"), now)
+	name := "Mis eventos en " + guilds.Name(feed.GuildID)
+	writeCalendar(c, calendar.Render(name, events, feed.UserID, now))
+}
+
+func calendarFeed(c *gin.Context) (storage.CalendarFeed, bool) {
+	feed, err := storage.Calendars.Authenticate(c.Param("token"))
+	if err != nil || !guilds.Allowed(feed.GuildID) {
+		c.String(http.StatusNotFound, "Calendario no encontrado")
+		return feed, false
+	}
+	return feed, true
+}
+
+func writeCalendar(c *gin.Context, body string) {
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(body))
+}
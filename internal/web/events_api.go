@@ -0,0 +1,526 @@
+package web
+
+import (
+	"discord-event-bot/config"
+	"discord-event-bot/internal/discord"
+	"discord-event-bot/internal/services/attendance"
+	eventsvc "discord-event-bot/internal/services/events"
+	"discord-event-bot/internal/services/guilds"
+	"discord-event-bot/internal/services/recurrence"
+	"discord-event-bot/internal/services/roster"
+	signupsvc "discord-event-bot/internal/services/signups"
+	"discord-event-bot/internal/storage"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Formatos de fecha aceptados por la API (además de RFC3339)
+var apiDateLayouts = []string{"2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"}
+
+// createEventRequest es el cuerpo aceptado por POST /api/events
+type createEventRequest struct {
+	GuildID               string `json:"guild_id"` // vacío = el servidor elegido (?guild=) o el principal
+	Name                  string `json:"name"`
+	Type                  string `json:"type"`
+	Description           string `json:"description"`
+	DateTime              string `json:"datetime"`
+	ChannelID             string `json:"channel_id"`
+	Template              string `json:"template"`
+	RepeatEveryDays       int    `json:"repeat_every_days"`
+	Recurrence            string `json:"recurrence"` // regla RRULE, p. ej. FREQ=WEEKLY;BYDAY=TU,TH
+	Exceptions            string `json:"exceptions"` // fechas YYYY-MM-DD separadas por coma
+	CarryOverSignups      bool   `json:"carry_over_signups"`
+	CreateDiscordEvent    bool   `json:"create_discord_event"`
+	AnnounceHours         int    `json:"announce_hours"`
+	ReminderOffsetMinutes int    `json:"reminder_offset_minutes"`
+	DeleteAfterHours      int    `json:"delete_after_hours"`
+	RequireApproval       bool   `json:"require_approval"`
+	ReminderOffsets       []int  `json:"reminder_offsets"` // minutos antes del evento
+
+	Requirements *storage.SignupRequirements `json:"requirements"`
+	FillIn       *storage.FillInSettings     `json:"fill_in"`
+}
+
+// updateEventRequest es el cuerpo aceptado por PUT /api/events/:id.
+// Los campos ausentes no se modifican.
+type updateEventRequest struct {
+	Name                  *string `json:"name"`
+	Type                  *string `json:"type"`
+	Description           *string `json:"description"`
+	DateTime              *string `json:"datetime"`
+	ChannelID             *string `json:"channel_id"`
+	AllowMultiSignup      *bool   `json:"allow_multi_signup"`
+	RequireApproval       *bool   `json:"require_approval"`
+	ReminderOffsetMinutes *int    `json:"reminder_offset_minutes"`
+	DeleteAfterHours      *int    `json:"delete_after_hours"`
+	ReminderOffsets       []int   `json:"reminder_offsets"`
+
+	Roles        []storage.RoleSignup        `json:"roles"` // ausente = no cambian
+	Requirements *storage.SignupRequirements `json:"requirements"`
+	FillIn       *storage.FillInSettings     `json:"fill_in"`
+}
+
+// signupRequest es el cuerpo aceptado por POST /api/events/:id/signups
+type signupRequest struct {
+	UserID    string `json:"user_id" binding:"required"`
+	Username  string `json:"username"`
+	Role      string `json:"role" binding:"required"`
+	Class     string `json:"class"`
+	Character string `json:"character"`
+}
+
+// reviewRequest indica el rol de la inscripción a confirmar o rechazar
+type reviewRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// attendanceRequest indica la asistencia de un usuario confirmado
+type attendanceRequest struct {
+	Attendance string `json:"attendance" binding:"required"`
+}
+
+// RegisterEventRoutes registra las rutas de la API de eventos e inscripciones
+func RegisterEventRoutes(router *gin.RouterGroup) {
+	router.GET("/api/events", handleAPIListEvents)
+	router.POST("/api/events", handleAPICreateEvent)
+	router.GET("/api/events/:id", handleAPIGetEvent)
+	router.PUT("/api/events/:id", handleAPIUpdateEvent)
+	router.DELETE("/api/events/:id", handleAPIDeleteEvent)
+	router.POST("/api/events/:id/cancel", handleAPICancelEvent)
+
+	router.GET("/api/events/:id/signups", handleAPIListSignups)
+	router.GET("/api/events/:id/roster", handleAPIExportRoster)
+	router.POST("/api/events/:id/signups", handleAPIAddSignup)
+	router.DELETE("/api/events/:id/signups/:userid", handleAPIRemoveSignup)
+	router.POST("/api/events/:id/signups/:userid/confirm", handleAPIConfirmSignup)
+	router.POST("/api/events/:id/signups/:userid/decline", handleAPIDeclineSignup)
+
+	router.GET("/api/events/:id/attendance", handleAPIListAttendance)
+	router.POST("/api/events/:id/signups/:userid/attendance", handleAPIMarkAttendance)
+}
+
+// handleAPIListEvents retorna los eventos del servidor (?guild=), opcionalmente
+// filtrados por status, type, serie (series) y rango de fechas (from/to)
+func handleAPIListEvents(c *gin.Context) {
+	status := c.Query("status")
+	eventType := c.Query("type")
+	series := c.Query("series")
+
+	var from, to time.Time
+	if raw := c.Query("from"); raw != "" {
+		t, err := parseAPITime(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parámetro from inválido"})
+			return
+		}
+		from = t
+	}
+	if raw := c.Query("to"); raw != "" {
+		t, err := parseAPITime(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parámetro to inválido"})
+			return
+		}
+		to = t
+	}
+
+	events := make([]*storage.Event, 0)
+	for _, event := range guilds.FilterEvents(storage.Store.GetAllEvents(), currentGuild(c)) {
+		if status != "" && event.Status != status {
+			continue
+		}
+		if eventType != "" && event.Type != eventType {
+			continue
+		}
+		if series != "" && event.ID != series && event.SeriesID != series {
+			continue
+		}
+		if !from.IsZero() && event.DateTime.Before(from) {
+			continue
+		}
+		if !to.IsZero() && event.DateTime.After(to) {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].DateTime.Before(events[j].DateTime)
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+		"count":  len(events),
+	})
+}
+
+// handleAPIGetEvent retorna un evento específico
+func handleAPIGetEvent(c *gin.Context) {
+	event, err := storage.Store.GetEvent(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evento no encontrado"})
+		return
+	}
+	c.JSON(http.StatusOK, event)
+}
+
+// handleAPICreateEvent crea un evento con las mismas reglas que Discord y el panel
+func handleAPICreateEvent(c *gin.Context) {
+	var req createEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	dateTime, err := parseAPITime(req.DateTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de fecha inválido"})
+		return
+	}
+
+	var rule *storage.Recurrence
+	if req.Recurrence != "" {
+		rule, err = recurrence.Parse(req.Recurrence, req.Exceptions, dateTime)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Regla de repetición inválida: " + err.Error()})
+			return
+		}
+	}
+
+	guildID := req.GuildID
+	if guildID == "" {
+		guildID = currentGuild(c)
+	} else if !guilds.Known(guildID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El bot no está en el servidor " + guildID})
+		return
+	} else if !allowedInGuild(c, guildID, storage.ScopeWriteEvents) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Falta el permiso " + storage.ScopeWriteEvents + " en el servidor " + guildID})
+		return
+	}
+
+	event, err := eventsvc.CreateEvent(eventsvc.CreateEventInput{
+		GuildID:               guildID,
+		Name:                  req.Name,
+		Type:                  req.Type,
+		Description:           req.Description,
+		DateTime:              dateTime,
+		ChannelID:             req.ChannelID,
+		RepeatEveryDays:       req.RepeatEveryDays,
+		TemplateName:          req.Template,
+		CreateDiscordEvent:    req.CreateDiscordEvent,
+		CreatedBy:             requestActor(c),
+		AnnounceHours:         req.AnnounceHours,
+		ReminderOffsetMinutes: req.ReminderOffsetMinutes,
+		DeleteAfterHours:      req.DeleteAfterHours,
+		RequireApproval:       req.RequireApproval,
+		Recurrence:            rule,
+		CarryOverSignups:      req.CarryOverSignups,
+		Requirements:          req.Requirements,
+		ReminderOffsets:       req.ReminderOffsets,
+		FillIn:                req.FillIn,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	publishNewEvent(event)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Evento creado exitosamente",
+		"event":   event,
+	})
+}
+
+// handleAPIUpdateEvent actualiza parcialmente un evento
+func handleAPIUpdateEvent(c *gin.Context) {
+	var req updateEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	input := eventsvc.EditEventInput{
+		Name:                  req.Name,
+		Type:                  req.Type,
+		Description:           req.Description,
+		ChannelID:             req.ChannelID,
+		Roles:                 req.Roles,
+		AllowMultiSignup:      req.AllowMultiSignup,
+		RequireApproval:       req.RequireApproval,
+		ReminderOffsetMinutes: req.ReminderOffsetMinutes,
+		DeleteAfterHours:      req.DeleteAfterHours,
+		Requirements:          req.Requirements,
+		ReminderOffsets:       req.ReminderOffsets,
+		FillIn:                req.FillIn,
+	}
+	if req.DateTime != nil {
+		dateTime, err := parseAPITime(*req.DateTime)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de fecha inválido"})
+			return
+		}
+		input.DateTime = &dateTime
+	}
+
+	result, err := eventsvc.EditEvent(c.Param("id"), input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if discord.Session != nil {
+		discord.SyncEditedEvent(discord.Session, result)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Evento actualizado exitosamente",
+		"event":   result.Event,
+		"changes": result.Changes,
+	})
+}
+
+// handleAPICancelEvent cancela un evento conservándolo en el historial
+func handleAPICancelEvent(c *gin.Context) {
+	event, err := eventsvc.CancelEvent(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evento no encontrado"})
+		return
+	}
+
+	cancelEventInDiscord(event)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Evento cancelado exitosamente",
+		"event":   event,
+	})
+}
+
+// handleAPIDeleteEvent elimina definitivamente un evento
+func handleAPIDeleteEvent(c *gin.Context) {
+	event, err := storage.Store.GetEvent(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evento no encontrado"})
+		return
+	}
+
+	removeEventFromDiscord(event)
+	if discord.Session != nil {
+		discord.DeleteDiscordScheduledEvent(discord.Session, event)
+	}
+
+	if err := storage.Store.DeleteEvent(event.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error eliminando evento"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Evento eliminado exitosamente"})
+}
+
+// handleAPIListSignups retorna las inscripciones y la banca de un evento
+func handleAPIListSignups(c *gin.Context) {
+	event, err := storage.Store.GetEvent(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evento no encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"signups":  event.Signups,
+		"waitlist": event.Waitlist,
+	})
+}
+
+// handleAPIExportRoster descarga el roster del evento (?format=csv|json|md)
+func handleAPIExportRoster(c *gin.Context) {
+	event, err := storage.Store.GetEvent(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evento no encontrado"})
+		return
+	}
+
+	export, err := roster.Build(event, c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.Filename))
+	c.Data(http.StatusOK, export.ContentType, export.Data)
+}
+
+// handleAPIAddSignup inscribe a un usuario aplicando las reglas de signupsvc
+func handleAPIAddSignup(c *gin.Context) {
+	var req signupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	username := req.Username
+	if username == "" {
+		username = req.UserID
+	}
+
+	// Los requisitos de roles se verifican con los roles actuales en Discord
+	var memberRoles []string
+	if current, err := storage.Store.GetEvent(c.Param("id")); err == nil && current.Requirements.HasRoleRules() && discord.Session != nil {
+		memberRoles = discord.MemberRoles(discord.Session, guilds.EventGuild(current), req.UserID)
+	}
+
+	event, err := signupsvc.SignupToEvent(signupsvc.SignupInput{
+		EventID:     c.Param("id"),
+		UserID:      req.UserID,
+		Username:    username,
+		Role:        req.Role,
+		Class:       req.Class,
+		Character:   req.Character,
+		MemberRoles: memberRoles,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	signup, _ := signupsvc.FindSignup(event, req.Role, req.UserID)
+	if discord.Session != nil {
+		discord.UpdateEventMessage(discord.Session, event)
+		if signup.Status == "pending" {
+			discord.RequestSignupApproval(discord.Session, event, signup)
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Inscripción registrada",
+		"signup":  signup,
+	})
+}
+
+// handleAPIRemoveSignup cancela la inscripción de un usuario y promueve la banca
+func handleAPIRemoveSignup(c *gin.Context) {
+	event, promoted, err := signupsvc.CancelSignup(signupsvc.CancelInput{
+		EventID: c.Param("id"),
+		UserID:  c.Param("userid"),
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if discord.Session != nil {
+		discord.UpdateEventMessage(discord.Session, event)
+		discord.NotifyWaitlistPromotions(discord.Session, event, promoted)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Inscripción cancelada",
+		"promoted": promoted,
+	})
+}
+
+// handleAPIConfirmSignup confirma una inscripción pendiente
+func handleAPIConfirmSignup(c *gin.Context) {
+	handleAPIReviewSignup(c, signupsvc.ApproveSignup, "Inscripción confirmada")
+}
+
+// handleAPIDeclineSignup rechaza una inscripción pendiente
+func handleAPIDeclineSignup(c *gin.Context) {
+	handleAPIReviewSignup(c, signupsvc.DeclineSignup, "Inscripción rechazada")
+}
+
+func handleAPIReviewSignup(c *gin.Context, review func(signupsvc.ReviewInput) (*storage.Event, error), message string) {
+	var req reviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	event, err := review(signupsvc.ReviewInput{
+		EventID:    c.Param("id"),
+		UserID:     c.Param("userid"),
+		Role:       req.Role,
+		ReviewerID: requestActor(c),
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if discord.Session != nil {
+		discord.UpdateEventMessage(discord.Session, event)
+	}
+
+	signup, _ := signupsvc.FindSignup(event, req.Role, c.Param("userid"))
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"signup":  signup,
+	})
+}
+
+// handleAPIListAttendance retorna la asistencia de los inscritos confirmados
+func handleAPIListAttendance(c *gin.Context) {
+	event, err := storage.Store.GetEvent(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evento no encontrado"})
+		return
+	}
+
+	summary := attendance.Summary(event)
+	c.JSON(http.StatusOK, gin.H{
+		"event_id":   event.ID,
+		"attendance": attendance.Entries(event),
+		"summary": gin.H{
+			attendance.Present: summary[attendance.Present],
+			attendance.Late:    summary[attendance.Late],
+			attendance.NoShow:  summary[attendance.NoShow],
+			"unmarked":         summary[""],
+		},
+	})
+}
+
+// handleAPIMarkAttendance registra la asistencia de un usuario confirmado
+func handleAPIMarkAttendance(c *gin.Context) {
+	var req attendanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	event, err := attendance.Mark(attendance.MarkInput{
+		EventID:    c.Param("id"),
+		UserID:     c.Param("userid"),
+		Attendance: req.Attendance,
+		MarkedBy:   requestActor(c),
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Asistencia registrada",
+		"attendance": attendance.Entries(event),
+	})
+}
+
+// parseAPITime acepta RFC3339 o fechas locales en la zona horaria configurada
+func parseAPITime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+
+	loc, err := time.LoadLocation(config.AppConfig.Timezone)
+	if err != nil {
+		loc = time.Local
+	}
+	for _, layout := range apiDateLayouts {
+		if t, err := time.ParseInLocation(layout, raw, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("fecha inválida: %s", raw)
+}
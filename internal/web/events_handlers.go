@@ -3,20 +3,31 @@ package web
 import (
 	"discord-event-bot/config"
 	"discord-event-bot/internal/discord"
+	"discord-event-bot/internal/services/attendance"
 	eventsvc "discord-event-bot/internal/services/events"
+	"discord-event-bot/internal/services/guilds"
+	"discord-event-bot/internal/services/recurrence"
+	remindersvc "discord-event-bot/internal/services/reminders"
+	signupsvc "discord-event-bot/internal/services/signups"
 	"discord-event-bot/internal/storage"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/gin-gonic/gin"
 )
 
+// errInvalidFormDate indica que la fecha del formulario no tiene el formato esperado
+var errInvalidFormDate = errors.New("formato de fecha inválido")
+
 // handleIndex muestra la página principal
 func handleIndex(c *gin.Context) {
-	events := storage.Store.GetActiveEvents()
+	events := guilds.FilterEvents(storage.Store.GetActiveEvents(), currentGuild(c))
 	c.HTML(http.StatusOK, "index.html", gin.H{
 		"title":  "Panel de Administración - Discord Event Bot",
 		"events": events,
@@ -25,7 +36,7 @@ func handleIndex(c *gin.Context) {
 
 // handleEventsList muestra la lista de eventos
 func handleEventsList(c *gin.Context) {
-	events := storage.Store.GetAllEvents()
+	events := guilds.FilterEvents(storage.Store.GetAllEvents(), currentGuild(c))
 	c.HTML(http.StatusOK, "events.html", gin.H{
 		"title":  "Todos los Eventos",
 		"events": events,
@@ -34,54 +45,68 @@ func handleEventsList(c *gin.Context) {
 
 // handleCreateEventPage muestra el formulario de creación
 func handleCreateEventPage(c *gin.Context) {
-	templates := storage.Templates.GetAllTemplates()
+	guildID := currentGuild(c)
+	templates := guilds.FilterTemplates(storage.Templates.GetAllTemplates(), guildID)
 	c.HTML(http.StatusOK, "create_event.html", gin.H{
 		"title":     "Crear Nuevo Evento",
-		"roles":     config.AppConfig.DefaultRoles,
+		"roles":     guilds.DefaultRoles(guildID),
 		"templates": templates,
 	})
 }
 
 // handleCreateEventPost procesa la creación de un evento
 func handleCreateEventPost(c *gin.Context) {
-	templates := storage.Templates.GetAllTemplates()
+	guildID := currentGuild(c)
+	templates := guilds.FilterTemplates(storage.Templates.GetAllTemplates(), guildID)
 
 	input, err := buildCreateEventInputFromForm(c)
 	if err != nil {
+		message := err.Error()
+		if errors.Is(err, errInvalidFormDate) {
+			message = "Formato de fecha inválido"
+		}
 		c.HTML(http.StatusBadRequest, "create_event.html", gin.H{
 			"title":     "Crear Nuevo Evento",
-			"error":     "Formato de fecha inválido",
-			"roles":     config.AppConfig.DefaultRoles,
+			"error":     message,
+			"roles":     guilds.DefaultRoles(guildID),
 			"templates": templates,
 		})
 		return
 	}
 
+	input.GuildID = guildID
 	event, err := eventsvc.CreateEvent(input)
 	if err != nil {
 		c.HTML(http.StatusBadRequest, "create_event.html", gin.H{
 			"title":     "Crear Nuevo Evento",
 			"error":     "Error creando evento: " + err.Error(),
-			"roles":     config.AppConfig.DefaultRoles,
+			"roles":     guilds.DefaultRoles(guildID),
 			"templates": templates,
 		})
 		return
 	}
 
-	// Publicar en Discord
-	if discord.Session != nil {
-		if event.AnnouncementTime.IsZero() || !event.AnnouncementTime.After(time.Now()) {
-			if err := discord.PublishEventMessage(discord.Session, event); err != nil {
-				log.Printf("Error publicando en Discord: %v", err)
-			}
-		}
+	publishNewEvent(event)
 
-		if config.AppConfig.EnableDiscordEvents && event.CreateDiscordEvent {
-			discord.CreateDiscordScheduledEvent(discord.Session, event)
+	c.Redirect(http.StatusSeeOther, "/events/"+event.ID)
+}
+
+// publishNewEvent publica en Discord un evento recién creado, salvo que
+// tenga un anuncio programado para más adelante
+func publishNewEvent(event *storage.Event) {
+	if discord.Session == nil {
+		return
+	}
+
+	if event.AnnouncementTime.IsZero() || !event.AnnouncementTime.After(time.Now()) {
+		if err := discord.PublishEventMessage(discord.Session, event); err != nil {
+			log.Printf("Error publicando en Discord: %v", err)
 		}
 	}
 
-	c.Redirect(http.StatusSeeOther, "/events/"+event.ID)
+	if guilds.DiscordEventsEnabled(event.GuildID) && event.CreateDiscordEvent {
+		discord.CreateDiscordScheduledEvent(discord.Session, event)
+	}
 }
 
 func buildCreateEventInputFromForm(c *gin.Context) (eventsvc.CreateEventInput, error) {
@@ -94,15 +119,9 @@ func buildCreateEventInputFromForm(c *gin.Context) (eventsvc.CreateEventInput, e
 	descripcion := c.PostForm("descripcion")
 	channel := c.PostForm("channel")
 	templateName := c.PostForm("template")
-	repeatDaysStr := c.PostForm("repeat_days")
 	createDiscordEvent := c.PostForm("discord_event") == "1"
-
-	repeatEveryDays := 0
-	if repeatDaysStr != "" {
-		if v, err := strconv.Atoi(repeatDaysStr); err == nil && v > 0 {
-			repeatEveryDays = v
-		}
-	}
+	requireApproval := c.PostForm("require_approval") == "1"
+	carryOverSignups := c.PostForm("carry_over_signups") == "1"
 
 	announceHours := 0
 	if announceHoursStr != "" {
@@ -128,7 +147,20 @@ func buildCreateEventInputFromForm(c *gin.Context) (eventsvc.CreateEventInput, e
 	loc, _ := time.LoadLocation(config.AppConfig.Timezone)
 	fecha, err := time.ParseInLocation("2006-01-02T15:04", fechaStr, loc)
 	if err != nil {
-		return eventsvc.CreateEventInput{}, err
+		return eventsvc.CreateEventInput{}, errInvalidFormDate
+	}
+
+	reminderOffsets, err := remindersvc.ParseOffsets(c.PostForm("reminder_offsets"))
+	if err != nil {
+		return eventsvc.CreateEventInput{}, fmt.Errorf("Recordatorios inválidos: %v", err)
+	}
+
+	var rule *storage.Recurrence
+	if ruleStr := buildRecurrenceRuleFromForm(c); ruleStr != "" {
+		rule, err = recurrence.Parse(ruleStr, c.PostForm("recurrence_exceptions"), fecha)
+		if err != nil {
+			return eventsvc.CreateEventInput{}, fmt.Errorf("Regla de repetición inválida: %v", err)
+		}
 	}
 
 	return eventsvc.CreateEventInput{
@@ -137,16 +169,70 @@ func buildCreateEventInputFromForm(c *gin.Context) (eventsvc.CreateEventInput, e
 		Description:           descripcion,
 		DateTime:              fecha,
 		ChannelID:             channel,
-		RepeatEveryDays:       repeatEveryDays,
 		TemplateName:          templateName,
 		CreateDiscordEvent:    createDiscordEvent,
-		CreatedBy:             "admin_web",
+		CreatedBy:             requestActor(c),
 		AnnounceHours:         announceHours,
 		ReminderOffsetMinutes: reminderOffsetMinutes,
 		DeleteAfterHours:      deleteAfterHours,
+		RequireApproval:       requireApproval,
+		Recurrence:            rule,
+		CarryOverSignups:      carryOverSignups,
+		Requirements:          buildRequirementsFromForm(c),
+		ReminderOffsets:       reminderOffsets,
 	}, nil
 }
 
+// buildRequirementsFromForm arma los requisitos de inscripción del formulario.
+// Si no se completó ninguno devuelve nil para usar los del template.
+func buildRequirementsFromForm(c *gin.Context) *storage.SignupRequirements {
+	requiredRoles := strings.TrimSpace(c.PostForm("required_roles"))
+	forbiddenRoles := strings.TrimSpace(c.PostForm("forbidden_roles"))
+	minItemLevel, _ := strconv.Atoi(c.PostForm("min_item_level"))
+	if requiredRoles == "" && forbiddenRoles == "" && minItemLevel == 0 {
+		return nil
+	}
+
+	return &storage.SignupRequirements{
+		RequiredRoles:  strings.Split(requiredRoles, ","),
+		ForbiddenRoles: strings.Split(forbiddenRoles, ","),
+		MinItemLevel:   minItemLevel,
+	}
+}
+
+// buildRecurrenceRuleFromForm arma una regla RRULE con los campos de repetición del formulario
+func buildRecurrenceRuleFromForm(c *gin.Context) string {
+	freq := c.PostForm("recurrence_freq")
+	if freq == "" {
+		return ""
+	}
+
+	parts := []string{"FREQ=" + freq}
+	if interval := c.PostForm("recurrence_interval"); interval != "" && interval != "1" {
+		parts = append(parts, "INTERVAL="+interval)
+	}
+
+	switch freq {
+	case "WEEKLY":
+		if weekdays := c.PostFormArray("recurrence_weekdays"); len(weekdays) > 0 {
+			parts = append(parts, "BYDAY="+strings.Join(weekdays, ","))
+		}
+	case "MONTHLY":
+		if week := c.PostForm("recurrence_month_week"); week != "" {
+			parts = append(parts, "BYDAY="+week+c.PostForm("recurrence_month_weekday"))
+		}
+	}
+
+	if until := c.PostForm("recurrence_until"); until != "" {
+		parts = append(parts, "UNTIL="+strings.ReplaceAll(until, "-", ""))
+	}
+	if count := c.PostForm("recurrence_count"); count != "" && count != "0" {
+		parts = append(parts, "COUNT="+count)
+	}
+
+	return strings.Join(parts, ";")
+}
+
 // handleEventDetail muestra los detalles de un evento
 func handleEventDetail(c *gin.Context) {
 	eventID := c.Param("id")
@@ -161,50 +247,225 @@ func handleEventDetail(c *gin.Context) {
 	}
 
 	c.HTML(http.StatusOK, "event_detail.html", gin.H{
-		"title": event.Name,
+		"title":   event.Name,
+		"event":   event,
+		"series":  buildSeriesHistory(event),
+		"started": !time.Now().Before(event.DateTime) && event.Status != "cancelled",
+	})
+}
+
+// handleEditEventPage muestra el formulario de edición de un evento
+func handleEditEventPage(c *gin.Context) {
+	event, err := storage.Store.GetEvent(c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"title": "Error",
+			"error": "Evento no encontrado",
+		})
+		return
+	}
+
+	renderEditEventPage(c, http.StatusOK, event, "")
+}
+
+// handleEditEventPost guarda los cambios del formulario y los sincroniza con Discord
+func handleEditEventPost(c *gin.Context) {
+	event, err := storage.Store.GetEvent(c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"title": "Error",
+			"error": "Evento no encontrado",
+		})
+		return
+	}
+
+	input, err := buildEditEventInputFromForm(c)
+	if err != nil {
+		message := err.Error()
+		if errors.Is(err, errInvalidFormDate) {
+			message = "Formato de fecha inválido"
+		}
+		renderEditEventPage(c, http.StatusBadRequest, event, message)
+		return
+	}
+
+	result, err := eventsvc.EditEvent(event.ID, input)
+	if err != nil {
+		renderEditEventPage(c, http.StatusBadRequest, event, "Error editando evento: "+err.Error())
+		return
+	}
+
+	if discord.Session != nil {
+		discord.SyncEditedEvent(discord.Session, result)
+	}
+
+	c.Redirect(http.StatusSeeOther, "/events/"+event.ID)
+}
+
+func renderEditEventPage(c *gin.Context, status int, event *storage.Event, message string) {
+	loc, _ := time.LoadLocation(config.AppConfig.Timezone)
+	c.HTML(status, "edit_event.html", gin.H{
+		"title": "Editar " + event.Name,
 		"event": event,
+		"fecha": event.DateTime.In(loc).Format("2006-01-02T15:04"),
+		"error": message,
 	})
 }
 
-// handleCancelEvent cancela un evento
-func handleCancelEvent(c *gin.Context) {
+// buildEditEventInputFromForm arma los cambios del formulario de edición.
+// Los roles con el nombre vacío se quitan del evento.
+func buildEditEventInputFromForm(c *gin.Context) (eventsvc.EditEventInput, error) {
+	nombre := c.PostForm("nombre")
+	tipo := c.PostForm("tipo")
+	descripcion := c.PostForm("descripcion")
+	channel := c.PostForm("channel")
+
+	loc, _ := time.LoadLocation(config.AppConfig.Timezone)
+	fecha, err := time.ParseInLocation("2006-01-02T15:04", c.PostForm("fecha"), loc)
+	if err != nil {
+		return eventsvc.EditEventInput{}, errInvalidFormDate
+	}
+
+	names := c.PostFormArray("role_name")
+	emojis := c.PostFormArray("role_emoji")
+	limits := c.PostFormArray("role_limit")
+	roles := make([]storage.RoleSignup, 0, len(names))
+	for idx, name := range names {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		role := storage.RoleSignup{Name: name}
+		if idx < len(emojis) {
+			role.Emoji = emojis[idx]
+		}
+		if idx < len(limits) && limits[idx] != "" {
+			limit, err := strconv.Atoi(limits[idx])
+			if err != nil {
+				return eventsvc.EditEventInput{}, fmt.Errorf("Límite inválido en el rol %s", name)
+			}
+			role.Limit = limit
+		}
+		roles = append(roles, role)
+	}
+
+	return eventsvc.EditEventInput{
+		Name:        &nombre,
+		Type:        &tipo,
+		Description: &descripcion,
+		DateTime:    &fecha,
+		ChannelID:   &channel,
+		Roles:       roles,
+	}, nil
+}
+
+// handleMarkAttendance registra la asistencia de un inscrito desde el detalle del evento
+func handleMarkAttendance(c *gin.Context) {
 	eventID := c.Param("id")
-	event, err := storage.Store.GetEvent(eventID)
 
+	_, err := attendance.Mark(attendance.MarkInput{
+		EventID:    eventID,
+		UserID:     c.Param("userid"),
+		Attendance: c.PostForm("attendance"),
+		MarkedBy:   requestActor(c),
+	})
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Evento no encontrado"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	event.Status = "cancelled"
-	storage.Store.SaveEvent(event)
+	c.Redirect(http.StatusSeeOther, "/events/"+eventID)
+}
 
-	// Eliminar mensaje de Discord y cerrar hilo si existen
-	if discord.Session != nil {
-		if event.MessageID != "" {
-			discord.Session.ChannelMessageDelete(event.Channel, event.MessageID)
+// seriesOccurrence es una fila del historial de una serie recurrente
+type seriesOccurrence struct {
+	Event     *storage.Event
+	Confirmed int
+	Current   bool
+}
+
+// buildSeriesHistory arma el historial de ocurrencias de la serie del evento.
+// Devuelve nil para eventos que no pertenecen a una serie.
+func buildSeriesHistory(event *storage.Event) []seriesOccurrence {
+	seriesID := event.SeriesID
+	if seriesID == "" {
+		if !recurrence.IsRecurring(event) {
+			return nil
 		}
-		if event.ThreadID != "" {
-			archived := true
-			locked := true
-			if _, err := discord.Session.ChannelEdit(event.ThreadID, &discordgo.ChannelEdit{Archived: &archived, Locked: &locked}); err != nil {
-				log.Printf("Error archivando hilo %s para evento %s: %v", event.ThreadID, event.ID, err)
+		seriesID = event.ID
+	}
+
+	var history []seriesOccurrence
+	for _, occurrence := range storage.Store.GetSeriesEvents(seriesID) {
+		confirmed := 0
+		for _, signups := range occurrence.Signups {
+			for _, signup := range signups {
+				if signup.Status == "confirmed" {
+					confirmed++
+				}
 			}
 		}
+		history = append(history, seriesOccurrence{
+			Event:     occurrence,
+			Confirmed: confirmed,
+			Current:   occurrence.ID == event.ID,
+		})
 	}
+	return history
+}
+
+// handleCancelEvent cancela un evento
+func handleCancelEvent(c *gin.Context) {
+	event, err := eventsvc.CancelEvent(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evento no encontrado"})
+		return
+	}
+
+	cancelEventInDiscord(event)
 
 	c.Redirect(http.StatusSeeOther, "/")
 }
 
+// cancelEventInDiscord quita el mensaje y el hilo de un evento cancelado y
+// cancela su evento oficial de Discord
+func cancelEventInDiscord(event *storage.Event) {
+	removeEventFromDiscord(event)
+	if discord.Session != nil {
+		discord.CancelDiscordScheduledEvent(discord.Session, event)
+	}
+}
+
+// removeEventFromDiscord elimina el mensaje del evento y cierra su hilo si existen
+func removeEventFromDiscord(event *storage.Event) {
+	if discord.Session == nil {
+		return
+	}
+
+	if event.MessageID != "" {
+		discord.Session.ChannelMessageDelete(event.Channel, event.MessageID)
+	}
+	if event.ThreadID != "" {
+		archived := true
+		locked := true
+		if _, err := discord.Session.ChannelEdit(event.ThreadID, &discordgo.ChannelEdit{Archived: &archived, Locked: &locked}); err != nil {
+			log.Printf("Error archivando hilo %s para evento %s: %v", event.ThreadID, event.ID, err)
+		}
+	}
+}
+
+// handleCleanupCancelledEvents elimina los eventos cancelados del servidor elegido
 func handleCleanupCancelledEvents(c *gin.Context) {
-	deleted, err := storage.Store.DeleteCancelledEvents()
+	guildID := currentGuild(c)
+	deleted, err := storage.Store.DeleteCancelledEvents(func(event *storage.Event) bool {
+		return guilds.InGuild(event, guildID)
+	})
 	if err != nil {
 		log.Printf("Error eliminando eventos cancelados: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error eliminando eventos cancelados"})
 		return
 	}
 
-	log.Printf("Eliminados %d eventos cancelados", deleted)
+	log.Printf("Eliminados %d eventos cancelados del servidor %s", deleted, guildID)
 	c.Redirect(http.StatusSeeOther, "/events")
 }
 
@@ -214,14 +475,44 @@ func handleConfirmSignup(c *gin.Context) {
 	userID := c.Param("userid")
 	role := c.Param("role")
 
-	if err := storage.Store.ConfirmSignup(eventID, userID, role, "admin_web"); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	event, err := signupsvc.ApproveSignup(signupsvc.ReviewInput{
+		EventID:    eventID,
+		UserID:     userID,
+		Role:       role,
+		ReviewerID: requestActor(c),
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Actualizar mensaje en Discord
-	event, _ := storage.Store.GetEvent(eventID)
-	if discord.Session != nil && event != nil {
+	if discord.Session != nil {
+		discord.UpdateEventMessage(discord.Session, event)
+	}
+
+	c.Redirect(http.StatusSeeOther, "/events/"+eventID)
+}
+
+// handleDeclineSignup rechaza una inscripción pendiente
+func handleDeclineSignup(c *gin.Context) {
+	eventID := c.Param("id")
+	userID := c.Param("userid")
+	role := c.Param("role")
+
+	event, err := signupsvc.DeclineSignup(signupsvc.ReviewInput{
+		EventID:    eventID,
+		UserID:     userID,
+		Role:       role,
+		ReviewerID: requestActor(c),
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Actualizar mensaje en Discord
+	if discord.Session != nil {
 		discord.UpdateEventMessage(discord.Session, event)
 	}
 
@@ -0,0 +1,175 @@
+package web
+
+import (
+	"discord-event-bot/config"
+	"discord-event-bot/internal/services/guilds"
+	"discord-event-bot/internal/storage"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cookie con el servidor elegido en el panel
+const guildCookieName = "guild"
+
+// guildSettingsRequest es el cuerpo aceptado por PUT /api/guilds/:id/settings
+type guildSettingsRequest struct {
+	DefaultRoles             []storage.RoleSignup `json:"default_roles"`
+	ReminderOffsetMinutes    int                  `json:"reminder_offset_minutes"`
+	EnableDiscordEvents      *bool                `json:"enable_discord_events"` // null = la configuración global
+	AttendanceVoiceChannelID string               `json:"attendance_voice_channel_id"`
+	FillInLeadMinutes        int                  `json:"fill_in_lead_minutes"`
+	FillInRolePings          map[string]string    `json:"fill_in_role_pings"`
+	ImportDiscordEvents      *bool                `json:"import_discord_events"` // null = la configuración global
+	ImportEventsChannelID    string               `json:"import_events_channel_id"`
+	ImportInterestedSignups  *bool                `json:"import_interested_signups"` // null = la configuración global
+}
+
+// RegisterGuildRoutes registra el selector de servidor y la API de configuración por servidor
+func RegisterGuildRoutes(router *gin.RouterGroup) {
+	router.GET("/api/guilds", handleAPIListGuilds)
+	router.GET("/api/guilds/:id", handleAPIGetGuild)
+	router.PUT("/api/guilds/:id/settings", handleAPISaveGuildSettings)
+
+	router.GET("/guilds", handleGuildsPage)
+}
+
+// guildMiddleware cambia el servidor del panel cuando la URL trae ?guild=<id>.
+// La elección se guarda en una cookie para las páginas siguientes.
+func guildMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if guildID := c.Query("guild"); guildID != "" && guilds.Known(guildID) {
+			c.Set(guildCookieName, guildID)
+			setCookie(c, guildCookieName, guildID, 365*24*60*60)
+		}
+		c.Next()
+	}
+}
+
+// eventGuildMiddleware responde 404 en las rutas de un evento
+// (/events/:id..., /api/events/:id...) cuando el evento es de otro servidor
+// que el elegido, igual que si no existiera
+func eventGuildMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if !strings.HasPrefix(path, "/events/:id") && !strings.HasPrefix(path, "/api/events/:id") {
+			c.Next()
+			return
+		}
+
+		event, err := storage.Store.GetEvent(c.Param("id"))
+		if err == nil && !guilds.InGuild(event, currentGuild(c)) {
+			if strings.HasPrefix(path, "/api/") {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Evento no encontrado"})
+				return
+			}
+			c.HTML(http.StatusNotFound, "error.html", gin.H{
+				"title": "Error",
+				"error": "Evento no encontrado",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// currentGuild devuelve el servidor con el que se está trabajando en el
+// panel o la API: ?guild=, la cookie del selector o el servidor principal
+func currentGuild(c *gin.Context) string {
+	if guildID := c.GetString(guildCookieName); guildID != "" {
+		return guildID
+	}
+	if guildID, err := c.Cookie(guildCookieName); err == nil && guilds.Known(guildID) {
+		return guildID
+	}
+	return config.AppConfig.GuildID
+}
+
+// handleGuildsPage muestra los servidores del bot y la configuración del elegido
+func handleGuildsPage(c *gin.Context) {
+	guildID := currentGuild(c)
+	settings, _ := storage.Guilds.GetSettings(guildID)
+
+	// Los templates no desreferencian punteros: "" = configuración global
+	optionalBool := func(value *bool) string {
+		if value == nil {
+			return ""
+		}
+		return strconv.FormatBool(*value)
+	}
+
+	list := guilds.List()
+	activeCounts := make(map[string]int, len(list))
+	for _, event := range storage.Store.GetActiveEvents() {
+		activeCounts[guilds.EventGuild(event)]++
+	}
+
+	c.HTML(http.StatusOK, "guilds.html", gin.H{
+		"title":            "Servidores",
+		"guilds":           list,
+		"current":          guildID,
+		"currentName":      guilds.Name(guildID),
+		"settings":         settings,
+		"discordEvents":    optionalBool(settings.EnableDiscordEvents),
+		"importEvents":     optionalBool(settings.ImportDiscordEvents),
+		"importInterested": optionalBool(settings.ImportInterestedSignups),
+		"activeCounts":     activeCounts,
+		"roles":            guilds.DefaultRoles(guildID),
+		"isMain":           guildID == config.AppConfig.GuildID,
+	})
+}
+
+// handleAPIListGuilds retorna los servidores del bot con su configuración
+func handleAPIListGuilds(c *gin.Context) {
+	all := guilds.List()
+	c.JSON(http.StatusOK, gin.H{
+		"guilds":  all,
+		"count":   len(all),
+		"current": currentGuild(c),
+	})
+}
+
+// handleAPIGetGuild retorna la configuración de un servidor
+func handleAPIGetGuild(c *gin.Context) {
+	guildID := c.Param("id")
+	if !guilds.Known(guildID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Servidor no encontrado"})
+		return
+	}
+	settings, _ := storage.Guilds.GetSettings(guildID)
+	c.JSON(http.StatusOK, settings)
+}
+
+// handleAPISaveGuildSettings reemplaza la configuración de un servidor
+func handleAPISaveGuildSettings(c *gin.Context) {
+	var req guildSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	settings, err := guilds.UpdateSettings(storage.GuildSettings{
+		GuildID:                  c.Param("id"),
+		DefaultRoles:             req.DefaultRoles,
+		ReminderOffsetMinutes:    req.ReminderOffsetMinutes,
+		EnableDiscordEvents:      req.EnableDiscordEvents,
+		AttendanceVoiceChannelID: req.AttendanceVoiceChannelID,
+		FillInLeadMinutes:        req.FillInLeadMinutes,
+		FillInRolePings:          req.FillInRolePings,
+		ImportDiscordEvents:      req.ImportDiscordEvents,
+		ImportEventsChannelID:    req.ImportEventsChannelID,
+		ImportInterestedSignups:  req.ImportInterestedSignups,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Configuración del servidor guardada",
+		"settings": settings,
+	})
+}
@@ -0,0 +1,314 @@
+package web
+
+import (
+	"crypto/rand"
+	"discord-event-bot/config"
+	"discord-event-bot/internal/services/guilds"
+	"discord-event-bot/internal/storage"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	oauthStateCookie = "meb_oauth_state"
+	oauthScopes      = "identify guilds.members.read"
+)
+
+var oauthHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// discordMember es la parte que usamos de /users/@me/guilds/{guild}/member
+type discordMember struct {
+	Nick  string   `json:"nick"`
+	Roles []string `json:"roles"`
+	User  struct {
+		ID         string `json:"id"`
+		Username   string `json:"username"`
+		GlobalName string `json:"global_name"`
+	} `json:"user"`
+}
+
+// RegisterAuthRoutes registra las rutas públicas de login y logout
+func RegisterAuthRoutes(router *gin.Engine) {
+	router.GET("/login", handleLoginPage)
+	router.GET("/login/basic", handleBasicLogin)
+	router.GET("/auth/discord", handleDiscordLogin)
+	router.GET("/auth/callback", handleDiscordCallback)
+	router.GET("/logout", handleLogout)
+}
+
+// handleLoginPage muestra la página de login
+func handleLoginPage(c *gin.Context) {
+	if !config.AppConfig.OAuth.Enabled() {
+		c.Redirect(http.StatusSeeOther, "/")
+		return
+	}
+
+	c.HTML(http.StatusOK, "login.html", gin.H{
+		"title": "Iniciar sesión",
+		"error": c.Query("error"),
+	})
+}
+
+// handleBasicLogin permite entrar con el usuario/contraseña del .env y
+// convierte ese acceso en una sesión de administrador
+func handleBasicLogin(c *gin.Context) {
+	user, pass, ok := c.Request.BasicAuth()
+	if !ok || !validAdminCredentials(user, pass) {
+		c.Header("WWW-Authenticate", `Basic realm="Authorization Required"`)
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	if err := setSession(c, panelSession{UserID: basicAuthActor, Username: user, GlobalRole: panelRoleAdmin}); err != nil {
+		c.String(http.StatusInternalServerError, "Error creando sesión")
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/")
+}
+
+// handleDiscordLogin redirige al proveedor OAuth2 de Discord
+func handleDiscordLogin(c *gin.Context) {
+	oauth := config.AppConfig.OAuth
+	if !oauth.Enabled() {
+		c.Redirect(http.StatusSeeOther, "/")
+		return
+	}
+
+	state, err := randomState()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error iniciando login")
+		return
+	}
+	setCookie(c, oauthStateCookie, state, 600)
+
+	params := url.Values{}
+	params.Set("client_id", oauth.ClientID)
+	params.Set("redirect_uri", oauth.RedirectURL)
+	params.Set("response_type", "code")
+	params.Set("scope", oauthScopes)
+	params.Set("state", state)
+	params.Set("prompt", "none")
+
+	c.Redirect(http.StatusFound, oauth.AuthorizeURL+"?"+params.Encode())
+}
+
+// handleDiscordCallback completa el login: canjea el código, consulta los
+// roles del usuario en cada servidor del bot y crea la sesión con su nivel
+// de permiso en cada uno
+func handleDiscordCallback(c *gin.Context) {
+	expected, err := c.Cookie(oauthStateCookie)
+	setCookie(c, oauthStateCookie, "", -1)
+	if err != nil || expected == "" || c.Query("state") != expected {
+		redirectLoginError(c, "La sesión de login expiró, intenta de nuevo")
+		return
+	}
+	if errParam := c.Query("error"); errParam != "" {
+		redirectLoginError(c, "Login cancelado en Discord")
+		return
+	}
+
+	accessToken, err := exchangeOAuthCode(c.Query("code"))
+	if err != nil {
+		log.Printf("Error canjeando código OAuth: %v", err)
+		redirectLoginError(c, "No se pudo completar el login con Discord")
+		return
+	}
+
+	member, guildRoles, err := fetchPanelRoles(accessToken)
+	if err != nil {
+		log.Printf("Error obteniendo miembro del servidor: %v", err)
+		redirectLoginError(c, "No eres miembro de ningún servidor del bot")
+		return
+	}
+	if len(guildRoles) == 0 {
+		log.Printf("🔒 Login denegado para %s: sin roles con acceso al panel", member.User.ID)
+		redirectLoginError(c, "Tus roles en Discord no tienen acceso al panel")
+		return
+	}
+
+	username := member.Nick
+	if username == "" {
+		username = member.User.GlobalName
+	}
+	if username == "" {
+		username = member.User.Username
+	}
+
+	if err := setSession(c, panelSession{UserID: member.User.ID, Username: username, GuildRoles: guildRoles}); err != nil {
+		redirectLoginError(c, "Error creando sesión")
+		return
+	}
+
+	log.Printf("🔓 %s (%s) inició sesión en el panel con %v", username, member.User.ID, guildRoles)
+
+	// Si el servidor elegido no le da acceso, se pasa a uno que sí
+	if _, ok := guildRoles[guilds.Resolve(currentGuild(c))]; !ok {
+		for guildID := range guildRoles {
+			setCookie(c, guildCookieName, guildID, 365*24*60*60)
+			break
+		}
+	}
+	c.Redirect(http.StatusSeeOther, "/")
+}
+
+// handleLogout cierra la sesión del panel
+func handleLogout(c *gin.Context) {
+	clearSession(c)
+	c.Redirect(http.StatusSeeOther, "/login")
+}
+
+// panelRoleForMember traduce los roles de Discord al nivel de permiso más alto.
+// Si no hay roles de lector configurados, cualquier miembro del servidor puede ver.
+func panelRoleForMember(roles []string) string {
+	oauth := config.AppConfig.OAuth
+	switch {
+	case hasAnyRole(roles, oauth.AdminRoles):
+		return panelRoleAdmin
+	case hasAnyRole(roles, oauth.OfficerRoles):
+		return panelRoleOfficer
+	case len(oauth.ViewerRoles) == 0 || hasAnyRole(roles, oauth.ViewerRoles):
+		return panelRoleViewer
+	}
+	return ""
+}
+
+// scopesForPanelRole devuelve los scopes equivalentes a un nivel del panel
+func scopesForPanelRole(role string) []string {
+	switch role {
+	case panelRoleAdmin:
+		return []string{storage.ScopeAdmin}
+	case panelRoleOfficer:
+		return []string{storage.ScopeReadEvents, storage.ScopeWriteEvents, storage.ScopeWriteTemplates}
+	case panelRoleViewer:
+		return []string{storage.ScopeReadEvents}
+	}
+	return nil
+}
+
+func exchangeOAuthCode(code string) (string, error) {
+	if code == "" {
+		return "", fmt.Errorf("código vacío")
+	}
+
+	oauth := config.AppConfig.OAuth
+	form := url.Values{}
+	form.Set("client_id", oauth.ClientID)
+	form.Set("client_secret", oauth.ClientSecret)
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", oauth.RedirectURL)
+
+	resp, err := oauthHTTPClient.PostForm(oauth.TokenURL, form)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("respuesta %d: %s", resp.StatusCode, body)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("respuesta sin access_token")
+	}
+	return token.AccessToken, nil
+}
+
+// fetchPanelRoles consulta al usuario en cada servidor del bot y devuelve el
+// nivel que tiene en cada uno (solo los que dan acceso). El miembro devuelto
+// es el del servidor principal si está en él, para usar su apodo.
+func fetchPanelRoles(accessToken string) (*discordMember, map[string]string, error) {
+	var member *discordMember
+	guildRoles := make(map[string]string)
+	var lastErr error
+
+	for _, settings := range guilds.List() {
+		guildID := guilds.Resolve(settings.GuildID)
+		m, err := fetchGuildMember(accessToken, guildID)
+		if err != nil {
+			// Un 404 solo indica que no es miembro de ese servidor
+			lastErr = err
+			continue
+		}
+		if member == nil || guildID == config.AppConfig.GuildID {
+			member = m
+		}
+		if role := panelRoleForMember(m.Roles); role != "" {
+			guildRoles[guildID] = role
+		}
+	}
+
+	if member == nil {
+		return nil, nil, lastErr
+	}
+	return member, guildRoles, nil
+}
+
+func fetchGuildMember(accessToken, guildID string) (*discordMember, error) {
+	endpoint := strings.TrimSuffix(config.AppConfig.OAuth.APIBaseURL, "/") +
+		"/users/@me/guilds/" + url.PathEscape(guildID) + "/member"
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := oauthHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("respuesta %d", resp.StatusCode)
+	}
+
+	var member discordMember
+	if err := json.NewDecoder(resp.Body).Decode(&member); err != nil {
+		return nil, err
+	}
+	if member.User.ID == "" {
+		return nil, fmt.Errorf("respuesta sin usuario")
+	}
+	return &member, nil
+}
+
+func redirectLoginError(c *gin.Context, message string) {
+	c.Redirect(http.StatusSeeOther, "/login?error="+url.QueryEscape(message))
+}
+
+func hasAnyRole(roles, allowed []string) bool {
+	for _, role := range roles {
+		for _, a := range allowed {
+			if role == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,148 @@
+package web
+
+import (
+	"discord-event-bot/internal/services/profiles"
+	"discord-event-bot/internal/storage"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// characterRequest es el cuerpo aceptado por PUT /api/profiles/:userid/characters
+type characterRequest struct {
+	Username  string `json:"username"`
+	Name      string `json:"name" binding:"required"`
+	Class     string `json:"class" binding:"required"`
+	Role      string `json:"role"`
+	ItemLevel int    `json:"item_level"`
+	Notes     string `json:"notes"`
+	Main      bool   `json:"main"`
+}
+
+// mainCharacterRequest indica el personaje a marcar como main
+type mainCharacterRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// RegisterProfileRoutes registra la página y la API de perfiles de jugadores
+func RegisterProfileRoutes(router *gin.RouterGroup) {
+	router.GET("/api/profiles", handleAPIListProfiles)
+	router.GET("/api/profiles/:userid", handleAPIGetProfile)
+	router.PUT("/api/profiles/:userid/characters", handleAPISaveCharacter)
+	router.DELETE("/api/profiles/:userid/characters/:name", handleAPIRemoveCharacter)
+	router.POST("/api/profiles/:userid/main", handleAPISetMainCharacter)
+
+	router.GET("/profiles", handleProfilesPage)
+}
+
+// handleProfilesPage muestra los personajes de todos los jugadores
+func handleProfilesPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "profiles.html", gin.H{
+		"title":    "Perfiles de Jugadores",
+		"profiles": sortedProfiles(),
+	})
+}
+
+// handleAPIListProfiles retorna todos los perfiles
+func handleAPIListProfiles(c *gin.Context) {
+	all := sortedProfiles()
+	c.JSON(http.StatusOK, gin.H{
+		"profiles": all,
+		"count":    len(all),
+	})
+}
+
+// handleAPIGetProfile retorna el perfil de un jugador
+func handleAPIGetProfile(c *gin.Context) {
+	profile, err := storage.Profiles.GetProfile(c.Param("userid"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "El jugador no tiene perfil"})
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+// handleAPISaveCharacter agrega o actualiza un personaje del jugador
+func handleAPISaveCharacter(c *gin.Context) {
+	if !canEditProfile(c, c.Param("userid")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo puedes modificar tu propio perfil"})
+		return
+	}
+
+	var req characterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	profile, err := profiles.SaveCharacter(profiles.CharacterInput{
+		UserID:    c.Param("userid"),
+		Username:  req.Username,
+		Name:      req.Name,
+		Class:     req.Class,
+		Role:      req.Role,
+		ItemLevel: req.ItemLevel,
+		Notes:     req.Notes,
+		Main:      req.Main,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+// handleAPIRemoveCharacter elimina un personaje del jugador
+func handleAPIRemoveCharacter(c *gin.Context) {
+	if !canEditProfile(c, c.Param("userid")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo puedes modificar tu propio perfil"})
+		return
+	}
+
+	if _, err := profiles.RemoveCharacter(c.Param("userid"), c.Param("name")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Personaje eliminado"})
+}
+
+// handleAPISetMainCharacter marca un personaje como main
+func handleAPISetMainCharacter(c *gin.Context) {
+	if !canEditProfile(c, c.Param("userid")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo puedes modificar tu propio perfil"})
+		return
+	}
+
+	var req mainCharacterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	profile, err := profiles.SetMain(c.Param("userid"), req.Name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+// canEditProfile indica si quien hizo la request puede modificar los
+// personajes de un jugador: un administrador o el mismo jugador logueado con Discord
+func canEditProfile(c *gin.Context, userID string) bool {
+	if isAdmin(c) {
+		return true
+	}
+	value, ok := c.Get(sessionContextKey)
+	return ok && value.(*panelSession).UserID == userID
+}
+
+func sortedProfiles() []*storage.Profile {
+	all := storage.Profiles.GetAllProfiles()
+	sort.Slice(all, func(i, j int) bool {
+		return strings.ToLower(all[i].Username) < strings.ToLower(all[j].Username)
+	})
+	return all
+}
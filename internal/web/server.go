@@ -2,9 +2,15 @@ package web
 
 import (
 	"discord-event-bot/config"
+	"discord-event-bot/internal/services/attendance"
+	"discord-event-bot/internal/services/recurrence"
+	remindersvc "discord-event-bot/internal/services/reminders"
+	"discord-event-bot/internal/storage"
 	"encoding/json"
 	"html/template"
 	"log"
+	"sort"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,15 +33,42 @@ func InitWebServer() {
 			// Lo marcamos como JS para que no escape comillas, etc.
 			return template.JS(b)
 		},
+		// Descripción de la repetición del evento, vacía si es único
+		"recurrence": func(event *storage.Event) string {
+			if !recurrence.IsRecurring(event) {
+				return ""
+			}
+			return recurrence.Describe(recurrence.RuleFor(event))
+		},
+		"attendanceLabel": attendance.Label,
+		"inc": func(i int) int {
+			return i + 1
+		},
+		"join":    strings.Join,
+		"offsets": remindersvc.FormatOffsets,
+		// Pares "clave=valor" ordenados, como los acepta FILL_IN_ROLE_PINGS
+		"pairs": func(values map[string]string) string {
+			pairs := make([]string, 0, len(values))
+			for key, value := range values {
+				pairs = append(pairs, key+"="+value)
+			}
+			sort.Strings(pairs)
+			return strings.Join(pairs, ", ")
+		},
 	})
 
 	// Cargar templates HTML
 	router.LoadHTMLGlob("internal/web/templates/*")
 
-	// Middleware de autenticación básica
-	authorized := router.Group("/", gin.BasicAuth(gin.Accounts{
-		config.AppConfig.AdminUser: config.AppConfig.AdminPass,
-	}))
+	// Login con Discord (rutas públicas)
+	initSessionKey()
+	RegisterAuthRoutes(router)
+
+	// Feeds iCal (rutas públicas, con el token del jugador en la URL)
+	RegisterCalendarRoutes(router)
+
+	// Autenticación: tokens Bearer, sesión de Discord o usuario/contraseña del .env
+	authorized := router.Group("/", guildMiddleware(), authMiddleware(), eventGuildMiddleware())
 
 	// Rutas de eventos
 	authorized.GET("/", handleIndex)
@@ -43,14 +76,36 @@ func InitWebServer() {
 	authorized.GET("/events/create", handleCreateEventPage)
 	authorized.POST("/events/create", handleCreateEventPost)
 	authorized.GET("/events/:id", handleEventDetail)
+	authorized.GET("/events/:id/edit", handleEditEventPage)
+	authorized.POST("/events/:id/edit", handleEditEventPost)
 	authorized.POST("/events/:id/cancel", handleCancelEvent)
 	authorized.POST("/events/:id/confirm/:userid/:role", handleConfirmSignup)
+	authorized.POST("/events/:id/decline/:userid/:role", handleDeclineSignup)
+	authorized.POST("/events/:id/attendance/:userid", handleMarkAttendance)
 	authorized.POST("/events/cleanup-cancelled", handleCleanupCancelledEvents)
 	authorized.GET("/config", handleConfigPage)
 
+	// API de eventos e inscripciones
+	RegisterEventRoutes(authorized)
+
 	// Rutas de templates
 	RegisterTemplateRoutes(authorized)
 
+	// Estadísticas de jugadores
+	RegisterStatsRoutes(authorized)
+
+	// Perfiles y personajes de jugadores
+	RegisterProfileRoutes(authorized)
+
+	// Selector y configuración de servidores
+	RegisterGuildRoutes(authorized)
+
+	// Rutas de backups
+	RegisterBackupRoutes(authorized)
+
+	// Rutas de tokens de API
+	RegisterTokenRoutes(authorized)
+
 	log.Printf("✅ Servidor web iniciado en http://localhost:%s", config.AppConfig.Port)
 }
 
@@ -0,0 +1,129 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"discord-event-bot/config"
+	"discord-event-bot/internal/services/guilds"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	sessionCookieName = "meb_session"
+	sessionDuration   = 12 * time.Hour
+)
+
+// Niveles de permiso del panel para usuarios que entran con Discord
+const (
+	panelRoleViewer  = "viewer"
+	panelRoleOfficer = "officer"
+	panelRoleAdmin   = "admin"
+)
+
+// panelSession es el contenido firmado de la cookie de sesión. Quien entra
+// con Discord tiene un nivel por servidor; el acceso del .env tiene el mismo
+// nivel en todos.
+type panelSession struct {
+	UserID     string            `json:"uid"`
+	Username   string            `json:"name"`
+	GuildRoles map[string]string `json:"guild_roles,omitempty"`
+	GlobalRole string            `json:"global_role,omitempty"`
+	ExpiresAt  int64             `json:"exp"`
+}
+
+// roleIn devuelve el nivel del usuario en un servidor; vacío = sin acceso
+func (s *panelSession) roleIn(guildID string) string {
+	if s.GlobalRole != "" {
+		return s.GlobalRole
+	}
+	return s.GuildRoles[guilds.Resolve(guildID)]
+}
+
+var sessionKey []byte
+
+// initSessionKey prepara la clave HMAC de las cookies. Sin SESSION_SECRET se
+// genera una al azar y las sesiones se pierden al reiniciar el bot.
+func initSessionKey() {
+	if secret := config.AppConfig.OAuth.SessionSecret; secret != "" {
+		sessionKey = []byte(secret)
+		return
+	}
+
+	sessionKey = make([]byte, 32)
+	if _, err := rand.Read(sessionKey); err != nil {
+		log.Fatalf("Error generando clave de sesión: %v", err)
+	}
+	if config.AppConfig.OAuth.Enabled() {
+		log.Println("⚠️ SESSION_SECRET no definido: las sesiones del panel se cerrarán al reiniciar")
+	}
+}
+
+// setSession firma la sesión y la guarda en una cookie HttpOnly
+func setSession(c *gin.Context, session panelSession) error {
+	session.ExpiresAt = time.Now().Add(sessionDuration).Unix()
+
+	payload, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	value := encoded + "." + signSession(encoded)
+
+	setCookie(c, sessionCookieName, value, int(sessionDuration.Seconds()))
+	return nil
+}
+
+// readSession valida la cookie de sesión y devuelve su contenido
+func readSession(c *gin.Context) (*panelSession, error) {
+	value, err := c.Cookie(sessionCookieName)
+	if err != nil || value == "" {
+		return nil, fmt.Errorf("sin sesión")
+	}
+
+	encoded, signature, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(signSession(encoded))) {
+		return nil, fmt.Errorf("firma de sesión inválida")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("sesión inválida")
+	}
+
+	var session panelSession
+	if err := json.Unmarshal(payload, &session); err != nil {
+		return nil, fmt.Errorf("sesión inválida")
+	}
+	if time.Now().Unix() > session.ExpiresAt {
+		return nil, fmt.Errorf("sesión expirada")
+	}
+
+	return &session, nil
+}
+
+// clearSession elimina la cookie de sesión
+func clearSession(c *gin.Context) {
+	setCookie(c, sessionCookieName, "", -1)
+}
+
+func signSession(encoded string) string {
+	mac := hmac.New(sha256.New, sessionKey)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func setCookie(c *gin.Context, name, value string, maxAge int) {
+	secure := strings.HasPrefix(config.AppConfig.OAuth.RedirectURL, "https://")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(name, value, maxAge, "/", "", secure, true)
+}
@@ -0,0 +1,44 @@
+package web
+
+import (
+	"discord-event-bot/internal/services/stats"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterStatsRoutes registra la página y la API de estadísticas de jugadores
+func RegisterStatsRoutes(router *gin.RouterGroup) {
+	router.GET("/api/stats", handleAPIListStats)
+	router.GET("/api/stats/:userid", handleAPIUserStats)
+
+	router.GET("/stats", handleStatsPage)
+}
+
+// handleStatsPage muestra la tabla de confiabilidad de los jugadores
+func handleStatsPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "stats.html", gin.H{
+		"title": "Estadísticas de Jugadores",
+		"stats": stats.Compute(currentGuild(c)),
+	})
+}
+
+// handleAPIListStats retorna las estadísticas de todos los jugadores
+// ordenadas por confiabilidad
+func handleAPIListStats(c *gin.Context) {
+	all := stats.Compute(currentGuild(c))
+	c.JSON(http.StatusOK, gin.H{
+		"stats": all,
+		"count": len(all),
+	})
+}
+
+// handleAPIUserStats retorna las estadísticas de un jugador
+func handleAPIUserStats(c *gin.Context) {
+	st, ok := stats.ForUser(currentGuild(c), c.Param("userid"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "El usuario no tiene inscripciones registradas"})
+		return
+	}
+	c.JSON(http.StatusOK, st)
+}
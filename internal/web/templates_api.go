@@ -1,7 +1,12 @@
 package web
 
 import (
+	eventsvc "discord-event-bot/internal/services/events"
+	"discord-event-bot/internal/services/guilds"
+	remindersvc "discord-event-bot/internal/services/reminders"
 	"discord-event-bot/internal/storage"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"time"
@@ -27,9 +32,10 @@ func RegisterTemplateRoutes(router *gin.RouterGroup) {
 	router.GET("/templates/:name/edit", handleEditTemplatePage)
 }
 
-// handleGetAllTemplates retorna todos los templates
+// handleGetAllTemplates retorna los templates que se pueden usar en el
+// servidor (?guild=): los propios y los compartidos
 func handleGetAllTemplates(c *gin.Context) {
-	templates := storage.Templates.GetAllTemplates()
+	templates := guilds.FilterTemplates(storage.Templates.GetAllTemplates(), currentGuild(c))
 	c.JSON(http.StatusOK, gin.H{
 		"templates": templates,
 		"count":     len(templates),
@@ -38,9 +44,8 @@ func handleGetAllTemplates(c *gin.Context) {
 
 // handleGetTemplate retorna un template específico
 func handleGetTemplate(c *gin.Context) {
-	name := c.Param("name")
-	template, err := storage.Templates.GetTemplate(name)
-	if err != nil {
+	template, ok := guildTemplate(c)
+	if !ok {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Template no encontrado"})
 		return
 	}
@@ -55,6 +60,12 @@ func handleCreateTemplate(c *gin.Context) {
 		return
 	}
 
+	template.GuildID = templateGuild(c, template.GuildID)
+	if err := normalizeTemplate(&template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Agregar timestamps
 	now := time.Now().Format(time.RFC3339)
 	template.CreatedAt = now
@@ -73,14 +84,16 @@ func handleCreateTemplate(c *gin.Context) {
 
 // handleUpdateTemplate actualiza un template existente
 func handleUpdateTemplate(c *gin.Context) {
-	name := c.Param("name")
-
 	// Verificar que el template existe
-	existingTemplate, err := storage.Templates.GetTemplate(name)
-	if err != nil {
+	existingTemplate, ok := guildTemplate(c)
+	if !ok {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Template no encontrado"})
 		return
 	}
+	if !canEditTemplate(c, existingTemplate) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo un administrador puede modificar los templates compartidos"})
+		return
+	}
 
 	var template storage.EventTemplate
 	if err := c.ShouldBindJSON(&template); err != nil {
@@ -88,8 +101,14 @@ func handleUpdateTemplate(c *gin.Context) {
 		return
 	}
 
+	template.GuildID = templateGuild(c, template.GuildID)
+	if err := normalizeTemplate(&template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Mantener el nombre original y createdAt
-	template.Name = name
+	template.Name = existingTemplate.Name
 	template.CreatedAt = existingTemplate.CreatedAt
 	template.UpdatedAt = time.Now().Format(time.RFC3339)
 
@@ -98,6 +117,14 @@ func handleUpdateTemplate(c *gin.Context) {
 		return
 	}
 
+	// Si cambió de servidor (o pasó a ser compartido) se borra la versión anterior
+	if template.Key() != existingTemplate.Key() {
+		if err := storage.Templates.DeleteTemplate(existingTemplate.GuildID, existingTemplate.Name); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":  "Template actualizado exitosamente",
 		"template": template,
@@ -106,9 +133,17 @@ func handleUpdateTemplate(c *gin.Context) {
 
 // handleDeleteTemplate elimina un template
 func handleDeleteTemplate(c *gin.Context) {
-	name := c.Param("name")
+	template, ok := guildTemplate(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template no encontrado"})
+		return
+	}
+	if !canEditTemplate(c, template) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo un administrador puede eliminar los templates compartidos"})
+		return
+	}
 
-	if err := storage.Templates.DeleteTemplate(name); err != nil {
+	if err := storage.Templates.DeleteTemplate(template.GuildID, template.Name); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -120,7 +155,11 @@ func handleDeleteTemplate(c *gin.Context) {
 
 // handleCloneTemplate clona un template existente
 func handleCloneTemplate(c *gin.Context) {
-	sourceName := c.Param("name")
+	source, ok := guildTemplate(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template no encontrado"})
+		return
+	}
 
 	var req struct {
 		NewName string `json:"new_name" binding:"required"`
@@ -131,7 +170,7 @@ func handleCloneTemplate(c *gin.Context) {
 		return
 	}
 
-	if err := storage.Templates.CloneTemplate(sourceName, req.NewName); err != nil {
+	if err := storage.Templates.CloneTemplate(source, req.NewName, templateGuild(c, source.GuildID)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -144,15 +183,19 @@ func handleCloneTemplate(c *gin.Context) {
 
 // handleExportTemplate exporta un template a JSON
 func handleExportTemplate(c *gin.Context) {
-	name := c.Param("name")
+	template, ok := guildTemplate(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template no encontrado"})
+		return
+	}
 
-	data, err := storage.Templates.ExportTemplate(name)
+	data, err := storage.Templates.ExportTemplate(template.GuildID, template.Name)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.Header("Content-Disposition", "attachment; filename="+name+".json")
+	c.Header("Content-Disposition", "attachment; filename="+template.Name+".json")
 	c.Data(http.StatusOK, "application/json", data)
 }
 
@@ -178,7 +221,19 @@ func handleImportTemplate(c *gin.Context) {
 		return
 	}
 
-	if err := storage.Templates.ImportTemplate(data); err != nil {
+	var template storage.EventTemplate
+	if err := json.Unmarshal(data, &template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error parseando JSON: " + err.Error()})
+		return
+	}
+
+	template.GuildID = templateGuild(c, template.GuildID)
+	if err := normalizeTemplate(&template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := storage.Templates.SaveTemplate(&template); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -188,9 +243,57 @@ func handleImportTemplate(c *gin.Context) {
 	})
 }
 
+// guildTemplate busca el template de la URL entre los que se pueden usar en
+// el servidor elegido; los de otros servidores no existen para este panel
+func guildTemplate(c *gin.Context) (*storage.EventTemplate, bool) {
+	template, err := guilds.FindTemplate(currentGuild(c), c.Param("name"))
+	return template, err == nil
+}
+
+// canEditTemplate indica si quien hizo la request puede modificar el
+// template: los compartidos valen para todos los servidores, así que solo
+// los toca un administrador
+func canEditTemplate(c *gin.Context, template *storage.EventTemplate) bool {
+	return template.GuildID != "" || isAdmin(c)
+}
+
+// templateGuild devuelve el servidor donde se guarda un template creado,
+// importado, clonado o editado. Solo un administrador puede dejarlo
+// compartido ("") o guardarlo en un servidor donde también tenga permiso;
+// el resto siempre lo guarda en el servidor elegido.
+func templateGuild(c *gin.Context, requested string) string {
+	if !isAdmin(c) || (requested != "" && !allowedInGuild(c, requested, storage.ScopeWriteTemplates)) {
+		return currentGuild(c)
+	}
+	return requested
+}
+
+// normalizeTemplate valida y normaliza los requisitos, las etapas de
+// recordatorio, el llamado a suplentes y el servidor de un template
+func normalizeTemplate(template *storage.EventTemplate) error {
+	requirements, err := eventsvc.NormalizeRequirements(template.Requirements)
+	if err != nil {
+		return err
+	}
+	template.Requirements = requirements
+
+	if template.ReminderOffsets, err = remindersvc.NormalizeOffsets(template.ReminderOffsets); err != nil {
+		return err
+	}
+
+	if template.FillIn, err = eventsvc.NormalizeFillIn(template.FillIn); err != nil {
+		return err
+	}
+
+	if template.GuildID != "" && !guilds.Known(template.GuildID) {
+		return fmt.Errorf("el bot no está en el servidor %s", template.GuildID)
+	}
+	return nil
+}
+
 // handleTemplatesPage muestra la página de gestión de templates
 func handleTemplatesPage(c *gin.Context) {
-	templates := storage.Templates.GetAllTemplates()
+	templates := guilds.FilterTemplates(storage.Templates.GetAllTemplates(), currentGuild(c))
 	c.HTML(http.StatusOK, "templates.html", gin.H{
 		"title":     "Gestión de Templates",
 		"templates": templates,
@@ -203,14 +306,15 @@ func handleCreateTemplatePage(c *gin.Context) {
 		"title":    "Crear Template",
 		"mode":     "create",
 		"template": nil,
+		"guild":    currentGuild(c),
+		"admin":    isAdmin(c),
 	})
 }
 
 // handleEditTemplatePage muestra el formulario de edición de template
 func handleEditTemplatePage(c *gin.Context) {
-	name := c.Param("name")
-	template, err := storage.Templates.GetTemplate(name)
-	if err != nil {
+	template, ok := guildTemplate(c)
+	if !ok {
 		c.HTML(http.StatusNotFound, "error.html", gin.H{
 			"title": "Error",
 			"error": "Template no encontrado",
@@ -219,8 +323,10 @@ func handleEditTemplatePage(c *gin.Context) {
 	}
 
 	c.HTML(http.StatusOK, "template_editor.html", gin.H{
-		"title":    "Editar Template: " + name,
+		"title":    "Editar Template: " + template.Name,
 		"mode":     "edit",
 		"template": template,
+		"guild":    currentGuild(c),
+		"admin":    isAdmin(c),
 	})
 }
@@ -0,0 +1,111 @@
+package web
+
+import (
+	"discord-event-bot/internal/storage"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tokenView es la representación pública de un token (sin el hash)
+type tokenView struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Hint       string     `json:"hint"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	CreatedBy  string     `json:"created_by"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// createTokenRequest es el cuerpo aceptado por POST /api/tokens
+type createTokenRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required"`
+}
+
+// RegisterTokenRoutes registra la página y la API de tokens
+func RegisterTokenRoutes(router *gin.RouterGroup) {
+	router.GET("/api/tokens", handleListTokens)
+	router.POST("/api/tokens", handleCreateToken)
+	router.DELETE("/api/tokens/:id", handleRevokeToken)
+
+	router.GET("/tokens", handleTokensPage)
+}
+
+// handleTokensPage muestra la página de gestión de tokens
+func handleTokensPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "tokens.html", gin.H{
+		"title":  "Tokens de API",
+		"tokens": listTokenViews(),
+		"scopes": storage.AllScopes,
+	})
+}
+
+// handleListTokens retorna todos los tokens
+func handleListTokens(c *gin.Context) {
+	tokens := listTokenViews()
+	c.JSON(http.StatusOK, gin.H{
+		"tokens": tokens,
+		"count":  len(tokens),
+	})
+}
+
+// handleCreateToken crea un token y devuelve el secreto una única vez
+func handleCreateToken(c *gin.Context) {
+	var req createTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	token, secret, err := storage.Tokens.CreateToken(req.Name, req.Scopes, requestActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Token creado. Guárdalo ahora: no se volverá a mostrar",
+		"token":   newTokenView(token),
+		"secret":  secret,
+	})
+}
+
+// handleRevokeToken revoca un token
+func handleRevokeToken(c *gin.Context) {
+	if err := storage.Tokens.RevokeToken(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Token revocado"})
+}
+
+func listTokenViews() []tokenView {
+	tokens := storage.Tokens.GetAllTokens()
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].CreatedAt.After(tokens[j].CreatedAt)
+	})
+
+	views := make([]tokenView, 0, len(tokens))
+	for _, token := range tokens {
+		views = append(views, newTokenView(token))
+	}
+	return views
+}
+
+func newTokenView(token *storage.APIToken) tokenView {
+	return tokenView{
+		ID:         token.ID,
+		Name:       token.Name,
+		Hint:       token.Hint,
+		Scopes:     token.Scopes,
+		CreatedAt:  token.CreatedAt,
+		CreatedBy:  token.CreatedBy,
+		LastUsedAt: token.LastUsedAt,
+		RevokedAt:  token.RevokedAt,
+	}
+}